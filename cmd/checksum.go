@@ -0,0 +1,219 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/checksum"
+    "github.com/helviojunior/pcapraptor/pkg/pcapw"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var checksumOpts = struct {
+    verify      bool
+    fix         bool
+}{}
+
+var checksumCmd = &cobra.Command{
+    Use:   "checksum",
+    Short: "Verify or fix IP/TCP/UDP/ICMP checksums of PCAP packets",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# checksum
+
+Verify (--verify) or recompute (--fix) the IPv4, TCP, UDP, ICMPv4 and
+ICMPv6 checksums.
+
+Checksums zeroed or holding just the pseudo header sum are reported as
+NIC checksum offload, usually the packets sent by the capture host.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor checksum --pcap data.pcap --verify
+   - pcapraptor checksum --pcap data.pcap --fix --output-file fixed.pcap`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if checksumOpts.verify && checksumOpts.fix {
+            return errors.New("--verify and --fix cannot be used together")
+        }
+
+        if !checksumOpts.fix {
+            checksumOpts.verify = true
+            return nil
+        }
+
+        if pcapFiles.toFile == "" {
+            ext := filepath.Ext(pcapFiles.fromFile)
+            pcapFiles.toFile = strings.TrimSuffix(pcapFiles.fromFile, ext) + "_fixed" + ext
+        }
+
+        if pcapFiles.toFile, err = checkDestinationFile(pcapFiles.toFile); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        var w *pcapw.Writer
+        var fixed, fixedPackets int64
+
+        counters := map[checksum.Protocol]map[checksum.Status]int64{}
+        for _, p := range checksum.Protocols {
+            counters[p] = map[checksum.Status]int64{}
+        }
+        offloadSources := map[string]int64{}
+        badSources := map[string]int64{}
+
+        if checksumOpts.fix {
+            log.Infof("Fixing checksums to %s", pcapFiles.toFile)
+        }
+
+        packets, err := readPcapFile("Checking checksums ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            var err error
+            lt := layers.LinkType(r.Header.Network)
+
+            var buf []byte
+            var packet gopacket.Packet
+            if checksumOpts.fix {
+                if w == nil {
+                    if w, err = pcapw.Open(pcapFiles.toFile, r.Header); err != nil {
+                        return err
+                    }
+                }
+                buf = make([]byte, len(data))
+                copy(buf, data)
+                packet = gopacket.NewPacket(buf, lt, gopacket.NoCopy)
+            } else {
+                packet = gopacket.NewPacket(data, lt, gopacket.NoCopy)
+            }
+
+            results := checksum.Verify(packet)
+            for _, res := range results {
+                counters[res.Protocol][res.Status]++
+                switch res.Status {
+                case checksum.StatusOffload:
+                    offloadSources[res.Source.String()]++
+                case checksum.StatusBad:
+                    badSources[res.Source.String()]++
+                }
+            }
+
+            if !checksumOpts.fix {
+                return nil
+            }
+
+            if n := checksum.Fix(packet); n > 0 {
+                fixed += int64(n)
+                fixedPackets++
+                return w.WritePacket(h, buf)
+            }
+            return w.WritePacket(h, data)
+        })
+        if w != nil {
+            w.Close()
+        }
+        if err != nil {
+            log.Error("Error checking PCAP file", "err", err)
+            os.Exit(2)
+        }
+
+        st := "Checksum results\n"
+        st += fmt.Sprintf("     %-8s %12s %12s %12s %12s %12s\n", "Proto", "Ok", "Bad", "Offload", "Disabled", "Truncated")
+        for _, p := range checksum.Protocols {
+            c := counters[p]
+            st += fmt.Sprintf("     %-8s %12s %12s %12s %12s %12s\n", p,
+                tools.FormatInt64Comma(c[checksum.StatusOk]),
+                tools.FormatInt64Comma(c[checksum.StatusBad]),
+                tools.FormatInt64Comma(c[checksum.StatusOffload]),
+                tools.FormatInt64Comma(c[checksum.StatusDisabled]),
+                tools.FormatInt64Comma(c[checksum.StatusTruncated]),
+            )
+        }
+        log.Info(st)
+
+        if len(offloadSources) > 0 {
+            log.Warn("Checksum offload pattern found, probably packets sent by the capture host")
+            printSources("Offload sources", offloadSources)
+        }
+        if len(badSources) > 0 {
+            printSources("Bad checksum sources", badSources)
+        }
+
+        if checksumOpts.fix {
+            log.Infof("Fixed %s checksums in %s packets", tools.FormatInt64Comma(fixed), tools.FormatInt64Comma(fixedPackets))
+        }
+
+        printElapsed("Checksum status", packets)
+    },
+}
+
+// printSources logs the top 10 source addresses of a counter map
+func printSources(title string, sources map[string]int64) {
+    addrs := []string{}
+    for a := range sources {
+        addrs = append(addrs, a)
+    }
+    sort.Slice(addrs, func(i, j int) bool {
+        if sources[addrs[i]] == sources[addrs[j]] {
+            return addrs[i] < addrs[j]
+        }
+        return sources[addrs[i]] > sources[addrs[j]]
+    })
+    if len(addrs) > 10 {
+        addrs = addrs[:10]
+    }
+
+    st := title + "\n"
+    for _, a := range addrs {
+        st += fmt.Sprintf("     -> %-40s %s\n", a, tools.FormatInt64Comma(sources[a]))
+    }
+    log.Info(st)
+}
+
+func init() {
+    rootCmd.AddCommand(checksumCmd)
+
+    checksumCmd.Flags().StringVarP(&pcapFiles.toFile, "output-file", "o", "", "The file to write fixed PCAP data to (default: <source>_fixed.pcap)")
+    checksumCmd.Flags().BoolVar(&checksumOpts.verify, "verify", false, "Verify checksums (default)")
+    checksumCmd.Flags().BoolVar(&checksumOpts.fix, "fix", false, "Recompute checksums and write a fixed PCAP file")
+}
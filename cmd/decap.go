@@ -0,0 +1,192 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/pkg/pcapw"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var decapOpts = struct {
+    encaps       string
+    rawIP        bool
+    onlyTunneled bool
+    set          decap.Set
+}{}
+
+// decapFlag holds the --decap option of analysis commands
+var decapFlag string
+
+var decapCmd = &cobra.Command{
+    Use:   "decap",
+    Short: "Strip tunnel encapsulation layers from PCAP packets",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# decap
+
+Strip encapsulation layers (GRE, ERSPAN, VXLAN, GENEVE, MPLS and
+802.1Q/QinQ) and write the inner frames. The dot11 encapsulation turns
+the unprotected (or decrypted) 802.11 data frames of Radiotap or plain
+802.11 captures into Ethernet frames.
+
+Inner frames are written as Ethernet, tunnels carrying plain IP get an
+Ethernet header built from the outer one. Use --raw-ip to write all
+frames as raw IP (LINKTYPE_RAW) instead.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor decap --pcap data.pcap
+   - pcapraptor decap --pcap data.pcap --encap vxlan,vlan --output-file inner.pcap
+   - pcapraptor decap --pcap data.pcap --encap gre,erspan --only-tunneled`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if pcapFiles.toFile == "" {
+            ext := filepath.Ext(pcapFiles.fromFile)
+            pcapFiles.toFile = strings.TrimSuffix(pcapFiles.fromFile, ext) + "_decap" + ext
+        }
+
+        if pcapFiles.toFile, err = checkDestinationFile(pcapFiles.toFile); err != nil {
+            return err
+        }
+
+        if decapOpts.set, err = decap.ParseSet(decapOpts.encaps); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        var w *pcapw.Writer
+        var stripped, dropped int64
+
+        log.Infof("Stripping %s encapsulations to %s", decapOpts.set.String(), pcapFiles.toFile)
+
+        packets, err := readPcapFile("Decapsulating ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            var err error
+
+            if w == nil {
+                fh := r.Header
+                fh.Network = uint32(layers.LinkTypeEthernet)
+                if decapOpts.rawIP {
+                    fh.Network = uint32(layers.LinkTypeRaw)
+                }
+                if w, err = pcapw.Open(pcapFiles.toFile, fh); err != nil {
+                    return err
+                }
+            }
+
+            inner, lt, n := decap.Strip(data, layers.LinkType(r.Header.Network), decapOpts.set)
+            if n == 0 && decapOpts.onlyTunneled {
+                dropped++
+                return nil
+            }
+            if n > 0 {
+                stripped++
+            }
+
+            if decapOpts.rawIP {
+                var ok bool
+                if inner, ok = decap.ToRawIP(inner, lt); !ok {
+                    dropped++
+                    return nil
+                }
+            } else if lt != layers.LinkTypeEthernet {
+                dropped++
+                return nil
+            }
+
+            h.CaptureLen = int32(len(inner))
+            h.OriginalLen = h.OriginalLen - int32(len(data) - len(inner))
+            if h.OriginalLen < h.CaptureLen {
+                h.OriginalLen = h.CaptureLen
+            }
+
+            return w.WritePacket(h, inner)
+        })
+        if w != nil {
+            w.Close()
+        }
+        if err != nil {
+            log.Error("Error decapsulating PCAP file", "err", err)
+            os.Exit(2)
+        }
+
+        st := "Decap results\n"
+        st += "     -> Decapsulated.......: %s\n"
+        st += "     -> Dropped............: %s\n"
+
+        log.Infof(st,
+            tools.FormatInt64Comma(stripped),
+            tools.FormatInt64Comma(dropped),
+        )
+
+        printElapsed("Decap status", packets)
+    },
+}
+
+// addDecapFlag adds the --decap option used to look inside tunnels
+func addDecapFlag(cmd *cobra.Command) {
+    cmd.Flags().StringVar(&decapFlag, "decap", "", "Look inside tunnels, comma-separated encapsulations (vlan,mpls,gre,erspan,vxlan,geneve,dot11 or all)")
+    cmd.Flags().Lookup("decap").NoOptDefVal = "all"
+}
+
+// setupDecap enables the --decap encapsulations on decap.NewPacket
+func setupDecap() error {
+    if decapFlag == "" {
+        return nil
+    }
+    s, err := decap.ParseSet(decapFlag)
+    if err != nil {
+        return err
+    }
+    decap.SetDefault(s)
+    log.Infof("Looking inside %s tunnels", s.String())
+    return nil
+}
+
+func init() {
+    rootCmd.AddCommand(decapCmd)
+
+    decapCmd.Flags().StringVarP(&pcapFiles.toFile, "output-file", "o", "", "The file to write inner frames to (default: <source>_decap.pcap)")
+    decapCmd.Flags().StringVarP(&decapOpts.encaps, "encap", "e", "all", "Comma-separated encapsulations to strip (vlan,mpls,gre,erspan,vxlan,geneve,dot11 or all)")
+    decapCmd.Flags().BoolVar(&decapOpts.rawIP, "raw-ip", false, "Write inner frames as raw IP (LINKTYPE_RAW)")
+    decapCmd.Flags().BoolVar(&decapOpts.onlyTunneled, "only-tunneled", false, "Drop packets without any stripped encapsulation")
+}
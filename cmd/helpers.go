@@ -0,0 +1,306 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/dnsmap"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/helviojunior/pcapraptor/pkg/inventory"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/helviojunior/pcapraptor/pkg/ntpcalc"
+    "github.com/spf13/cobra"
+    resolver "github.com/helviojunior/gopathresolver"
+)
+
+// checkSourceFile resolves and validates the --pcap source file
+func checkSourceFile() error {
+    var err error
+
+    if pcapFiles.fromFile == "" {
+        return errors.New("from file not set")
+    }
+    pcapFiles.fromFile, err = resolver.ResolveFullPath(pcapFiles.fromFile)
+    if err != nil {
+        return err
+    }
+
+    pcapFiles.fromExt = strings.ToLower(filepath.Ext(pcapFiles.fromFile))
+
+    if pcapFiles.fromExt == "" {
+        return errors.New("source files must have extensions")
+    }
+
+    if !tools.SliceHasStr(pcapExtensions, pcapFiles.fromExt) {
+        return errors.New(fmt.Sprintf("unsupported from (%s) file type", pcapFiles.fromExt))
+    }
+
+    return nil
+}
+
+// checkDestinationFile resolves a PCAP destination file and checks that
+// it does not exist yet
+func checkDestinationFile(fileName string) (string, error) {
+    var err error
+
+    fileName, err = resolver.ResolveFullPath(fileName)
+    if err != nil {
+        return "", err
+    }
+
+    ext := strings.ToLower(filepath.Ext(fileName))
+    if ext == "" {
+        return "", errors.New("destination files must have extensions")
+    }
+
+    if !tools.SliceHasStr(pcapExtensions, ext) {
+        return "", errors.New(fmt.Sprintf("unsupported to (%s) file type", ext))
+    }
+
+    if fileName == pcapFiles.fromFile {
+        return "", errors.New("👀 source and destination files cannot be the same")
+    }
+
+    if isv, err := resolver.IsValidAndNotExists(fileName); !isv {
+        return "", err
+    }
+
+    return fileName, nil
+}
+
+// checkReportFile resolves a report destination file and checks that
+// it does not exist yet
+func checkReportFile(fileName string) (string, error) {
+    var err error
+
+    fileName, err = resolver.ResolveFullPath(fileName)
+    if err != nil {
+        return "", err
+    }
+
+    if fileName == pcapFiles.fromFile {
+        return "", errors.New("👀 source and report files cannot be the same")
+    }
+
+    if isv, err := resolver.IsValidAndNotExists(fileName); !isv {
+        return "", err
+    }
+
+    return fileName, nil
+}
+
+// timeShift holds the options used to correct packet timestamps
+var timeShift = struct {
+    useNtp bool
+    value  string
+}{}
+
+// getTimeShift returns the time correction to apply to packet timestamps,
+// either from --time-shift or calculated from NTP data (--ntp)
+func getTimeShift() (*time.Duration, error) {
+    if timeShift.value != "" {
+        d, err := time.ParseDuration(timeShift.value)
+        if err != nil {
+            return nil, errors.New(fmt.Sprintf("invalid time shift (%s): %s", timeShift.value, err))
+        }
+        return &d, nil
+    }
+
+    if timeShift.useNtp {
+        log.Infof("Looking for NTP data into pcap file, this can take a while. Please be patient.")
+        return ntpcalc.GetFileDelta(pcapFiles.fromFile)
+    }
+
+    return nil, nil
+}
+
+// PacketFunc is called for every packet read by readPcapFile
+type PacketFunc func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error
+
+// startStatus shows the progress spinner until the returned function
+// is called
+func startStatus(status *ConvStatus) func() {
+    running := true
+    wg := sync.WaitGroup{}
+
+    ascii.HideCursor()
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for running {
+            status.Print()
+            time.Sleep(time.Duration(time.Second/6))
+        }
+    }()
+
+    return func() {
+        running = false
+        wg.Wait()
+
+        fmt.Fprintf(os.Stderr, "%s\n%s\r\033[A",
+            "                                                                                ",
+            "                                                                                ",
+        )
+        ascii.ClearLine()
+        ascii.ShowCursor()
+    }
+}
+
+// readPcapFile loops over all packets of the source file showing the
+// progress spinner while doing it
+func readPcapFile(label string, fn PacketFunc) (int, error) {
+    var status = &ConvStatus{
+        Packets: 0,
+        Label: label,
+        ShowCounter: true,
+        Spin: "",
+    }
+
+    r, err := gopcap.Open(pcapFiles.fromFile)
+    if err != nil {
+        return 0, err
+    }
+    defer r.Close()
+
+    stop := startStatus(status)
+    defer stop()
+
+    for {
+        h, data, err := r.ReadNextPacket()
+        if err != nil {
+            if err != io.EOF {
+                return status.Packets, err
+            }
+            return status.Packets, nil
+        }
+
+        status.Packets++
+
+        if err := fn(r, h, data); err != nil {
+            return status.Packets, err
+        }
+    }
+}
+
+// printElapsed logs the elapsed time and the number of analysed packets
+func printElapsed(title string, packets int) {
+    ediff := time.Now().Sub(startTime)
+    out := time.Time{}.Add(ediff)
+
+    st := title + "\n"
+    st += "     -> Elapsed time.......: %s\n"
+    st += "     -> Packets analysed..: %s\n"
+
+    log.Infof(st,
+        out.Format("15:04:05"),
+        tools.FormatIntComma(packets),
+    )
+}
+
+var scopeRanges = struct {
+    file   string
+    ranges []string
+    set    *netcalc.Ranges
+}{ set: &netcalc.Ranges{} }
+
+func addRangesFlags(cmd *cobra.Command) {
+    cmd.Flags().StringVar(&scopeRanges.file, "ranges", "", "Ranges file with internal, deny, exclude and scope ranges")
+    cmd.Flags().StringArrayVar(&scopeRanges.ranges, "range", []string{}, "Range (can be repeated), same syntax of the ranges file (e.g. \"scope 10.0.0.0/8\")")
+}
+
+// setupRanges loads the --ranges file and --range flags, adding the
+// internal and deny ranges to subnet discovery
+func setupRanges() error {
+    var err error
+
+    if scopeRanges.file != "" {
+        if scopeRanges.file, err = resolver.ResolveFullPath(scopeRanges.file); err != nil {
+            return err
+        }
+        if scopeRanges.set, err = netcalc.LoadRanges(scopeRanges.file); err != nil {
+            return err
+        }
+    }
+    for _, r := range scopeRanges.ranges {
+        if err = scopeRanges.set.AddRange(r); err != nil {
+            return err
+        }
+    }
+    scopeRanges.set.Apply()
+
+    if c := scopeRanges.set.Count(); c > 0 {
+        log.Infof("Using %d custom ranges", c)
+    }
+    return nil
+}
+
+// nameResolver holds the --resolver hosts file used to label addresses
+// with the names found by locate dns
+var nameResolver = struct {
+    file   string
+    names  dnsmap.Resolver
+}{}
+
+// addResolverFlag adds the --resolver flag to the command
+func addResolverFlag(cmd *cobra.Command) {
+    cmd.Flags().StringVar(&nameResolver.file, "resolver", "", "Hosts file used to label addresses with names (see locate dns --resolver-file)")
+}
+
+// setupResolver loads the --resolver file
+func setupResolver() error {
+    var err error
+
+    if nameResolver.file == "" {
+        return nil
+    }
+    if nameResolver.file, err = resolver.ResolveFullPath(nameResolver.file); err != nil {
+        return err
+    }
+    if nameResolver.names, err = dnsmap.LoadResolver(nameResolver.file); err != nil {
+        return err
+    }
+    log.Infof("Using %d resolver entries", len(nameResolver.names))
+    return nil
+}
+
+// resolveHosts adds the --resolver names to the hosts
+func resolveHosts(hosts []inventory.Host) {
+    for i := range hosts {
+        for _, ip := range hosts[i].IPs {
+            for _, name := range nameResolver.names.Names(ip) {
+                found := false
+                for _, n := range hosts[i].Hostnames {
+                    if strings.EqualFold(n.Name, name) {
+                        found = true
+                        break
+                    }
+                }
+                if !found {
+                    hosts[i].Hostnames = append(hosts[i].Hostnames, inventory.Name{ Name: name, Source: "resolver" })
+                }
+            }
+        }
+    }
+}
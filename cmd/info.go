@@ -0,0 +1,167 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/capinfo"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/helviojunior/pcapraptor/pkg/report"
+    "github.com/spf13/cobra"
+)
+
+var infoOpts = struct {
+    top        int
+}{}
+
+var infoCmd = &cobra.Command{
+    Use:   "info",
+    Short: "Show capture file statistics",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# info
+
+Show capture file statistics: file format, link type, packet and byte
+counters, time range (raw and corrected), average rates, out-of-order
+timestamps, protocol hierarchy and top talkers.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor info --pcap data.pcap
+   - pcapraptor info --pcap data.pcap --ntp
+   - pcapraptor info --pcap data.pcap --time-shift 2h30m --format json
+   - pcapraptor info --pcap data.pcap --format json --report-file info.json`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        var collector *capinfo.Collector
+        packets, err := readPcapFile("Reading packets ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            if collector == nil {
+                collector = capinfo.NewCollector(pcapFiles.fromFile, r)
+                collector.TopCount = infoOpts.top
+            }
+            collector.Add(h, data)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        if collector == nil {
+            // valid file without packets, report just the header
+            r, err := gopcap.Open(pcapFiles.fromFile)
+            if err != nil {
+                log.Error("PCAP read error:", "err", err)
+                os.Exit(2)
+            }
+            collector = capinfo.NewCollector(pcapFiles.fromFile, r)
+            collector.TopCount = infoOpts.top
+            r.Close()
+        }
+
+        stats := collector.Stats(shift)
+
+        if writeOutput(stats, infoText(stats)) {
+            return
+        }
+
+        printElapsed("Info status", packets)
+    },
+}
+
+func infoText(st *capinfo.Stats) string {
+    tf := "2006-01-02 15:04:05.000000 MST"
+
+    txt := "Capture file information\n"
+    txt += fmt.Sprintf("     -> File...............: %s\n", st.File)
+    txt += fmt.Sprintf("     -> Format.............: %s %s (%s, %s)\n", st.Format, st.Version, st.Endianness, st.Resolution)
+    txt += fmt.Sprintf("     -> Link type..........: %s (%d)\n", st.LinkType, st.LinkTypeId)
+    txt += fmt.Sprintf("     -> Snaplen............: %d\n", st.Snaplen)
+    txt += fmt.Sprintf("     -> Packets............: %s\n", tools.FormatInt64Comma(st.Packets))
+    txt += fmt.Sprintf("     -> Captured bytes.....: %s (%s)\n", tools.FormatInt64Comma(st.CapturedBytes), tools.Bytes(uint64(st.CapturedBytes)))
+    txt += fmt.Sprintf("     -> Original bytes.....: %s (%s)\n", tools.FormatInt64Comma(st.OriginalBytes), tools.Bytes(uint64(st.OriginalBytes)))
+    txt += fmt.Sprintf("     -> Truncated packets..: %s\n", tools.FormatInt64Comma(st.Truncated))
+    if st.Packets > 0 {
+        txt += fmt.Sprintf("     -> First packet.......: %s\n", st.Raw.First.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Last packet........: %s\n", st.Raw.Last.UTC().Format(tf))
+    }
+    txt += fmt.Sprintf("     -> Duration...........: %s\n", tools.FormatDuration(time.Duration(st.Raw.Duration * float64(time.Second))))
+    if st.Corrected != nil && st.Packets > 0 {
+        txt += fmt.Sprintf("     -> Time shift.........: %s\n", tools.FormatDuration(time.Duration(st.TimeShift * float64(time.Second))))
+        txt += fmt.Sprintf("     -> Corrected first....: %s\n", st.Corrected.First.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Corrected last.....: %s\n", st.Corrected.Last.UTC().Format(tf))
+    }
+    txt += fmt.Sprintf("     -> Avg packet size....: %.2f bytes\n", st.AvgPacketSize)
+    txt += fmt.Sprintf("     -> Avg packet rate....: %.2f packets/s\n", st.AvgPacketRate)
+    txt += fmt.Sprintf("     -> Avg data rate......: %s/s (%.2f bits/s)\n", tools.Bytes(uint64(st.AvgByteRate)), st.AvgBitRate)
+    txt += fmt.Sprintf("     -> Out-of-order.......: %s (max backward jump %.6fs)\n", tools.FormatInt64Comma(st.OutOfOrder), st.MaxBackwardJump)
+
+    txt += "\n     Protocol hierarchy\n"
+    txt += st.ProtocolTree()
+
+    txt += "\n     Top talkers\n"
+    for i, t := range st.TopTalkers {
+        txt += fmt.Sprintf("     %02d. %-40s %10d pkts %14s bytes\n", i + 1, t.Address, t.Packets, tools.FormatInt64Comma(t.Bytes))
+    }
+
+    return txt
+}
+
+func init() {
+    rootCmd.AddCommand(infoCmd)
+
+    addOutputFlags(infoCmd, report.FormatText, report.FormatJSON, report.FormatJSONL)
+    infoCmd.Flags().IntVar(&infoOpts.top, "top", 10, "Number of top talkers to list")
+    infoCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    infoCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on corrected times (e.g. 2h30m, -15m)")
+}
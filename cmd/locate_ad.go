@@ -0,0 +1,182 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/adrecon"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var locateAdCmd = &cobra.Command{
+    Use:   "ad",
+    Short: "Identify the Active Directory domains, controllers and users found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate ad
+
+Active Directory reconnaissance report of the capture: domain and forest
+names, NetBIOS domain names, domain GUID and sites, the domain
+controllers and their roles, the user principal names seen at Kerberos
+AS-REQs (with the KDC answer, e.g. unknown user or pre-authentication
+not required), the service principal names requested and the domain
+member computers.
+
+The data comes from Kerberos, LDAP rootDSE and CLDAP netlogon ping
+responses, NTLM challenges (SMB, LDAP, DCE/RPC and others) and the AD
+DNS SRV records (e.g. _ldap._tcp.dc._msdcs).
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate ad --pcap data.pcap
+   - pcapraptor locate ad --pcap data.pcap --format json
+   - pcapraptor locate ad --pcap data.pcap --format csv --report-file ad.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        collector := adrecon.NewCollector()
+        packets, err := readPcapFile("Getting AD data ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            ts := r.Header.PacketTime(h)
+            if shift != nil {
+                ts = ts.Add(*shift)
+            }
+            collector.Add(decap.NewPacket(data, layers.LinkType(r.Header.Network)), ts)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        rpt := collector.Report()
+
+        if writeOutput(rpt, adText(rpt)) {
+            return
+        }
+
+        log.Infof("%d domains, %d controllers and %d users found", len(rpt.Domains), len(rpt.Controllers), len(rpt.Users))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func adText(rpt *adrecon.Report) string {
+    txt := "Active Directory\n"
+    for i, d := range rpt.Domains {
+        txt += fmt.Sprintf("\n     %04d. %s\n", i + 1, d.Name)
+        if d.NetBIOS != "" {
+            txt += fmt.Sprintf("     -> NetBIOS domain.....: %s\n", d.NetBIOS)
+        }
+        if d.Forest != "" {
+            txt += fmt.Sprintf("     -> Forest.............: %s\n", d.Forest)
+        }
+        if d.GUID != "" {
+            txt += fmt.Sprintf("     -> GUID...............: %s\n", d.GUID)
+        }
+        if len(d.Sites) > 0 {
+            txt += fmt.Sprintf("     -> Sites..............: %s\n", strings.Join(d.Sites, ", "))
+        }
+        if len(d.Controllers) > 0 {
+            txt += fmt.Sprintf("     -> Controllers........: %s\n", strings.Join(d.Controllers, ", "))
+        }
+        txt += fmt.Sprintf("     -> Sources............: %s\n", strings.Join(d.Sources, ", "))
+    }
+
+    if len(rpt.Controllers) > 0 {
+        txt += "\n     Domain controllers\n"
+        for _, dc := range rpt.Controllers {
+            name := dc.Hostname
+            if dc.NetBIOS != "" {
+                name = strings.TrimSpace(name + " (" + dc.NetBIOS + ")")
+            }
+            txt += fmt.Sprintf("     %-39s %-45s %-20s %s\n", dc.IP, name, dc.Domain, strings.Join(dc.Roles, ", "))
+        }
+    }
+
+    if len(rpt.Users) > 0 {
+        txt += "\n     Users\n"
+        for _, u := range rpt.Users {
+            txt += fmt.Sprintf("     %-50s %5d requests  %s\n", u.Principal, u.Requests, strings.Join(u.Status, ", "))
+        }
+    }
+
+    if len(rpt.Services) > 0 {
+        txt += "\n     Services\n"
+        for _, s := range rpt.Services {
+            txt += fmt.Sprintf("     %-60s %s\n", s.SPN, s.Realm)
+        }
+    }
+
+    if len(rpt.Computers) > 0 {
+        txt += "\n     Computers\n"
+        for _, cp := range rpt.Computers {
+            txt += fmt.Sprintf("     %-39s %-16s %-45s %s\n", cp.IP, cp.NetBIOS, cp.Hostname, cp.Domain)
+        }
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateAdCmd)
+
+    addOutputFlags(locateAdCmd)
+    locateAdCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateAdCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateAdCmd)
+}
@@ -0,0 +1,190 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/helviojunior/pcapraptor/pkg/credentials"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+    resolver "github.com/helviojunior/gopathresolver"
+)
+
+var credentialsOpts = struct {
+    redact          bool
+    hashcatDir      string
+}{}
+
+var locateCredentialsCmd = &cobra.Command{
+    Use:   "credentials",
+    Short: "Extract clear text credentials and crackable authentication exchanges found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate credentials
+
+Extract the credentials of each flow: FTP, Telnet, HTTP Basic, POP3,
+IMAP and SMTP AUTH (PLAIN and LOGIN) user names and passwords, SNMP v1
+and v2c community strings, NTLMv1/v2 challenge-responses (SMB, HTTP,
+LDAP and others) and Kerberos AS-REP, pre-authentication and RC4 service
+ticket (TGS-REP) hashes, Kerberos over TCP messages are reassembled. The
+server answer, when seen, tells if the authentication succeeded.
+
+The NTLM and Kerberos hashes are written in the hashcat format, one file
+per hashcat mode, with --hashcat-dir. Use --redact to hide the passwords,
+the secret part of the hashes and the URL query strings at the report
+(the --hashcat-dir files always keep them).
+
+Use it only on captures you are authorized to analyse.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate credentials --pcap data.pcap
+   - pcapraptor locate credentials --pcap data.pcap --hashcat-dir hashes/
+   - pcapraptor locate credentials --pcap data.pcap --redact --format csv --report-file credentials.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if credentialsOpts.hashcatDir != "" {
+            if credentialsOpts.hashcatDir, err = resolver.ResolveFullPath(credentialsOpts.hashcatDir); err != nil {
+                return err
+            }
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        collector := credentials.NewCollector()
+        packets, err := readPcapFile("Getting credentials ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            ts := r.Header.PacketTime(h)
+            if shift != nil {
+                ts = ts.Add(*shift)
+            }
+            collector.Add(decap.NewPacket(data, layers.LinkType(r.Header.Network)), ts)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        creds := credentials.Credentials(collector.Credentials())
+
+        if credentialsOpts.hashcatDir != "" {
+            files, err := creds.WriteHashcat(credentialsOpts.hashcatDir)
+            if err != nil {
+                log.Error("Error writing hashcat files", "err", err)
+                os.Exit(2)
+            }
+            for _, f := range files {
+                log.Infof("Hashes saved to %s", f)
+            }
+        }
+
+        if credentialsOpts.redact {
+            creds.Redact()
+        }
+
+        if writeOutput(creds, credentialsText(creds)) {
+            return
+        }
+
+        log.Infof("%d credentials found", len(creds))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func credentialsText(creds credentials.Credentials) string {
+    txt := "Credentials\n"
+    for i, c := range creds {
+        txt += fmt.Sprintf("\n     %04d. %s %s -> %s\n", i + 1, c.Protocol, c.Client, c.Server)
+        if c.Username != "" {
+            user := c.Username
+            if c.Domain != "" {
+                user = c.Domain + "\\" + c.Username
+            }
+            txt += fmt.Sprintf("     -> User...............: %s\n", user)
+        }
+        if c.Password != "" {
+            label := "Password..........."
+            if c.Protocol == credentials.ProtoSNMP {
+                label = "Community.........."
+            }
+            txt += fmt.Sprintf("     -> %s: %s\n", label, c.Password)
+        }
+        if c.Hash != "" {
+            txt += fmt.Sprintf("     -> Hash...............: %s\n", c.Hash)
+            txt += fmt.Sprintf("     -> Hashcat mode.......: %d\n", c.HashcatMode)
+        }
+        if c.Info != "" {
+            txt += fmt.Sprintf("     -> Info...............: %s\n", c.Info)
+        }
+        if c.Status != "" {
+            txt += fmt.Sprintf("     -> Status.............: %s\n", c.Status)
+        }
+        txt += fmt.Sprintf("     -> Seen...............: %d times, %s\n", c.Count, c.FirstSeen.Format("2006-01-02 15:04:05 MST"))
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateCredentialsCmd)
+
+    addOutputFlags(locateCredentialsCmd)
+    locateCredentialsCmd.Flags().BoolVar(&credentialsOpts.redact, "redact", false, "Hide the passwords and the secret part of the hashes at the report")
+    locateCredentialsCmd.Flags().StringVar(&credentialsOpts.hashcatDir, "hashcat-dir", "", "Write the NTLM and Kerberos hashes to this directory, one file per hashcat mode")
+    locateCredentialsCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateCredentialsCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateCredentialsCmd)
+}
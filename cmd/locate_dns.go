@@ -0,0 +1,185 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/dnsmap"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var dnsOpts = struct {
+    resolverFile    string
+}{}
+
+var locateDnsCmd = &cobra.Command{
+    Use:   "dns",
+    Short: "Extract DNS queries, records, servers and internal domains found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate dns
+
+Extract the DNS activity of the capture: queries, responses, resolved
+A/AAAA/CNAME/PTR/NS/MX/SRV records, Active Directory SRV records (e.g.
+_ldap._tcp.dc._msdcs), internal domain names and the DNS servers used by
+the clients.
+
+The resolver map (address to names) can be saved in the hosts file format
+with --resolver-file and used by other locate commands (--resolver) to
+label addresses with host names.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate dns --pcap data.pcap
+   - pcapraptor locate dns --pcap data.pcap --format csv --report-file records.csv
+   - pcapraptor locate dns --pcap data.pcap --resolver-file names.txt
+   - pcapraptor locate hosts --pcap data.pcap --resolver names.txt`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if dnsOpts.resolverFile != "" {
+            if dnsOpts.resolverFile, err = checkReportFile(dnsOpts.resolverFile); err != nil {
+                return err
+            }
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        collector := dnsmap.NewCollector()
+        packets, err := readPcapFile("Getting DNS data ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            ts := r.Header.PacketTime(h)
+            if shift != nil {
+                ts = ts.Add(*shift)
+            }
+            collector.Add(decap.NewPacket(data, layers.LinkType(r.Header.Network)), ts)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        res := collector.Result()
+
+        if dnsOpts.resolverFile != "" {
+            f, err := os.Create(dnsOpts.resolverFile)
+            if err != nil {
+                log.Error("Error writing resolver file", "err", err)
+                os.Exit(2)
+            }
+            err = collector.Resolver().WriteHosts(f)
+            f.Close()
+            if err != nil {
+                log.Error("Error writing resolver file", "err", err)
+                os.Exit(2)
+            }
+            log.Infof("Resolver map saved to %s (%d entries)", dnsOpts.resolverFile, len(res.Resolver))
+        }
+
+        if writeOutput(res, dnsText(res)) {
+            return
+        }
+
+        log.Infof("%d queries and %d records found", len(res.Queries), len(res.Records))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func dnsText(res *dnsmap.Result) string {
+    txt := "DNS\n"
+    txt += fmt.Sprintf("     -> Servers............: %d\n", len(res.Servers))
+    txt += fmt.Sprintf("     -> Queries............: %d\n", len(res.Queries))
+    txt += fmt.Sprintf("     -> Records............: %d\n", len(res.Records))
+    txt += fmt.Sprintf("     -> AD records.........: %d\n", len(res.ADRecords))
+    if len(res.Domains) > 0 {
+        txt += fmt.Sprintf("     -> Internal domains...: %s\n", strings.Join(res.Domains, ", "))
+    }
+
+    if len(res.Servers) > 0 {
+        txt += "\n     Servers\n"
+        for _, s := range res.Servers {
+            txt += fmt.Sprintf("     %-39s %6d queries %6d responses %6d nxdomain %4d clients\n",
+                s.IP, s.Queries, s.Responses, s.NXDomain, len(s.Clients))
+        }
+    }
+
+    if len(res.ADRecords) > 0 {
+        txt += "\n     Active Directory\n"
+        for _, r := range res.ADRecords {
+            txt += fmt.Sprintf("     %-60s %s\n", r.Name, r.Value)
+        }
+    }
+
+    if len(res.Resolver) > 0 {
+        txt += "\n     Resolver\n"
+        for _, e := range res.Resolver {
+            txt += fmt.Sprintf("     %-39s %s\n", e.IP, strings.Join(e.Names, ", "))
+        }
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateDnsCmd)
+
+    addOutputFlags(locateDnsCmd)
+    locateDnsCmd.Flags().StringVar(&dnsOpts.resolverFile, "resolver-file", "", "Write the resolver map (address to names) in the hosts file format")
+    locateDnsCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateDnsCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateDnsCmd)
+}
@@ -0,0 +1,156 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "net"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/inventory"
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var locateGatewaysCmd = &cobra.Command{
+    Use:   "gateways",
+    Short: "Identify the default gateways and routers found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate gateways
+
+Identify the routers of the capture and list their IP and MAC addresses,
+MAC vendor, the local subnets routed through them and the prefixes they
+advertise.
+
+A router is identified by the MAC address many off-subnet destinations
+are reached through, frames sent with a decremented TTL, the DHCP router
+option (3), IPv6 router advertisements, HSRP/VRRP speakers and routing
+protocol (OSPF, RIP and EIGRP) speakers.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate gateways --pcap data.pcap
+   - pcapraptor locate gateways --pcap data.pcap --format json
+   - pcapraptor locate gateways --pcap data.pcap --format csv --report-file gateways.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        engine := netcalc.NewMaskInference()
+        collector := netcalc.NewGatewayCollector()
+        packets, err := readPcapFile("Getting gateways ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            e := netcalc.GetEvidenceFromPacket(decap.NewPacket(data, layers.LinkType(r.Header.Network)))
+            engine.AddEvidence(e)
+            collector.AddEvidence(e)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        gateways := collector.Gateways(engine.Subnets())
+        for i, g := range gateways {
+            if mac, err := net.ParseMAC(g.MAC); err == nil {
+                gateways[i].Vendor = inventory.Vendor(mac)
+            }
+        }
+
+        if writeOutput(netcalc.Gateways(gateways), gatewaysText(gateways)) {
+            return
+        }
+
+        log.Infof("%d gateways found", len(gateways))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func gatewaysText(gateways []netcalc.Gateway) string {
+    txt := "Gateways\n"
+    for i, g := range gateways {
+        name := strings.Join(g.IPs, ", ")
+        if name == "" {
+            name = g.MAC
+        }
+        txt += fmt.Sprintf("\n     %04d. %s\n", i + 1, name)
+        if g.MAC != "" {
+            txt += fmt.Sprintf("     -> MAC................: %s\n", g.MAC)
+        }
+        if g.Vendor != "" {
+            txt += fmt.Sprintf("     -> Vendor.............: %s\n", g.Vendor)
+        }
+        if len(g.VirtualIPs) > 0 {
+            txt += fmt.Sprintf("     -> Virtual IPs........: %s\n", strings.Join(g.VirtualIPs, ", "))
+        }
+        if len(g.Subnets) > 0 {
+            txt += fmt.Sprintf("     -> Subnets............: %s\n", strings.Join(g.Subnets, ", "))
+        }
+        if len(g.Routes) > 0 {
+            txt += fmt.Sprintf("     -> Routes.............: %s\n", strings.Join(g.Routes, ", "))
+        }
+        if g.Destinations > 0 {
+            txt += fmt.Sprintf("     -> Destinations.......: %d\n", g.Destinations)
+        }
+        if g.RoutedFrames > 0 {
+            txt += fmt.Sprintf("     -> Routed frames......: %d\n", g.RoutedFrames)
+        }
+        txt += fmt.Sprintf("     -> Evidence...........: %s\n", strings.Join(g.Evidence, ", "))
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateGatewaysCmd)
+
+    addOutputFlags(locateGatewaysCmd)
+
+    addDecapFlag(locateGatewaysCmd)
+}
@@ -0,0 +1,195 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "net"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/inventory"
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/spf13/cobra"
+)
+
+var locateHostsCmd = &cobra.Command{
+    Use:   "hosts",
+    Short: "Build a host inventory from the PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate hosts
+
+Build a per host inventory: IP and MAC addresses, MAC vendor (offline
+OUI database), host names (DHCP option 12, NBNS, LLMNR, mDNS and DNS
+PTR), first/last seen time, VLANs, open ports/services and a guessed
+role (gateway, dns, dhcp and dc).
+
+The OS is guessed passively from the TCP SYN and SYN+ACK parameters
+(p0f v3 signatures), the DHCP parameter request list (option 55) and
+vendor class (option 60) and the HTTP User-Agent header.
+
+Addresses bound to the same MAC address by ARP, DHCP or NDP are merged
+into one host.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate hosts --pcap data.pcap
+   - pcapraptor locate hosts --pcap data.pcap --ntp --format json
+   - pcapraptor locate hosts --pcap data.pcap --format csv --report-file hosts.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        if err = setupResolver(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        var collector *inventory.Collector
+        packets, err := readPcapFile("Getting hosts ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            if collector == nil {
+                collector = inventory.NewCollector(r)
+            }
+            collector.Add(h, data)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        if collector == nil {
+            log.Error("PCAP file has no packets")
+            os.Exit(2)
+        }
+
+        hosts := []inventory.Host{}
+        for _, h := range collector.Hosts(shift) {
+            if privateOnly && !hasPrivateIP(h) {
+                continue
+            }
+            hosts = append(hosts, h)
+        }
+        resolveHosts(hosts)
+
+        if writeOutput(inventory.Hosts(hosts), hostsText(hosts)) {
+            return
+        }
+
+        if privateOnly {
+            log.Warn("Listing only hosts with private addresses")
+        }
+        log.Infof("%d hosts found", len(hosts))
+        printElapsed("Locate status", packets)
+    },
+}
+
+// hasPrivateIP returns true if the host has a private or link-local address
+func hasPrivateIP(h inventory.Host) bool {
+    for _, a := range h.IPs {
+        ip := net.ParseIP(a)
+        if netcalc.IsPrivateIP(ip) || ip.IsLinkLocalUnicast() {
+            return true
+        }
+    }
+    return false
+}
+
+func hostsText(hosts []inventory.Host) string {
+    tf := "2006-01-02 15:04:05 MST"
+
+    txt := "Hosts\n"
+    for i, h := range hosts {
+        txt += fmt.Sprintf("\n     %04d. %s\n", i + 1, strings.Join(h.IPs, ", "))
+        if len(h.MACs) > 0 {
+            txt += fmt.Sprintf("     -> MAC................: %s\n", strings.Join(h.MACs, ", "))
+        }
+        if len(h.Vendors) > 0 {
+            txt += fmt.Sprintf("     -> Vendor.............: %s\n", strings.Join(h.Vendors, ", "))
+        }
+        if h.OS != "" {
+            txt += fmt.Sprintf("     -> OS.................: %s\n", h.OS)
+        }
+        if len(h.Hostnames) > 0 {
+            txt += fmt.Sprintf("     -> Host names.........: %s\n", strings.Join(h.HostnameList(), ", "))
+        }
+        if len(h.Domains) > 0 {
+            txt += fmt.Sprintf("     -> Domains............: %s\n", strings.Join(h.Domains, ", "))
+        }
+        txt += fmt.Sprintf("     -> First seen.........: %s\n", h.FirstSeen.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Last seen..........: %s\n", h.LastSeen.UTC().Format(tf))
+        if len(h.VLANs) > 0 {
+            txt += fmt.Sprintf("     -> VLANs..............: %s\n", strings.Join(h.VLANList(), ", "))
+        }
+        if len(h.Services) > 0 {
+            txt += fmt.Sprintf("     -> Services...........: %s\n", strings.Join(h.ServiceList(), ", "))
+        }
+        if len(h.Roles) > 0 {
+            txt += fmt.Sprintf("     -> Roles..............: %s\n", strings.Join(h.Roles, ", "))
+        }
+        txt += fmt.Sprintf("     -> Packets............: %d\n", h.Packets)
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateHostsCmd)
+
+    addOutputFlags(locateHostsCmd)
+    locateHostsCmd.Flags().BoolVarP(&privateOnly, "private-only", "P", false, "List just hosts with private addresses")
+    locateHostsCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateHostsCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addResolverFlag(locateHostsCmd)
+    addDecapFlag(locateHostsCmd)
+}
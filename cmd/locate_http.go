@@ -0,0 +1,182 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/httpinv"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+    resolver "github.com/helviojunior/gopathresolver"
+)
+
+var httpOpts = struct {
+    extractDir      string
+}{}
+
+var locateHttpCmd = &cobra.Command{
+    Use:   "http",
+    Short: "Locate HTTP hosts, URLs and objects at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate http
+
+Reassemble the HTTP/1.x connections and list the hosts, URLs, methods,
+status codes, user agents, server banners and content types found.
+
+With --extract-dir the request and response bodies are carved to that
+directory, decoded from chunked transfer and gzip/deflate content
+encodings, named by their SHA256 hash. A manifest.csv maps each file to
+the URL, method, status and endpoints it came from.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate http --pcap data.pcap
+   - pcapraptor locate http --pcap data.pcap --extract-dir objects/
+   - pcapraptor locate http --pcap data.pcap --format jsonl | jq 'select(.type == "transaction")'
+   - pcapraptor locate http --pcap data.pcap --format csv --report-file http.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if httpOpts.extractDir != "" {
+            if httpOpts.extractDir, err = resolver.ResolveFullPath(httpOpts.extractDir); err != nil {
+                return err
+            }
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        collector := httpinv.NewCollector(httpOpts.extractDir)
+        packets, err := readPcapFile("Getting HTTP data ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            ts := r.Header.PacketTime(h)
+            if shift != nil {
+                ts = ts.Add(*shift)
+            }
+            collector.Add(decap.NewPacket(data, layers.LinkType(r.Header.Network)), ts)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        rpt := collector.Report()
+
+        if httpOpts.extractDir != "" {
+            objects, err := collector.Objects()
+            if err != nil {
+                log.Error("Error extracting HTTP objects", "err", err)
+                os.Exit(2)
+            }
+            log.Infof("%d HTTP objects saved to %s", len(objects), httpOpts.extractDir)
+        }
+
+        if writeOutput(rpt, httpText(rpt)) {
+            return
+        }
+
+        log.Infof("%d HTTP hosts and %d requests found", len(rpt.Hosts), len(rpt.Transactions))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func httpText(rpt *httpinv.Report) string {
+    txt := "HTTP hosts\n"
+    for _, h := range rpt.Hosts {
+        txt += fmt.Sprintf("\n     %s\n", h.Name)
+        txt += fmt.Sprintf("     -> Servers............: %s\n", strings.Join(h.Servers, ", "))
+        if len(h.Banners) > 0 {
+            txt += fmt.Sprintf("     -> Banners............: %s\n", strings.Join(h.Banners, ", "))
+        }
+        txt += fmt.Sprintf("     -> Requests...........: %d\n", h.Requests)
+    }
+
+    if len(rpt.UserAgents) > 0 {
+        txt += "\nUser agents\n"
+        for _, ua := range rpt.UserAgents {
+            txt += fmt.Sprintf("\n     %s\n", ua.Value)
+            txt += fmt.Sprintf("     -> Clients............: %s\n", strings.Join(ua.Clients, ", "))
+            txt += fmt.Sprintf("     -> Requests...........: %d\n", ua.Requests)
+        }
+    }
+
+    if len(rpt.Transactions) > 0 {
+        txt += "\nRequests\n\n"
+        for _, t := range rpt.Transactions {
+            status := "---"
+            if t.Status != 0 {
+                status = fmt.Sprintf("%d", t.Status)
+            }
+            txt += fmt.Sprintf("     %s %-7s %s %s", t.Time.Format("2006-01-02 15:04:05"), t.Method, status, t.URL)
+            if t.ContentType != "" {
+                txt += " (" + t.ContentType + ")"
+            }
+            txt += "\n"
+        }
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateHttpCmd)
+
+    addOutputFlags(locateHttpCmd)
+    locateHttpCmd.Flags().StringVar(&httpOpts.extractDir, "extract-dir", "", "Carve the HTTP bodies to this directory, named by hash, with a manifest.csv")
+    locateHttpCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateHttpCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateHttpCmd)
+}
@@ -0,0 +1,189 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var locateNeighborsCmd = &cobra.Command{
+    Use:   "neighbors",
+    Short: "List LLDP, CDP, FDP and EDP neighbors found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate neighbors
+
+Decode the link layer discovery frames (LLDP, CDP, FDP and EDP) into a
+neighbor table: device and system names, port IDs, platform and software
+strings, management addresses, native VLAN and capabilities.
+
+Each neighbor port is listed once, no matter how many announcements were
+captured.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate neighbors --pcap data.pcap
+   - pcapraptor locate neighbors --pcap data.pcap --format json
+   - pcapraptor locate neighbors --pcap data.pcap --format csv --report-file neighbors.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        table := map[string]*netcalc.Neighbor{}
+        packets, err := readPcapFile("Getting neighbors ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            packet := decap.NewPacket(data, layers.LinkType(r.Header.Network))
+            n := netcalc.GetNeighborFromPacket(packet)
+            if n == nil {
+                return nil
+            }
+
+            n.FirstSeen = r.Header.PacketTime(h)
+            n.LastSeen = n.FirstSeen
+            if shift != nil {
+                n.FirstSeen = n.FirstSeen.Add(*shift)
+                n.LastSeen = n.FirstSeen
+            }
+
+            if e, ok := table[n.Key()]; ok {
+                e.Merge(*n)
+            } else {
+                table[n.Key()] = n
+            }
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        neighbors := []netcalc.Neighbor{}
+        for _, n := range table {
+            neighbors = append(neighbors, *n)
+        }
+        sort.Slice(neighbors, func(i, j int) bool {
+            return neighbors[i].Key() < neighbors[j].Key()
+        })
+
+        if writeOutput(netcalc.Neighbors(neighbors), neighborsText(neighbors)) {
+            return
+        }
+
+        log.Infof("%d neighbors found", len(neighbors))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func neighborsText(neighbors []netcalc.Neighbor) string {
+    tf := "2006-01-02 15:04:05 MST"
+
+    txt := "Neighbors\n"
+    for i, n := range neighbors {
+        name := n.SystemName
+        if name == "" {
+            name = n.DeviceID
+        }
+        txt += fmt.Sprintf("\n     %04d. %s (%s)\n", i + 1, name, strings.ToUpper(n.Protocol))
+        txt += fmt.Sprintf("     -> Device ID..........: %s\n", n.DeviceID)
+        txt += fmt.Sprintf("     -> Port ID............: %s\n", n.PortID)
+        if n.PortDescription != "" {
+            txt += fmt.Sprintf("     -> Port description...: %s\n", n.PortDescription)
+        }
+        if n.Platform != "" {
+            txt += fmt.Sprintf("     -> Platform...........: %s\n", n.Platform)
+        }
+        if n.Description != "" {
+            txt += fmt.Sprintf("     -> Description........: %s\n", strings.ReplaceAll(n.Description, "\n", " "))
+        }
+        if len(n.MgmtIPs) > 0 {
+            txt += fmt.Sprintf("     -> Management IPs.....: %s\n", strings.Join(n.MgmtIPs, ", "))
+        }
+        if len(n.Prefixes) > 0 {
+            txt += fmt.Sprintf("     -> Prefixes...........: %s\n", strings.Join(n.Prefixes, ", "))
+        }
+        if n.NativeVLAN != 0 {
+            txt += fmt.Sprintf("     -> Native VLAN........: %d\n", n.NativeVLAN)
+        }
+        if len(n.VLANs) > 0 {
+            txt += fmt.Sprintf("     -> VLANs..............: %s\n", strings.Join(n.VLANs, ", "))
+        }
+        if n.VTPDomain != "" {
+            txt += fmt.Sprintf("     -> VTP domain.........: %s\n", n.VTPDomain)
+        }
+        if len(n.Capabilities) > 0 {
+            txt += fmt.Sprintf("     -> Capabilities.......: %s\n", strings.Join(n.Capabilities, ", "))
+        }
+        txt += fmt.Sprintf("     -> Source MAC.........: %s\n", n.SourceMAC)
+        txt += fmt.Sprintf("     -> First seen.........: %s\n", n.FirstSeen.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Last seen..........: %s\n", n.LastSeen.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Frames.............: %d\n", n.Frames)
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateNeighborsCmd)
+
+    addOutputFlags(locateNeighborsCmd)
+    locateNeighborsCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateNeighborsCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateNeighborsCmd)
+}
@@ -22,6 +22,12 @@ import (
     "strings"
     "fmt"
     "sync"
+    "encoding/csv"
+    "math/big"
+    "strconv"
+    "bytes"
+    "net"
+    "sort"
 
     "github.com/helviojunior/pcapraptor/pkg/pcapw"
     "github.com/helviojunior/pcapraptor/internal/ascii"
@@ -29,7 +35,8 @@ import (
     "github.com/helviojunior/pcapraptor/pkg/log"
     "github.com/helviojunior/pcapraptor/pkg/gopcap"
     "github.com/helviojunior/pcapraptor/pkg/netcalc"
-    "github.com/google/gopacket"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/pkg/report"
     "github.com/google/gopacket/layers"
 
     resolver "github.com/helviojunior/gopathresolver"
@@ -38,22 +45,89 @@ import (
 
 var privateOnly = false
 
+var supernetOpts = struct {
+    minPrefix  uint32
+    distance   uint32
+    mode       string
+    tolerance  float64
+}{}
+
+var targetOpts = struct {
+    nmapFile        string
+    masscanFile     string
+    xmlFile         string
+    grepableFile    string
+}{}
+
 var locateSubnetCmd = &cobra.Command{
     Use:   "subnets",
-    Short: "Look for NTP request/response into PCAP file and calculate package time shifiting",
+    Short: "Enumerate the subnets and supernets found at PCAP file",
     Long: ascii.LogoHelp(ascii.Markdown(`
 # locate subnets
 
 Enumerate all subnets found at PCAP file.
 
+Host names found in NBNS, LLMNR, mDNS and DNS reverse lookup traffic
+(NetBIOS names, workgroups/domains and mDNS service instances) are also
+listed. DNS reverse lookups may be about remote addresses, they are not
+taken as subnet evidence.
+
+Management addresses announced by LLDP, CDP, FDP and EDP are added to
+the subnet list.
+
+Prefixes advertised by OSPFv2, RIPv1/v2, RIPng and EIGRP are listed with
+their real masks along with the advertising router, as well as the
+HSRP/VRRP virtual IPs. Advertised prefixes may be summaries, they are
+listed with low confidence and only bound the inferred masks. Virtual IPs
+carry no mask, their subnet is inferred from the other evidence.
+
+Subnet masks are inferred from the evidence found along the capture
+instead of a fixed /24 guess: DHCP masks, ICMP address mask replies, OSPF
+hellos, routing updates, directed broadcasts, ARP requests and hosts
+sending through the same gateway MAC address at the same VLAN. Every subnet is listed with
+a confidence (high, medium or low) and the evidence used.
+
+Every subnet is classified as in-scope, out-of-scope, excluded or partial
+(only part of it in scope, or in scope and partly excluded). Custom
+ranges are set by a --ranges file or --range flags, one per line:
+
+    internal <cidr>    treated as private (e.g. customer public blocks)
+    deny     <cidr>    never reported
+    exclude  <cidr>    reported as excluded
+    scope    <cidr>    in-scope ranges (default: the private ranges)
+
+Supernets are grouped by distance between subnets (--group-distance and
+--min-prefix) or, with --aggregate exact, as the minimal CIDR cover of the
+subnets that never includes more unobserved address space than the
+--tolerance. The unobserved space of every supernet is reported.
+
+Scan targets can be exported for the scanning tools: an nmap -iL target
+list (--nmap-targets), a masscan -c range file (--masscan-ranges) and the
+live hosts seen (addresses sending traffic) as nmap XML (--hosts-xml) or
+grepable (--hosts-grepable) output. Only the in-scope part of the IPv4
+subnets and the in-scope hosts are exported, the exclude ranges are cut
+out of the subnets.
+
+Subnets are mapped per 802.1Q VLAN (QinQ tags as outer.inner), so trunk
+captures with overlapping address plans are kept apart. Supernets are
+grouped per VLAN and a VLAN summary (subnets, hosts and gateways) is
+printed for tagged captures.
+
 A -pcap must be specified.
 `)),
     Example: `
-   - pcapraptor ntp --pcap data.pcap
-   - pcapraptor ntp --pcap data.pcap --output-file adjusted.pcap`,
+   - pcapraptor locate subnets --pcap data.pcap
+   - pcapraptor locate subnets --pcap data.pcap --private-only
+   - pcapraptor locate subnets --pcap data.pcap --ranges customer.ranges
+   - pcapraptor locate subnets --pcap data.pcap --range "scope 10.0.0.0/8" --range "exclude 10.10.0.0/16"
+   - pcapraptor locate subnets --pcap data.pcap --aggregate exact --tolerance 25
+   - pcapraptor locate subnets --pcap data.pcap --format json --report-file subnets.json
+   - pcapraptor locate subnets --pcap data.pcap --ranges customer.ranges --nmap-targets targets.txt --hosts-xml live.xml`,
     PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
         var err error
 
+        setOutputLogo()
+
         // Annoying quirk, but because I'm overriding PersistentPreRun
         // here which overrides the parent it seems.
         // So we need to explicitly call the parent's one now.
@@ -108,6 +182,46 @@ A -pcap must be specified.
         if !tools.SliceHasStr(pcapExtensions, pcapFiles.fromExt) {
             return errors.New(fmt.Sprintf("unsupported from (%s) file type", pcapFiles.fromExt))
         }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        for _, f := range []*string{ &targetOpts.nmapFile, &targetOpts.masscanFile, &targetOpts.xmlFile, &targetOpts.grepableFile } {
+            if *f != "" {
+                if *f, err = checkReportFile(*f); err != nil {
+                    return err
+                }
+            }
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        if err = setupRanges(); err != nil {
+            return err
+        }
+
+        if cmd.Flags().Changed("min-prefix") {
+            if err = netcalc.SetMinPrefix(supernetOpts.minPrefix); err != nil {
+                return err
+            }
+        }
+
+        if cmd.Flags().Changed("group-distance") {
+            if err = netcalc.SetGroupDistance(supernetOpts.distance); err != nil {
+                return err
+            }
+        }
+
+        if supernetOpts.mode != netcalc.AggregateDistance && supernetOpts.mode != netcalc.AggregateExact {
+            return errors.New(fmt.Sprintf("unsupported aggregation mode (%s)", supernetOpts.mode))
+        }
+
+        if supernetOpts.tolerance < 0 || supernetOpts.tolerance > 100 {
+            return errors.New("tolerance must be a percentage between 0 and 100")
+        }
         
         return nil
     },
@@ -156,8 +270,9 @@ A -pcap must be specified.
         }
 
         log.Warn("Reading PCAP file...")
-        subnetList := []string{}
-        hasNoPrivate := false
+        mapper := netcalc.NewVLANMapper()
+        nameList := []string{}
+        routeList := []string{}
 
         wg.Add(1)
         go func() {
@@ -180,26 +295,38 @@ A -pcap must be specified.
 
                 status.Packets++
 
-                packet := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.NoCopy)
-                for _, subnet := range netcalc.GetSubnetsFromPacket(packet) {
-                    if subnet.Net != "" && (!privateOnly || subnet.IsPrivate) {
-                        hasNoPrivate = !subnet.IsPrivate || hasNoPrivate
-                        n := fmt.Sprintf("%s/%d", subnet.Net, subnet.Mask)
-                        if !tools.SliceHasStr(subnetList, n) {
-                            subnetList = append(subnetList, n)
-                            log.Info("Subnet found", "subnet", subnet.Net, "netmask", subnet.Mask)
-                        }
+                e := netcalc.GetEvidenceFromPacket(decap.NewPacket(data, layers.LinkType(r.Header.Network)))
+                for _, name := range e.Names {
+                    if privateOnly && !netcalc.IsPrivateIP(name.IP) {
+                        continue
+                    }
+                    if k := name.Key(); !tools.SliceHasStr(nameList, k) {
+                        nameList = append(nameList, k)
+                        log.Info("Name found", "name", name.Name, "ip", name.IP, "type", name.Type, "source", name.Source, "info", name.Info)
                     }
+                }
 
-                    if w != nil {
-                        if err := w.WritePacket(h, data); err != nil {
-                            log.Printf("Failed to send packet: %s\n", err)
-                            log.Error("PCAP writting error:", err)
-                            return
+                for _, route := range e.Routes {
+                    if privateOnly && !netcalc.IsPrivateIP(route.Network.IP) {
+                        continue
+                    }
+                    if k := route.Key(); !tools.SliceHasStr(routeList, k) {
+                        routeList = append(routeList, k)
+                        if route.VirtualIP {
+                            log.Info("Virtual IP found", "ip", route.Network.IP, "protocol", route.Protocol, "router", route.Router, "info", route.Info)
+                        } else {
+                            log.Info("Route found", "network", route.CIDR(), "protocol", route.Protocol, "router", route.Router, "metric", route.Metric, "info", route.Info)
                         }
                     }
                 }
 
+                if mapper.AddEvidence(e) && w != nil {
+                    if err := w.WritePacket(h, data); err != nil {
+                        log.Error("PCAP writting error:", err)
+                        return
+                    }
+                }
+
             }
             running = false
             time.Sleep(time.Second)
@@ -213,11 +340,62 @@ A -pcap must be specified.
         )
         ascii.ClearLine()
 
+        log.Warn("Inferring subnet masks...")
+        hasNoPrivate := false
+        filter := func(subnet netcalc.InferredSubnet) bool {
+            return !privateOnly || subnet.IsPrivate
+        }
+        vlanSubnets := map[string][]string{}
+        scopes := map[string]int{}
+        rpt := &subnetsReport{
+            Subnets     : []netcalc.InferredSubnet{},
+            Supernets   : []supernetRecord{},
+            VLANs       : []netcalc.VLANSummary{},
+        }
+        for _, vlan := range mapper.VLANs() {
+            for _, subnet := range mapper.Subnets(vlan) {
+                if !filter(subnet) {
+                    continue
+                }
+                hasNoPrivate = !subnet.IsPrivate || hasNoPrivate
+                subnet.Scope = scopeRanges.set.Classify(subnet.IPNet())
+                scopes[subnet.Scope]++
+                vlanSubnets[vlan] = append(vlanSubnets[vlan], subnet.String())
+                rpt.Subnets = append(rpt.Subnets, subnet)
+                if vlan != "" {
+                    log.Info("Subnet found", "subnet", subnet.Net, "netmask", subnet.Mask, "vlan", vlan, "scope", subnet.Scope, "confidence", subnet.Confidence, "hosts", subnet.Hosts, "evidence", strings.Join(subnet.Evidence, "; "))
+                } else {
+                    log.Info("Subnet found", "subnet", subnet.Net, "netmask", subnet.Mask, "scope", subnet.Scope, "confidence", subnet.Confidence, "hosts", subnet.Hosts, "evidence", strings.Join(subnet.Evidence, "; "))
+                }
+            }
+        }
+        log.Infof("Subnet scope: %d in-scope, %d partial, %d out-of-scope, %d excluded", scopes[netcalc.ScopeIn], scopes[netcalc.ScopePartial], scopes[netcalc.ScopeOut], scopes[netcalc.ScopeExcluded])
+
         log.Warn("Calculating supernets...")
-        netGroups := netcalc.GroupSubnets(subnetList)
-        for i, group := range netGroups {
-            supnet := netcalc.CalculateSupernet(group)
-            log.Infof("Supernet %04d: %s (from %d subnets)", i+1, supnet.String(), len(group))
+        for _, vlan := range mapper.VLANs() {
+            for i, sn := range supernets(vlanSubnets[vlan]) {
+                rpt.Supernets = append(rpt.Supernets, newSupernetRecord(vlan, sn))
+                info := fmt.Sprintf("%s (from %d subnets, %.2f%% unobserved, %s of %s addresses)", 
+                    sn.Network.String(), len(sn.Subnets), sn.UnobservedPct(), sn.Unobserved.String(), sn.Size.String())
+                if vlan != "" {
+                    log.Infof("VLAN %s supernet %04d: %s", vlan, i+1, info)
+                } else {
+                    log.Infof("Supernet %04d: %s", i+1, info)
+                }
+            }
+        }
+
+        if mapper.Tagged() {
+            rpt.VLANs = mapper.Summary(filter)
+            log.Info(vlanSummaryText(rpt.VLANs))
+        }
+
+        writeTargets(mapper, rpt.Subnets)
+
+        if output.format != report.FormatText || output.reportFile != "" {
+            if saveOutput(rpt, subnetsText(rpt)) {
+                return
+            }
         }
 
         if hasNoPrivate {
@@ -239,12 +417,239 @@ A -pcap must be specified.
     },
 }
 
+// supernets groups the subnets using the --aggregate mode
+func supernets(subnets []string) []netcalc.Supernet {
+    if supernetOpts.mode == netcalc.AggregateExact {
+        return netcalc.AggregateSubnets(subnets, supernetOpts.tolerance / 100)
+    }
+    list := []netcalc.Supernet{}
+    for _, group := range netcalc.GroupSubnets(subnets) {
+        list = append(list, netcalc.NewSupernet(group))
+    }
+    return list
+}
+
+// writeTargets writes the scan target files set by the export flags
+func writeTargets(mapper *netcalc.VLANMapper, subnets []netcalc.InferredSubnet) {
+    targets := scopeRanges.set.Targets(subnets)
+
+    seen := map[string]bool{}
+    hosts := []net.IP{}
+    for _, vlan := range mapper.VLANs() {
+        for _, ip := range mapper.LiveHosts(vlan) {
+            if ip.To4() == nil || seen[ip.String()] || !scopeRanges.set.InScope(ip) {
+                continue
+            }
+            seen[ip.String()] = true
+            // 4 byte form, so the addresses sort the same way
+            hosts = append(hosts, ip.To4())
+        }
+    }
+    sort.Slice(hosts, func(i, j int) bool {
+        return bytes.Compare(hosts[i], hosts[j]) < 0
+    })
+
+    exports := []struct {
+        file    string
+        label   string
+        count   int
+        write   func(io.Writer) error
+    }{
+        { targetOpts.nmapFile, "Nmap targets", len(targets), func(w io.Writer) error { return netcalc.WriteNmapTargets(w, targets) } },
+        { targetOpts.masscanFile, "Masscan ranges", len(targets), func(w io.Writer) error { return netcalc.WriteMasscanRanges(w, targets) } },
+        { targetOpts.xmlFile, "Live hosts XML", len(hosts), func(w io.Writer) error { return netcalc.WriteHostsXML(w, hosts) } },
+        { targetOpts.grepableFile, "Live hosts grepable", len(hosts), func(w io.Writer) error { return netcalc.WriteHostsGrepable(w, hosts) } },
+    }
+    for _, e := range exports {
+        if e.file == "" {
+            continue
+        }
+        f, err := os.Create(e.file)
+        if err != nil {
+            log.Error("Error writing target file", "err", err)
+            os.Exit(2)
+        }
+        err = e.write(f)
+        f.Close()
+        if err != nil {
+            log.Error("Error writing target file", "err", err)
+            os.Exit(2)
+        }
+        log.Infof("%s saved to %s (%d entries)", e.label, e.file, e.count)
+    }
+}
+
+// subnetsReport is the locate subnets result written by --format
+type subnetsReport struct {
+    Subnets         []netcalc.InferredSubnet    `json:"subnets"`
+    Supernets       []supernetRecord            `json:"supernets"`
+    VLANs           []netcalc.VLANSummary       `json:"vlans"`
+}
+
+type supernetRecord struct {
+    Network         string              `json:"network"`
+    VLAN            string              `json:"vlan"`
+    Subnets         []string            `json:"subnets"`
+    Size            *big.Int            `json:"size"`
+    Unobserved      *big.Int            `json:"unobserved"`
+    UnobservedPct   float64             `json:"unobserved_pct"`
+}
+
+func newSupernetRecord(vlan string, sn netcalc.Supernet) supernetRecord {
+    rec := supernetRecord{
+        Network         : sn.Network.String(),
+        VLAN            : vlan,
+        Subnets         : []string{},
+        Size            : sn.Size,
+        Unobserved      : sn.Unobserved,
+        UnobservedPct   : sn.UnobservedPct(),
+    }
+    for _, n := range sn.Subnets {
+        rec.Subnets = append(rec.Subnets, n.String())
+    }
+    return rec
+}
+
+// JSONLines writes one record per subnet, supernet and VLAN, the "type"
+// field (subnet, supernet or vlan) tells them apart
+func (r *subnetsReport) JSONLines() []interface{} {
+    list := []interface{}{}
+    for _, s := range r.Subnets {
+        list = append(list, struct {
+            Type    string  `json:"type"`
+            netcalc.InferredSubnet
+        }{ "subnet", s })
+    }
+    for _, s := range r.Supernets {
+        list = append(list, struct {
+            Type    string  `json:"type"`
+            supernetRecord
+        }{ "supernet", s })
+    }
+    for _, v := range r.VLANs {
+        list = append(list, struct {
+            Type    string  `json:"type"`
+            netcalc.VLANSummary
+        }{ "vlan", v })
+    }
+    return list
+}
+
+// WriteCSV writes one row per subnet, supernet and VLAN, the columns not
+// used by the row type are left empty
+func (r *subnetsReport) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    header := []string{
+        "type", "vlan", "network", "mask", "is_private", "confidence", "scope", "hosts",
+        "evidence", "subnets", "size", "unobserved", "unobserved_pct", "gateways",
+    }
+    if err := cw.Write(header); err != nil {
+        return err
+    }
+    for _, s := range r.Subnets {
+        row := []string{
+            "subnet", s.VLAN, s.Net, strconv.Itoa(s.Mask), strconv.FormatBool(s.IsPrivate), s.Confidence, s.Scope,
+            strconv.Itoa(s.Hosts), strings.Join(s.Evidence, "; "), "", "", "", "", "",
+        }
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    for _, s := range r.Supernets {
+        ip, mask := s.Network, ""
+        if idx := strings.Index(ip, "/"); idx >= 0 {
+            ip, mask = ip[:idx], ip[idx+1:]
+        }
+        row := []string{
+            "supernet", s.VLAN, ip, mask, "", "", "", "", "", strings.Join(s.Subnets, " "),
+            s.Size.String(), s.Unobserved.String(), strconv.FormatFloat(s.UnobservedPct, 'f', 2, 64), "",
+        }
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    for _, v := range r.VLANs {
+        row := []string{
+            "vlan", v.VLAN, "", "", "", "", "", strconv.Itoa(v.Hosts), "", strings.Join(v.Subnets, " "),
+            "", "", "", strings.Join(v.Gateways, " "),
+        }
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+func subnetsText(r *subnetsReport) string {
+    txt := "Subnets\n"
+    for i, s := range r.Subnets {
+        txt += fmt.Sprintf("\n     %04d. %s\n", i + 1, s.String())
+        if s.VLAN != "" {
+            txt += fmt.Sprintf("     -> VLAN...............: %s\n", s.VLAN)
+        }
+        txt += fmt.Sprintf("     -> Scope..............: %s\n", s.Scope)
+        txt += fmt.Sprintf("     -> Confidence.........: %s\n", s.Confidence)
+        txt += fmt.Sprintf("     -> Hosts..............: %d\n", s.Hosts)
+        if len(s.Evidence) > 0 {
+            txt += fmt.Sprintf("     -> Evidence...........: %s\n", strings.Join(s.Evidence, "; "))
+        }
+    }
+
+    txt += "\n     Supernets\n"
+    for i, s := range r.Supernets {
+        vlan := ""
+        if s.VLAN != "" {
+            vlan = fmt.Sprintf(" (VLAN %s)", s.VLAN)
+        }
+        txt += fmt.Sprintf("     %04d. %s%s from %d subnets, %.2f%% unobserved, %s of %s addresses\n",
+            i + 1, s.Network, vlan, len(s.Subnets), s.UnobservedPct, s.Unobserved.String(), s.Size.String())
+    }
+
+    if len(r.VLANs) > 0 {
+        txt += "\n" + vlanSummaryText(r.VLANs)
+    }
+
+    return txt
+}
+
+func vlanSummaryText(summary []netcalc.VLANSummary) string {
+    txt := "VLAN summary\n"
+    txt += fmt.Sprintf("\n     %-12s %-6s %-20s %s\n", "VLAN", "Hosts", "Gateway", "Subnets")
+    for _, s := range summary {
+        vlan := s.VLAN
+        if vlan == "" {
+            vlan = "untagged"
+        }
+        gw := strings.Join(s.Gateways, ", ")
+        if gw == "" {
+            gw = "-"
+        }
+        txt += fmt.Sprintf("     %-12s %-6d %-20s %s\n", vlan, s.Hosts, gw, strings.Join(s.Subnets, ", "))
+    }
+    return txt
+}
+
 func init() {
     locateRootCmd.AddCommand(locateSubnetCmd)
 
     locateSubnetCmd.Flags().StringVarP(&pcapFiles.toFile, "output-file", "o", "", "The file to write adjusted PCAP data to")
 
-    locateSubnetCmd.Flags().BoolVarP(&privateOnly, "private-only", "P", false, "Check just private subnets (192.168.0.0/16, 10.0.0.0/8 and 172.31.0.0/12)")
+    addDecapFlag(locateSubnetCmd)
+
+    locateSubnetCmd.Flags().BoolVarP(&privateOnly, "private-only", "P", false, "Check just private subnets (10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16, 100.64.0.0/10 and internal ranges)")
+    addRangesFlags(locateSubnetCmd)
+    addOutputFlags(locateSubnetCmd)
+
+    locateSubnetCmd.Flags().StringVar(&targetOpts.nmapFile, "nmap-targets", "", "Write the in-scope subnets as an nmap -iL target list")
+    locateSubnetCmd.Flags().StringVar(&targetOpts.masscanFile, "masscan-ranges", "", "Write the in-scope subnets as a masscan -c range file")
+    locateSubnetCmd.Flags().StringVar(&targetOpts.xmlFile, "hosts-xml", "", "Write the in-scope live hosts as nmap XML")
+    locateSubnetCmd.Flags().StringVar(&targetOpts.grepableFile, "hosts-grepable", "", "Write the in-scope live hosts as nmap grepable output")
+
+    locateSubnetCmd.Flags().Uint32Var(&supernetOpts.minPrefix, "min-prefix", 8, "Minimum IPv4 supernet prefix length (8-32)")
+    locateSubnetCmd.Flags().Uint32Var(&supernetOpts.distance, "group-distance", 512, "Maximum distance, in addresses, between grouped IPv4 subnets (distance mode)")
+    locateSubnetCmd.Flags().StringVar(&supernetOpts.mode, "aggregate", netcalc.AggregateDistance, "Supernet aggregation mode (distance or exact)")
+    locateSubnetCmd.Flags().Float64Var(&supernetOpts.tolerance, "tolerance", 0, "Unobserved address space allowed in a supernet, in percent (exact mode)")
 
     //autoNtpCmd.PersistentFlags().StringVar(&rptFilter, "filter", "", "Comma-separated terms to filter results")
 }
@@ -0,0 +1,170 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/tlsmeta"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var locateTlsCmd = &cobra.Command{
+    Use:   "tls",
+    Short: "Extract the TLS metadata (SNI, certificates, JA3/JA4) of each connection found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate tls
+
+Extract the TLS handshake metadata of each TCP connection: SNI, ALPN
+(offered and negotiated), TLS version and cipher, server certificate
+(subject, issuer, SAN, serial and validity) and the JA3, JA3S and JA4
+fingerprints.
+
+The handshake is reassembled across TCP segments, so certificate chains
+spanning many segments are decoded. TLS 1.3 encrypts the certificate, so
+it is only available up to TLS 1.2.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate tls --pcap data.pcap
+   - pcapraptor locate tls --pcap data.pcap --format jsonl | jq 'select(.certificate.self_signed)'
+   - pcapraptor locate tls --pcap data.pcap --format csv --report-file tls.csv`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        collector := tlsmeta.NewCollector()
+        packets, err := readPcapFile("Getting TLS data ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            ts := r.Header.PacketTime(h)
+            if shift != nil {
+                ts = ts.Add(*shift)
+            }
+            collector.Add(decap.NewPacket(data, layers.LinkType(r.Header.Network)), ts)
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        sessions := tlsmeta.Sessions(collector.Sessions())
+
+        if writeOutput(sessions, tlsText(sessions)) {
+            return
+        }
+
+        log.Infof("%d TLS sessions found", len(sessions))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func tlsText(sessions tlsmeta.Sessions) string {
+    txt := "TLS sessions\n"
+    for i, s := range sessions {
+        txt += fmt.Sprintf("\n     %04d. %s -> %s\n", i + 1, s.Client, s.Server)
+        if s.SNI != "" {
+            txt += fmt.Sprintf("     -> SNI................: %s\n", s.SNI)
+        }
+        if len(s.ALPN) > 0 {
+            alpn := strings.Join(s.ALPN, ", ")
+            if s.NegotiatedALPN != "" {
+                alpn += " (" + s.NegotiatedALPN + ")"
+            }
+            txt += fmt.Sprintf("     -> ALPN...............: %s\n", alpn)
+        }
+        if s.Version != "" {
+            txt += fmt.Sprintf("     -> Version............: %s\n", s.Version)
+            txt += fmt.Sprintf("     -> Cipher.............: %s\n", s.Cipher)
+        } else if s.ClientVersion != "" {
+            txt += fmt.Sprintf("     -> Client version.....: %s\n", s.ClientVersion)
+        }
+        if s.JA3Hash != "" {
+            txt += fmt.Sprintf("     -> JA3................: %s\n", s.JA3Hash)
+            txt += fmt.Sprintf("     -> JA4................: %s\n", s.JA4)
+        }
+        if s.JA3SHash != "" {
+            txt += fmt.Sprintf("     -> JA3S...............: %s\n", s.JA3SHash)
+        }
+        if c := s.Certificate; c != nil {
+            txt += fmt.Sprintf("     -> Subject............: %s\n", c.Subject)
+            txt += fmt.Sprintf("     -> Issuer.............: %s\n", c.Issuer)
+            if len(c.SANs) > 0 {
+                txt += fmt.Sprintf("     -> SAN................: %s\n", strings.Join(c.SANs, ", "))
+            }
+            validity := fmt.Sprintf("%s to %s", c.NotBefore.Format("2006-01-02"), c.NotAfter.Format("2006-01-02"))
+            if c.Expired {
+                validity += " (expired)"
+            }
+            if c.SelfSigned {
+                validity += " (self signed)"
+            }
+            txt += fmt.Sprintf("     -> Validity...........: %s\n", validity)
+        }
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateTlsCmd)
+
+    addOutputFlags(locateTlsCmd)
+    locateTlsCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateTlsCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateTlsCmd)
+}
@@ -0,0 +1,233 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "net"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/inventory"
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/helviojunior/pcapraptor/pkg/topology"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/pkg/report"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var topologyOpts = struct {
+    conversations   int
+}{}
+
+var locateTopologyCmd = &cobra.Command{
+    Use:   "topology",
+    Short: "Build a graph of hosts, subnets, gateways and conversations found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate topology
+
+Build the network topology graph of the capture: hosts (see locate
+hosts), subnets (see locate subnets), gateways (see locate gateways) and
+the conversations between hosts.
+
+The graph is exported as Graphviz DOT, GraphML or a self-contained HTML
+page (embedded JavaScript, no network access needed) with the --format
+flag. Conversations are limited to the ones with most packets
+(--max-conversations).
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate topology --pcap data.pcap
+   - pcapraptor locate topology --pcap data.pcap --format html --report-file topology.html
+   - pcapraptor locate topology --pcap data.pcap --format dot | dot -Tsvg -o topology.svg
+   - pcapraptor locate topology --pcap data.pcap --format graphml --report-file topology.graphml`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if topologyOpts.conversations < 0 {
+            return errors.New("max conversations must be zero (all) or greater")
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        if err = setupResolver(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        var collector *inventory.Collector
+        mapper := netcalc.NewVLANMapper()
+        conversations := topology.NewConversations()
+        packets, err := readPcapFile("Building topology ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            if collector == nil {
+                collector = inventory.NewCollector(r)
+            }
+            packet := decap.NewPacket(data, layers.LinkType(r.Header.Network))
+            e := netcalc.GetEvidenceFromPacket(packet)
+            collector.AddPacket(h, packet, e.Names)
+            mapper.AddEvidence(e)
+            conversations.Add(packet, int(h.OriginalLen))
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        if collector == nil {
+            log.Error("PCAP file has no packets")
+            os.Exit(2)
+        }
+
+        hosts := []inventory.Host{}
+        for _, h := range collector.Hosts(nil) {
+            if privateOnly && !hasPrivateIP(h) {
+                continue
+            }
+            hosts = append(hosts, h)
+        }
+        resolveHosts(hosts)
+
+        builder := topology.NewBuilder()
+        builder.AddHosts(hosts)
+        subnets := []netcalc.InferredSubnet{}
+        for _, vlan := range mapper.VLANs() {
+            for _, s := range mapper.Subnets(vlan) {
+                if !privateOnly || s.IsPrivate {
+                    subnets = append(subnets, s)
+                }
+            }
+        }
+        builder.AddSubnets(subnets)
+        for _, vlan := range mapper.VLANs() {
+            gateways := mapper.Gateways(vlan)
+            for i, g := range gateways {
+                if mac, err := net.ParseMAC(g.MAC); err == nil {
+                    gateways[i].Vendor = inventory.Vendor(mac)
+                }
+            }
+            builder.AddGateways(vlan, gateways)
+        }
+        builder.AddConversations(conversations.Top(topologyOpts.conversations))
+        graph := builder.Graph()
+
+        if writeOutput(graph, topologyText(graph)) {
+            return
+        }
+
+        if privateOnly {
+            log.Warn("Listing only hosts and subnets with private addresses")
+        }
+        log.Infof("%d nodes and %d edges found", len(graph.Nodes), len(graph.Edges))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func topologyText(g *topology.Graph) string {
+    nodes := map[string]topology.Node{}
+    for _, n := range g.Nodes {
+        nodes[n.ID] = n
+    }
+    label := func(id string) string {
+        return strings.ReplaceAll(nodes[id].Label, "\n", " ")
+    }
+
+    txt := "Topology\n"
+    txt += fmt.Sprintf("     -> Hosts..............: %d\n", g.Count(topology.NodeHost))
+    txt += fmt.Sprintf("     -> Gateways...........: %d\n", g.Count(topology.NodeGateway))
+    txt += fmt.Sprintf("     -> Subnets............: %d\n", g.Count(topology.NodeSubnet))
+    txt += fmt.Sprintf("     -> Edges..............: %d\n", len(g.Edges))
+
+    txt += "\n     Subnets\n"
+    for _, n := range g.Nodes {
+        if n.Type != topology.NodeSubnet {
+            continue
+        }
+        members, routers := 0, []string{}
+        for _, e := range g.Edges {
+            if e.Target != n.ID {
+                continue
+            }
+            switch e.Type {
+            case topology.EdgeMember:
+                members++
+            case topology.EdgeRoutes:
+                routers = append(routers, label(e.Source))
+            }
+        }
+        gw := strings.Join(routers, ", ")
+        if gw == "" {
+            gw = "-"
+        }
+        txt += fmt.Sprintf("     %-40s %5d hosts  gateway %s\n", label(n.ID), members, gw)
+    }
+
+    txt += "\n     Conversations\n"
+    i := 0
+    for _, e := range g.Edges {
+        if e.Type != topology.EdgeConversation {
+            continue
+        }
+        i++
+        pair := fmt.Sprintf("%s <-> %s", label(e.Source), label(e.Target))
+        txt += fmt.Sprintf("     %04d. %-60s %10d pkts %14s bytes\n", i, pair, e.Packets, tools.FormatInt64Comma(e.Bytes))
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateTopologyCmd)
+
+    addOutputFlags(locateTopologyCmd, report.FormatText, report.FormatJSON, topology.FormatDOT, topology.FormatGraphML, topology.FormatHTML)
+    locateTopologyCmd.Flags().IntVar(&topologyOpts.conversations, "max-conversations", 200, "Maximum number of conversations in the graph, most packets first (0 for all)")
+    locateTopologyCmd.Flags().BoolVarP(&privateOnly, "private-only", "P", false, "List just hosts and subnets with private addresses")
+
+    addResolverFlag(locateTopologyCmd)
+    addDecapFlag(locateTopologyCmd)
+}
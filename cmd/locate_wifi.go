@@ -0,0 +1,246 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+
+package cmd
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/pkg/dot11"
+    "github.com/helviojunior/pcapraptor/pkg/wifi"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    "github.com/spf13/cobra"
+)
+
+var wifiOpts = struct {
+    tsfFile         string
+}{}
+
+var locateWifiCmd = &cobra.Command{
+    Use:   "wifi",
+    Short: "List the wireless networks, clients and probe requests found at PCAP file",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# locate wifi
+
+List the access points (BSSID, SSID, channel, band, encryption, ciphers
+and authentication), their clients and the SSIDs looked for by probe
+requests of a monitor mode capture (Radiotap or plain 802.11 link type).
+Hidden SSIDs are revealed by probe responses and association requests.
+
+The beacon TSF timer of each access point is a monotonic clock: the
+capture clock is fitted against it and the drift (ppm) and residual are
+reported. Use --tsf-file to save the raw TSF samples, numbered by
+segment as the timer restarts when the access point reboots.
+
+The unprotected (or decrypted) data frames are read as Ethernet with
+--decap dot11, so --ntp and the other commands (ntp, locate hosts, ...)
+work on wireless captures. --ntp here enables dot11 by default.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor locate wifi --pcap monitor.pcap
+   - pcapraptor locate wifi --pcap monitor.pcap --ntp --tsf-file tsf.csv
+   - pcapraptor locate wifi --pcap monitor.pcap --format csv --report-file wifi.csv
+   - pcapraptor ntp --pcap monitor.pcap --decap dot11`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        setOutputLogo()
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if wifiOpts.tsfFile != "" {
+            if wifiOpts.tsfFile, err = checkReportFile(wifiOpts.tsfFile); err != nil {
+                return err
+            }
+        }
+
+        // NTP data of a wireless capture is inside the 802.11 data frames
+        if timeShift.useNtp && decapFlag == "" {
+            decapFlag = string(decap.EncapDot11)
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+
+        shift, err := getTimeShift()
+        if err != nil {
+            log.Error("Error getting file time delta", "err", err)
+            os.Exit(2)
+        }
+
+        wireless := true
+        collector := wifi.NewCollector()
+        packets, err := readPcapFile("Getting wireless data ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            linkType := layers.LinkType(r.Header.Network)
+            if !dot11.IsWireless(linkType) {
+                wireless = false
+                return nil
+            }
+            // capture time, the shift is applied at the report so the
+            // TSF samples keep the original clock
+            collector.Add(data, linkType, r.Header.PacketTime(h))
+            return nil
+        })
+        if err != nil {
+            log.Error("PCAP read error:", "err", err)
+            os.Exit(2)
+        }
+
+        if !wireless {
+            log.Warn("PCAP file is not a Radiotap or 802.11 capture")
+        }
+
+        if wifiOpts.tsfFile != "" {
+            f, err := os.Create(wifiOpts.tsfFile)
+            if err != nil {
+                log.Error("Error writing TSF file", "err", err)
+                os.Exit(2)
+            }
+            err = wifi.WriteTSF(f, collector.TSFSamples())
+            f.Close()
+            if err != nil {
+                log.Error("Error writing TSF file", "err", err)
+                os.Exit(2)
+            }
+            log.Infof("TSF samples saved to %s", wifiOpts.tsfFile)
+        }
+
+        rpt := collector.Report(shift)
+
+        if writeOutput(rpt, wifiText(rpt)) {
+            return
+        }
+
+        log.Infof("%d access points, %d clients and %d probed networks found", len(rpt.AccessPoints), len(rpt.Clients), len(rpt.Probes))
+        printElapsed("Locate status", packets)
+    },
+}
+
+func wifiSignal(s int) string {
+    if s == 0 {
+        return "-"
+    }
+    return fmt.Sprintf("%d dBm", s)
+}
+
+func wifiText(rpt *wifi.Report) string {
+    tf := "2006-01-02 15:04:05 MST"
+
+    txt := "Access points\n"
+    for i, ap := range rpt.AccessPoints {
+        ssid := ap.SSID
+        if ssid == "" {
+            ssid = "<hidden>"
+        } else if ap.Hidden {
+            ssid += " (hidden)"
+        }
+        txt += fmt.Sprintf("\n     %04d. %s %s\n", i + 1, ap.BSSID, ssid)
+        if ap.Channel > 0 {
+            txt += fmt.Sprintf("     -> Channel............: %d (%s)\n", ap.Channel, ap.Band)
+        }
+        if ap.Encryption != "" {
+            enc := ap.Encryption
+            if len(ap.Auth) > 0 || len(ap.Ciphers) > 0 {
+                enc += fmt.Sprintf(" (%s)", strings.Join(append(append([]string{}, ap.Auth...), ap.Ciphers...), ", "))
+            }
+            txt += fmt.Sprintf("     -> Encryption.........: %s\n", enc)
+        }
+        if ap.Vendor != "" {
+            txt += fmt.Sprintf("     -> Vendor.............: %s\n", ap.Vendor)
+        }
+        if len(ap.Clients) > 0 {
+            txt += fmt.Sprintf("     -> Clients............: %s\n", strings.Join(ap.Clients, ", "))
+        }
+        txt += fmt.Sprintf("     -> Signal.............: %s\n", wifiSignal(ap.Signal))
+        txt += fmt.Sprintf("     -> Frames.............: %d beacons, %d data\n", ap.Beacons, ap.DataFrames)
+        if ap.Clock != nil {
+            txt += fmt.Sprintf("     -> Clock drift........: %+.2f ppm (%d samples, %.0fs, residual %.3f ms)\n",
+                ap.Clock.Drift, ap.Clock.Samples, ap.Clock.Span, ap.Clock.Residual)
+            if ap.Clock.Resets > 0 {
+                txt += fmt.Sprintf("     -> TSF resets.........: %d\n", ap.Clock.Resets)
+            }
+        }
+        txt += fmt.Sprintf("     -> First seen.........: %s\n", ap.FirstSeen.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Last seen..........: %s\n", ap.LastSeen.UTC().Format(tf))
+    }
+
+    txt += "\nClients\n"
+    for i, c := range rpt.Clients {
+        txt += fmt.Sprintf("\n     %04d. %s\n", i + 1, c.MAC)
+        if c.Vendor != "" {
+            txt += fmt.Sprintf("     -> Vendor.............: %s\n", c.Vendor)
+        }
+        if len(c.BSSIDs) > 0 {
+            txt += fmt.Sprintf("     -> BSSIDs.............: %s\n", strings.Join(c.BSSIDs, ", "))
+        }
+        if len(c.Probes) > 0 {
+            txt += fmt.Sprintf("     -> Probes.............: %s\n", strings.Join(c.Probes, ", "))
+        }
+        txt += fmt.Sprintf("     -> Signal.............: %s\n", wifiSignal(c.Signal))
+        txt += fmt.Sprintf("     -> Frames.............: %d\n", c.Frames)
+        txt += fmt.Sprintf("     -> First seen.........: %s\n", c.FirstSeen.UTC().Format(tf))
+        txt += fmt.Sprintf("     -> Last seen..........: %s\n", c.LastSeen.UTC().Format(tf))
+    }
+
+    txt += "\nProbe requests\n"
+    for i, p := range rpt.Probes {
+        txt += fmt.Sprintf("\n     %04d. %s\n", i + 1, p.SSID)
+        txt += fmt.Sprintf("     -> Clients............: %s\n", strings.Join(p.Clients, ", "))
+        txt += fmt.Sprintf("     -> Requests...........: %d\n", p.Requests)
+    }
+
+    return txt
+}
+
+func init() {
+    locateRootCmd.AddCommand(locateWifiCmd)
+
+    addOutputFlags(locateWifiCmd)
+    locateWifiCmd.Flags().StringVar(&wifiOpts.tsfFile, "tsf-file", "", "Write the beacon TSF samples (bssid, capture time, TSF and segment) in CSV format")
+    locateWifiCmd.Flags().BoolVar(&timeShift.useNtp, "ntp", false, "Calculate corrected times using NTP data from the capture")
+    locateWifiCmd.Flags().StringVar(&timeShift.value, "time-shift", "", "Time shift to apply on first/last seen times (e.g. 2h30m, -15m)")
+
+    addDecapFlag(locateWifiCmd)
+}
@@ -29,10 +29,15 @@ import (
     "github.com/helviojunior/pcapraptor/internal/tools"
     "github.com/helviojunior/pcapraptor/pkg/log"
     "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/helviojunior/pcapraptor/pkg/report"
     resolver "github.com/helviojunior/gopathresolver"
     "github.com/spf13/cobra"
 )
 
+var ntpOpts = struct {
+    samples    int
+}{}
+
 var autoNtpCmd = &cobra.Command{
     Use:   "ntp",
     Short: "Look for NTP request/response into PCAP file and calculate package time shifiting",
@@ -41,14 +46,25 @@ var autoNtpCmd = &cobra.Command{
 
 Look for NTP request/response into PCAP file and calculate package time shifiting.
 
+The time shift is the median offset of the first NTP request/response
+pairs found (see --samples), the confidence is high with 3 or more
+samples within 1 second, medium with 2 or more within 5 seconds and low
+otherwise.
+
+With a JSON, JSON Lines or CSV --format and no --report-file the result
+is written to stdout and the file is not converted.
+
 A -pcap must be specified.
 `)),
     Example: `
    - pcapraptor ntp --pcap data.pcap
-   - pcapraptor ntp --pcap data.pcap --output-file adjusted.pcap`,
+   - pcapraptor ntp --pcap data.pcap --output-file adjusted.pcap
+   - pcapraptor ntp --pcap data.pcap --samples 20 --format json --report-file ntp.json`,
     PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
         var err error
 
+        setOutputLogo()
+
         // Annoying quirk, but because I'm overriding PersistentPreRun
         // here which overrides the parent it seems.
         // So we need to explicitly call the parent's one now.
@@ -103,6 +119,18 @@ A -pcap must be specified.
         if !tools.SliceHasStr(pcapExtensions, pcapFiles.fromExt) {
             return errors.New(fmt.Sprintf("unsupported from (%s) file type", pcapFiles.fromExt))
         }
+
+        if ntpOpts.samples < 0 {
+            return errors.New("samples must be zero (all) or greater")
+        }
+
+        if err = checkOutput(cmd); err != nil {
+            return err
+        }
+
+        if err = setupDecap(); err != nil {
+            return err
+        }
         
         return nil
     },
@@ -129,11 +157,17 @@ A -pcap must be specified.
 
         status.Label = "Looking for NTP data..."
         log.Infof("Looking for NTP data into pcap file, this can take a while. Please be patient.")
-        diff, err := ntpcalc.GetFileDelta(pcapFiles.fromFile)
+        res, err := ntpcalc.GetFileSamples(pcapFiles.fromFile, ntpOpts.samples)
         if err != nil {
             log.Error("Error getting file time delta", "err", err)
             os.Exit(2)
         }
+        d := res.Duration()
+        diff := &d
+
+        if writeOutput(res, ntpText(res)) {
+            return
+        }
 
         //Check if need to auto name output file
         if pcapFiles.toFile == "" {
@@ -210,9 +244,7 @@ A -pcap must be specified.
                 status.Packets++
 
                 //Calculate new package time
-                newTime := (time.Unix(int64(h.TsSec), int64(h.TsUsec))).Add(*diff)
-                h.TsSec = int32(newTime.Unix())                   // seconds since Unix epoch
-                h.TsUsec = int32(newTime.UnixNano()/1e3 % 1e6)    // microseconds component
+                r.Header.SetPacketTime(&h, r.Header.PacketTime(h).Add(*diff))
 
                 if err := w.WritePacket(h, data); err != nil {
                     log.Printf("Failed to send packet: %s\n", err)
@@ -248,10 +280,30 @@ A -pcap must be specified.
     },
 }
 
+func ntpText(res *ntpcalc.Result) string {
+    tf := "2006-01-02 15:04:05.000000 MST"
+
+    txt := "NTP time shift\n"
+    txt += fmt.Sprintf("     -> Offset.............: %s (%.6fs)\n", tools.FormatDuration(res.Duration()), res.Offset)
+    txt += fmt.Sprintf("     -> Samples............: %d\n", len(res.Samples))
+    txt += fmt.Sprintf("     -> Spread.............: %.6fs\n", res.Spread)
+    txt += fmt.Sprintf("     -> Confidence.........: %s\n", res.Confidence)
+
+    txt += "\n     Samples\n"
+    for i, s := range res.Samples {
+        txt += fmt.Sprintf("     %02d. %s %s -> %s offset %.6fs delay %.6fs\n", i + 1, s.Time.Format(tf), s.Client, s.Server, s.Offset, s.Delay)
+    }
+
+    return txt
+}
+
 func init() {
     rootCmd.AddCommand(autoNtpCmd)
 
     autoNtpCmd.Flags().StringVarP(&pcapFiles.toFile, "output-file", "o", "", "The file to write adjusted PCAP data to")
+    autoNtpCmd.Flags().IntVar(&ntpOpts.samples, "samples", ntpcalc.DefaultSamples, "Number of NTP request/response pairs used (0 for all)")
+    addOutputFlags(autoNtpCmd, report.FormatText, report.FormatJSON, report.FormatJSONL)
+    addDecapFlag(autoNtpCmd)
 
     //autoNtpCmd.PersistentFlags().StringVar(&rptFilter, "filter", "", "Comma-separated terms to filter results")
 }
@@ -0,0 +1,111 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/report"
+    "github.com/spf13/cobra"
+)
+
+// Output options shared by the commands writing results (one command
+// runs per process)
+var output = struct {
+    format     string
+    reportFile string
+}{}
+
+// formats supported by each command
+var outputFormats = map[*cobra.Command][]string{}
+
+// addOutputFlags adds the --format and --report-file flags, formats
+// lists the formats supported by the command (default: all)
+func addOutputFlags(cmd *cobra.Command, formats ...string) {
+    if len(formats) == 0 {
+        formats = report.Formats
+    }
+    outputFormats[cmd] = formats
+
+    cmd.Flags().StringVarP(&output.format, "format", "f", report.FormatText, fmt.Sprintf("Output format (%s)", strings.Join(formats, ", ")))
+    cmd.Flags().StringVar(&output.reportFile, "report-file", "", "The file to write the report to (default: stdout/log)")
+}
+
+// setOutputLogo hides the logo when the result is written to stdout,
+// it must be called before the rootCmd PersistentPreRunE
+func setOutputLogo() {
+    if output.format != report.FormatText && output.reportFile == "" {
+        noLogo = true
+    }
+}
+
+// checkOutput validates the --format and --report-file flags
+func checkOutput(cmd *cobra.Command) error {
+    var err error
+
+    if formats, ok := outputFormats[cmd]; ok && !tools.SliceHasStr(formats, output.format) {
+        return errors.New(fmt.Sprintf("unsupported output format (%s)", output.format))
+    }
+
+    if output.reportFile != "" {
+        if output.reportFile, err = checkReportFile(output.reportFile); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// writeOutput writes the result using the --format and --report-file
+// flags, text is the human readable result. It returns true when the
+// result was written to stdout, so nothing else must be printed.
+func writeOutput(v interface{}, text string) bool {
+    if saveOutput(v, text) {
+        return true
+    }
+
+    if output.format == report.FormatText {
+        log.Info(text)
+    }
+    return false
+}
+
+// saveOutput is writeOutput without logging the text result, used by
+// commands that log their results as they are found
+func saveOutput(v interface{}, text string) bool {
+    out, err := report.Encode(output.format, v, ascii.ScapeAnsi(text))
+    if err != nil {
+        log.Error("Error encoding result", "err", err)
+        os.Exit(2)
+    }
+
+    if output.reportFile != "" {
+        if err := os.WriteFile(output.reportFile, out, 0644); err != nil {
+            log.Error("Error writing report file", "err", err)
+            os.Exit(2)
+        }
+        log.Infof("Report saved to %s", output.reportFile)
+    } else if output.format != report.FormatText {
+        fmt.Print(string(out))
+        return true
+    }
+
+    return false
+}
@@ -0,0 +1,162 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/rewrite"
+    "github.com/helviojunior/pcapraptor/pkg/pcapw"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket/layers"
+    resolver "github.com/helviojunior/gopathresolver"
+    "github.com/spf13/cobra"
+)
+
+var rewriteOpts = struct {
+    rulesFile     string
+    rules         []string
+    noChecksum    bool
+    ruleSet       *rewrite.Rules
+}{}
+
+var rewriteCmd = &cobra.Command{
+    Use:   "rewrite",
+    Short: "Rewrite MAC/IP addresses, ports and VLAN tags of PCAP packets",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# rewrite
+
+Remap addresses of PCAP packets using a rules file. Rules are applied
+to Ethernet, 802.1Q, ARP, IPv4/IPv6, TCP/UDP and DHCP payloads and the
+IP/TCP/UDP checksums of changed packets are recomputed.
+
+Rules file format, one rule per line ('#' starts a comment):
+
+    ip   10.0.0.0/16 172.16.0.0/16
+    mac  00:11:22:33:44:55 02:00:00:00:00:01
+    port tcp 8080 80
+    vlan 10 20
+
+A -pcap and a --rules file (or --rule) must be specified.
+`)),
+    Example: `
+   - pcapraptor rewrite --pcap data.pcap --rules lab.rules
+   - pcapraptor rewrite --pcap data.pcap --rule "ip 10.1.0.0/16 192.168.0.0/16" --output-file lab.pcap`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if rewriteOpts.rulesFile == "" && len(rewriteOpts.rules) == 0 {
+            return errors.New("rules file not set")
+        }
+
+        rewriteOpts.ruleSet = &rewrite.Rules{}
+        if rewriteOpts.rulesFile != "" {
+            if rewriteOpts.rulesFile, err = resolver.ResolveFullPath(rewriteOpts.rulesFile); err != nil {
+                return err
+            }
+            if rewriteOpts.ruleSet, err = rewrite.LoadRules(rewriteOpts.rulesFile); err != nil {
+                return err
+            }
+        }
+        for _, r := range rewriteOpts.rules {
+            if err = rewriteOpts.ruleSet.AddRule(r); err != nil {
+                return err
+            }
+        }
+        if rewriteOpts.ruleSet.Count() == 0 {
+            return errors.New("no rewrite rules found")
+        }
+
+        if pcapFiles.toFile == "" {
+            ext := filepath.Ext(pcapFiles.fromFile)
+            pcapFiles.toFile = strings.TrimSuffix(pcapFiles.fromFile, ext) + "_rewritten" + ext
+        }
+
+        if pcapFiles.toFile, err = checkDestinationFile(pcapFiles.toFile); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        var w *pcapw.Writer
+
+        rw := rewrite.NewRewriter(rewriteOpts.ruleSet)
+        rw.FixChecksums = !rewriteOpts.noChecksum
+
+        log.Infof("Applying %d rules to %s", rewriteOpts.ruleSet.Count(), pcapFiles.toFile)
+
+        packets, err := readPcapFile("Rewriting ->", func(r *gopcap.Reader, h gopcap.PacketHeader, data []byte) error {
+            var err error
+
+            if w == nil {
+                if w, err = pcapw.Open(pcapFiles.toFile, r.Header); err != nil {
+                    return err
+                }
+            }
+
+            out, _ := rw.Apply(data, layers.LinkType(r.Header.Network))
+            return w.WritePacket(h, out)
+        })
+        if w != nil {
+            w.Close()
+        }
+        if err != nil {
+            log.Error("Error rewriting PCAP file", "err", err)
+            os.Exit(2)
+        }
+
+        st := "Rewrite results\n"
+        st += "     -> Rewritten packets..: %s\n"
+        st += "     -> Fixed checksums....: %s\n"
+
+        log.Infof(st,
+            tools.FormatInt64Comma(rw.Stats.Rewritten),
+            tools.FormatInt64Comma(rw.Stats.Checksums),
+        )
+
+        printElapsed("Rewrite status", packets)
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(rewriteCmd)
+
+    rewriteCmd.Flags().StringVarP(&pcapFiles.toFile, "output-file", "o", "", "The file to write rewritten PCAP data to (default: <source>_rewritten.pcap)")
+    rewriteCmd.Flags().StringVarP(&rewriteOpts.rulesFile, "rules", "r", "", "Rewrite rules file")
+    rewriteCmd.Flags().StringArrayVar(&rewriteOpts.rules, "rule", []string{}, "Rewrite rule (can be repeated), same syntax of the rules file")
+    rewriteCmd.Flags().BoolVar(&rewriteOpts.noChecksum, "no-checksum", false, "Do not recompute checksums of rewritten packets")
+}
@@ -71,6 +71,10 @@ type LoggingOptions struct {
 }
 
 var startTime time.Time
+
+// noLogo is set by commands writing machine readable data to stdout
+var noLogo bool
+
 var rootCmd = &cobra.Command{
 	Use:   "pcapraptor",
 	Short: "pcapraptor is a modular PCAP file worker",
@@ -79,7 +83,7 @@ var rootCmd = &cobra.Command{
 
         startTime = time.Now()
 
-	    if cmd.CalledAs() != "version" {
+	    if cmd.CalledAs() != "version" && !noLogo {
 			fmt.Println(ascii.Logo())
 		}
 
@@ -0,0 +1,160 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/pcapsort"
+    "github.com/helviojunior/pcapraptor/internal/ascii"
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/spf13/cobra"
+)
+
+var sortOpts = struct {
+    maxMemory   string
+    tempDir     string
+    window      string
+    reorder     time.Duration
+}{}
+
+var sortCmd = &cobra.Command{
+    Use:   "sort",
+    Short: "Reorder PCAP packets chronologically",
+    Long: ascii.LogoHelp(ascii.Markdown(`
+# sort
+
+Reorder PCAP packets by timestamp.
+
+By default an external merge sort is used, so only --max-memory of packet
+data is kept in memory and multi-GB files can be sorted. With --window a
+single pass sort is done using a small reorder window, suitable for
+streaming use.
+
+A -pcap must be specified.
+`)),
+    Example: `
+   - pcapraptor sort --pcap data.pcap
+   - pcapraptor sort --pcap data.pcap --output-file sorted.pcap --max-memory 1GB
+   - pcapraptor sort --pcap data.pcap --window 500ms`,
+    PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        // Annoying quirk, but because I'm overriding PersistentPreRun
+        // here which overrides the parent it seems.
+        // So we need to explicitly call the parent's one now.
+        if err = rootCmd.PersistentPreRunE(cmd, args); err != nil {
+            return err
+        }
+
+        return nil
+    },
+    PreRunE: func(cmd *cobra.Command, args []string) error {
+        var err error
+
+        if err = checkSourceFile(); err != nil {
+            return err
+        }
+
+        if pcapFiles.toFile == "" {
+            ext := filepath.Ext(pcapFiles.fromFile)
+            pcapFiles.toFile = strings.TrimSuffix(pcapFiles.fromFile, ext) + "_sorted" + ext
+        }
+
+        if pcapFiles.toFile, err = checkDestinationFile(pcapFiles.toFile); err != nil {
+            return err
+        }
+
+        if _, err = tools.ParseBytes(sortOpts.maxMemory); err != nil {
+            return errors.New(fmt.Sprintf("invalid max memory (%s): %s", sortOpts.maxMemory, err))
+        }
+
+        if sortOpts.window != "" {
+            if sortOpts.reorder, err = time.ParseDuration(sortOpts.window); err != nil {
+                return errors.New(fmt.Sprintf("invalid reorder window (%s): %s", sortOpts.window, err))
+            }
+            if sortOpts.reorder <= 0 {
+                return errors.New(fmt.Sprintf("invalid reorder window (%s): must be greater than zero", sortOpts.window))
+            }
+        }
+
+        if sortOpts.tempDir != "" {
+            if sortOpts.tempDir, err = tools.CreateDir(sortOpts.tempDir); err != nil {
+                return err
+            }
+        }
+
+        return nil
+    },
+    Run: func(cmd *cobra.Command, args []string) {
+        var err error
+
+        opts := pcapsort.Options{
+            TempDir: sortOpts.tempDir,
+        }
+        opts.MaxMemory, _ = tools.ParseBytes(sortOpts.maxMemory)
+
+        if sortOpts.window != "" {
+            opts.Window = sortOpts.reorder
+            log.Infof("Sorting using a reorder window of %s", opts.Window)
+        }
+
+        log.Infof("Sorting to %s", pcapFiles.toFile)
+
+        var status = &ConvStatus{
+            Packets: 0,
+            Label: "Sorting packets ->",
+            ShowCounter: true,
+            Spin: "",
+        }
+        opts.OnPacket = func() { status.Packets++ }
+
+        stop := startStatus(status)
+        res, err := pcapsort.SortFile(pcapFiles.fromFile, pcapFiles.toFile, opts)
+        stop()
+
+        if err != nil {
+            log.Error("Error sorting PCAP file", "err", err)
+            os.Exit(2)
+        }
+
+        st := "Sort results\n"
+        st += "     -> Packets sorted.....: %s\n"
+        st += "     -> Out-of-order.......: %s\n"
+        st += "     -> Sorted chunks......: %d\n"
+
+        log.Infof(st,
+            tools.FormatInt64Comma(res.Packets),
+            tools.FormatInt64Comma(res.OutOfOrder),
+            res.Chunks,
+        )
+
+        printElapsed("Sort status", status.Packets)
+    },
+}
+
+func init() {
+    rootCmd.AddCommand(sortCmd)
+
+    sortCmd.Flags().StringVarP(&pcapFiles.toFile, "output-file", "o", "", "The file to write sorted PCAP data to (default: <source>_sorted.pcap)")
+    sortCmd.Flags().StringVar(&sortOpts.maxMemory, "max-memory", "256MB", "Maximum amount of packet data kept in memory")
+    sortCmd.Flags().StringVar(&sortOpts.tempDir, "temp-dir", "", "Directory to store temporary sorted chunks")
+    sortCmd.Flags().StringVar(&sortOpts.window, "window", "", "Sort in a single pass using a reorder window (e.g. 500ms, 2s)")
+}
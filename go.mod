@@ -9,6 +9,7 @@ require (
 	github.com/charmbracelet/log v0.4.1
 	github.com/davecgh/go-spew v1.1.1
 	github.com/google/gopacket v1.1.19
+	github.com/helviojunior/gopathresolver v0.1.0
 	github.com/miekg/pcap v1.0.1
 	github.com/prometheus/procfs v0.16.0
 	github.com/spf13/cobra v1.9.1
@@ -27,7 +28,6 @@ require (
 	github.com/dlclark/regexp2 v1.11.0 // indirect
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
 	github.com/gorilla/css v1.0.1 // indirect
-	github.com/helviojunior/gopathresolver v0.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
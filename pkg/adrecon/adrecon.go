@@ -0,0 +1,591 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package adrecon
+
+import (
+    "net"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/dnsmap"
+    "github.com/helviojunior/pcapraptor/pkg/msproto"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// Domain is an Active Directory domain seen at the capture
+type Domain struct {
+    Name            string          `json:"name"`
+    NetBIOS         string          `json:"netbios"`
+    Forest          string          `json:"forest"`
+    GUID            string          `json:"guid"`
+    Sites           []string        `json:"sites"`
+    Controllers     []string        `json:"controllers"`
+    // kerberos, cldap, ldap, ntlm and dns
+    Sources         []string        `json:"sources"`
+}
+
+// Controller is a domain controller, IP is empty for the controllers
+// only known by their DNS SRV records
+type Controller struct {
+    IP              string          `json:"ip"`
+    Hostname        string          `json:"hostname"`
+    NetBIOS         string          `json:"netbios"`
+    Domain          string          `json:"domain"`
+    Site            string          `json:"site"`
+    // pdc, gc, ldap, ds, kdc, timeserv and writable
+    Roles           []string        `json:"roles"`
+    Evidence        []string        `json:"evidence"`
+}
+
+// User is a user principal seen at Kerberos AS exchanges
+type User struct {
+    Principal       string          `json:"principal"`
+    Name            string          `json:"name"`
+    Realm           string          `json:"realm"`
+    Clients         []string        `json:"clients"`
+    // KDC answers: valid, unknown, revoked, password_expired,
+    // bad_password, authenticated and no_preauth (AS-REP roastable)
+    Status          []string        `json:"status"`
+    Requests        int             `json:"requests"`
+    FirstSeen       time.Time       `json:"first_seen"`
+    LastSeen        time.Time       `json:"last_seen"`
+}
+
+// Service is a service principal requested at Kerberos TGS exchanges
+type Service struct {
+    SPN             string          `json:"spn"`
+    Realm           string          `json:"realm"`
+    Clients         []string        `json:"clients"`
+    Requests        int             `json:"requests"`
+}
+
+// Computer is a domain member identified by its NTLM challenges or its
+// machine account Kerberos requests
+type Computer struct {
+    IP              string          `json:"ip"`
+    NetBIOS         string          `json:"netbios"`
+    Hostname        string          `json:"hostname"`
+    Domain          string          `json:"domain"`
+    Sources         []string        `json:"sources"`
+}
+
+// Report is the Active Directory environment found at the capture
+type Report struct {
+    Domains         []Domain        `json:"domains"`
+    Controllers     []Controller    `json:"controllers"`
+    Users           []User          `json:"users"`
+    Services        []Service       `json:"services"`
+    Computers       []Computer      `json:"computers"`
+}
+
+// krbErrorStatus maps the KDC errors to the user status
+var krbErrorStatus = map[int]string{
+    msproto.KrbErrPrincipalUnknown  : "unknown",
+    msproto.KrbErrClientRevoked     : "revoked",
+    msproto.KrbErrKeyExpired        : "password_expired",
+    msproto.KrbErrPreauthFailed     : "bad_password",
+    msproto.KrbErrPreauthRequired   : "valid",
+}
+
+type asRequest struct {
+    principal       string
+    preauth         bool
+}
+
+// Collector finds the Active Directory environment packet by packet
+type Collector struct {
+    dns             *dnsmap.Collector
+    domains         map[string]*Domain
+    controllers     map[string]*Controller
+    users           map[string]*User
+    services        map[string]*Service
+    computers       map[string]*Computer
+    // last AS-REQ of each client/KDC pair, KDC errors do not always
+    // carry the client principal
+    pending         map[string]asRequest
+}
+
+func NewCollector() *Collector {
+    return &Collector{
+        dns         : dnsmap.NewCollector(),
+        domains     : map[string]*Domain{},
+        controllers : map[string]*Controller{},
+        users       : map[string]*User{},
+        services    : map[string]*Service{},
+        computers   : map[string]*Computer{},
+        pending     : map[string]asRequest{},
+    }
+}
+
+// Add collects the Kerberos, LDAP/CLDAP, NTLM (SMB and others) and DNS
+// data of the packet
+func (c *Collector) Add(packet gopacket.Packet, ts time.Time) {
+    c.dns.Add(packet, ts)
+
+    var src, dst net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        src, dst = ipv4.SrcIP, ipv4.DstIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        src, dst = ipv6.SrcIP, ipv6.DstIP
+    }
+    if src == nil {
+        return
+    }
+
+    var sport, dport int
+    var payload []byte
+    tcp := false
+    if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
+        udp := udpLayer.(*layers.UDP)
+        sport, dport, payload = int(udp.SrcPort), int(udp.DstPort), udp.Payload
+    } else if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+        t := tcpLayer.(*layers.TCP)
+        sport, dport, payload = int(t.SrcPort), int(t.DstPort), t.Payload
+        tcp = true
+    }
+    if len(payload) == 0 {
+        return
+    }
+
+    switch {
+    case sport == msproto.KerberosPort || dport == msproto.KerberosPort:
+        msgs := [][]byte{ payload }
+        if tcp {
+            msgs = msproto.SplitKerberosTCP(payload)
+        }
+        for _, data := range msgs {
+            if m, err := msproto.ParseKerberos(data); err == nil {
+                if sport == msproto.KerberosPort {
+                    c.addKerberos(m, dst.String(), src.String(), ts)
+                } else {
+                    c.addKerberos(m, src.String(), dst.String(), ts)
+                }
+            }
+        }
+
+    case sport == msproto.LDAPPort || sport == msproto.GlobalCatalogPort:
+        for _, e := range msproto.ParseLDAPEntries(payload) {
+            c.addLDAP(e, src.String(), sport == msproto.GlobalCatalogPort, !tcp)
+        }
+    }
+
+    if tcp {
+        for _, m := range msproto.FindNTLM(payload) {
+            if m.Type == msproto.NTLMChallenge {
+                c.addChallenge(m, src.String())
+            }
+        }
+    }
+}
+
+func (c *Collector) domain(name string) *Domain {
+    name = strings.TrimSuffix(strings.ToLower(name), ".")
+    if name == "" {
+        return nil
+    }
+    d, ok := c.domains[name]
+    if !ok {
+        d = &Domain{ Name: name, Sites: []string{}, Controllers: []string{}, Sources: []string{} }
+        c.domains[name] = d
+    }
+    return d
+}
+
+func (c *Collector) controller(key string) *Controller {
+    dc, ok := c.controllers[key]
+    if !ok {
+        dc = &Controller{ Roles: []string{}, Evidence: []string{} }
+        if net.ParseIP(key) != nil {
+            dc.IP = key
+        } else {
+            dc.Hostname = key
+        }
+        c.controllers[key] = dc
+    }
+    return dc
+}
+
+func (c *Collector) computer(ip string) *Computer {
+    cp, ok := c.computers[ip]
+    if !ok {
+        cp = &Computer{ IP: ip, Sources: []string{} }
+        c.computers[ip] = cp
+    }
+    return cp
+}
+
+func (c *Collector) user(name, realm string) *User {
+    realm = strings.ToUpper(realm)
+    principal := name + "@" + realm
+    key := strings.ToLower(principal)
+    u, ok := c.users[key]
+    if !ok {
+        u = &User{ Principal: principal, Name: name, Realm: realm, Clients: []string{}, Status: []string{} }
+        c.users[key] = u
+    }
+    return u
+}
+
+func (c *Collector) addKerberos(m *msproto.KerberosMessage, client, kdc string, ts time.Time) {
+    if m.Realm != "" {
+        addUnique(&c.domain(m.Realm).Sources, "kerberos")
+    }
+    isResponse := m.Type == msproto.KrbASRep || m.Type == msproto.KrbTGSRep || m.Type == msproto.KrbError
+    if isResponse {
+        dc := c.controller(kdc)
+        addUnique(&dc.Roles, "kdc")
+        addUnique(&dc.Evidence, "kerberos")
+        if dc.Domain == "" {
+            dc.Domain = strings.ToLower(m.Realm)
+        }
+    }
+
+    pairKey := client + "|" + kdc
+    switch m.Type {
+    case msproto.KrbASReq:
+        name := m.Client()
+        if name == "" {
+            return
+        }
+        if strings.HasSuffix(name, "$") {
+            cp := c.computer(client)
+            cp.NetBIOS = strings.ToUpper(strings.TrimSuffix(name, "$"))
+            if cp.Domain == "" {
+                cp.Domain = strings.ToLower(m.Realm)
+            }
+            addUnique(&cp.Sources, "kerberos")
+            return
+        }
+        u := c.user(name, m.Realm)
+        u.Requests++
+        addUnique(&u.Clients, client)
+        if u.FirstSeen.IsZero() || ts.Before(u.FirstSeen) {
+            u.FirstSeen = ts
+        }
+        if ts.After(u.LastSeen) {
+            u.LastSeen = ts
+        }
+        c.pending[pairKey] = asRequest{ principal: u.Principal, preauth: m.PreAuth != nil }
+
+    case msproto.KrbASRep:
+        name := m.Client()
+        if name == "" || strings.HasSuffix(name, "$") {
+            return
+        }
+        u := c.user(name, m.Realm)
+        status := "authenticated"
+        if req, ok := c.pending[pairKey]; ok && strings.EqualFold(req.principal, u.Principal) && !req.preauth {
+            status = "no_preauth"
+        }
+        addUnique(&u.Status, status)
+
+    case msproto.KrbTGSReq:
+        spn := m.Service()
+        if spn == "" || strings.EqualFold(m.SName[0], "krbtgt") {
+            return
+        }
+        realm := strings.ToUpper(m.Realm)
+        key := strings.ToLower(spn + "@" + realm)
+        s, ok := c.services[key]
+        if !ok {
+            s = &Service{ SPN: spn, Realm: realm, Clients: []string{} }
+            c.services[key] = s
+        }
+        s.Requests++
+        addUnique(&s.Clients, client)
+
+    case msproto.KrbError:
+        status, ok := krbErrorStatus[m.ErrorCode]
+        if !ok {
+            return
+        }
+        var u *User
+        if name := m.Client(); name != "" {
+            if strings.HasSuffix(name, "$") {
+                return
+            }
+            u = c.user(name, m.Realm)
+        } else if req, ok := c.pending[pairKey]; ok {
+            u = c.users[strings.ToLower(req.principal)]
+        }
+        if u != nil {
+            addUnique(&u.Status, status)
+        }
+    }
+}
+
+func (c *Collector) addLDAP(e *msproto.LDAPEntry, server string, gc bool, cldap bool) {
+    if values := e.Attributes["netlogon"]; len(values) > 0 {
+        n, err := msproto.ParseNetlogon(values[0])
+        if err != nil {
+            return
+        }
+        dc := c.controller(server)
+        addUnique(&dc.Evidence, "cldap")
+        for _, r := range n.Roles() {
+            addUnique(&dc.Roles, r)
+        }
+        dc.Hostname = strings.ToLower(n.HostName)
+        dc.NetBIOS = strings.ToUpper(n.NetBIOSComputer)
+        dc.Domain = strings.ToLower(n.Domain)
+        if n.DCSite != "" {
+            dc.Site = n.DCSite
+        }
+
+        if d := c.domain(n.Domain); d != nil {
+            addUnique(&d.Sources, "cldap")
+            d.NetBIOS = strings.ToUpper(n.NetBIOSDomain)
+            d.Forest = strings.ToLower(n.Forest)
+            d.GUID = n.DomainGUID
+            for _, s := range []string{ n.DCSite, n.ClientSite } {
+                if s != "" {
+                    addUnique(&d.Sites, s)
+                }
+            }
+        }
+        return
+    }
+
+    // rootDSE, any LDAP server answers it so only the AD ones are taken
+    domain := msproto.DNToDomain(e.Value("defaultNamingContext"))
+    if domain == "" || !isADRootDSE(e) {
+        return
+    }
+    dc := c.controller(server)
+    evidence := "ldap"
+    if cldap {
+        evidence = "cldap"
+    }
+    addUnique(&dc.Evidence, evidence)
+    addUnique(&dc.Roles, "ldap")
+    if gc {
+        addUnique(&dc.Roles, "gc")
+    }
+    if host := e.Value("dnsHostName"); host != "" {
+        dc.Hostname = strings.ToLower(host)
+    }
+    dc.Domain = domain
+    d := c.domain(domain)
+    addUnique(&d.Sources, "ldap")
+    if forest := msproto.DNToDomain(e.Value("rootDomainNamingContext")); forest != "" {
+        d.Forest = forest
+    }
+}
+
+// LDAP_CAP_ACTIVE_DIRECTORY_OID, announced by the AD rootDSE
+const adCapabilityOID = "1.2.840.113556.1.4.800"
+
+// isADRootDSE tells if the rootDSE is of an AD domain controller: the
+// AD capability OID or the attributes only AD publishes
+func isADRootDSE(e *msproto.LDAPEntry) bool {
+    for _, v := range e.Attributes["supportedcapabilities"] {
+        if string(v) == adCapabilityOID {
+            return true
+        }
+    }
+    for _, a := range []string{ "dnsHostName", "rootDomainNamingContext", "domainControllerFunctionality", "dsServiceName" } {
+        if e.Value(a) != "" {
+            return true
+        }
+    }
+    return false
+}
+
+func (c *Collector) addChallenge(m *msproto.NTLMMessage, server string) {
+    if m.NbComputerName == "" {
+        return
+    }
+    cp := c.computer(server)
+    cp.NetBIOS = strings.ToUpper(m.NbComputerName)
+    if m.DnsComputerName != "" {
+        cp.Hostname = strings.ToLower(m.DnsComputerName)
+    }
+    addUnique(&cp.Sources, "ntlm")
+
+    // stand alone hosts answer with their own name as the domain
+    if m.DnsDomainName == "" || strings.EqualFold(m.NbDomainName, m.NbComputerName) {
+        return
+    }
+    cp.Domain = strings.ToLower(m.DnsDomainName)
+    d := c.domain(m.DnsDomainName)
+    addUnique(&d.Sources, "ntlm")
+    if m.NbDomainName != "" {
+        d.NetBIOS = strings.ToUpper(m.NbDomainName)
+    }
+    if m.DnsTreeName != "" {
+        d.Forest = strings.ToLower(m.DnsTreeName)
+    }
+}
+
+// addSRV adds the domains and controllers of the AD SRV records
+func (c *Collector) addSRV() {
+    res := c.dns.Result()
+    names := c.dns.Resolver()
+    ips := map[string][]string{}
+    for _, ip := range names.IPs() {
+        for _, n := range names.Names(ip) {
+            ips[n] = append(ips[n], ip)
+        }
+    }
+
+    for _, r := range res.ADRecords {
+        d := c.domain(dnsmap.ADDomain(r.Name))
+        if d == nil {
+            continue
+        }
+        addUnique(&d.Sources, "dns")
+        if idx := strings.Index(r.Name, "._sites."); idx > 0 {
+            labels := strings.Split(r.Name[:idx], ".")
+            addUnique(&d.Sites, labels[len(labels) - 1])
+        }
+
+        role := ""
+        switch {
+        case strings.HasPrefix(r.Name, "_ldap._tcp.pdc."):
+            role = "pdc"
+        case strings.HasPrefix(r.Name, "_gc."):
+            role = "gc"
+            if !strings.Contains(r.Name, "._msdcs.") && !strings.Contains(r.Name, "._sites.") {
+                d.Forest = d.Name
+            }
+        case strings.HasPrefix(r.Name, "_kerberos."):
+            role = "kdc"
+        case strings.HasPrefix(r.Name, "_ldap."):
+            role = "ldap"
+        }
+
+        // SRV value: priority weight port target
+        fields := strings.Fields(r.Value)
+        if len(fields) != 4 || role == "" {
+            continue
+        }
+        target := fields[3]
+        keys := ips[target]
+        if len(keys) == 0 {
+            keys = []string{ target }
+        }
+        for _, key := range keys {
+            dc := c.controller(key)
+            if dc.Hostname == "" {
+                dc.Hostname = target
+            }
+            if dc.Domain == "" {
+                dc.Domain = d.Name
+            }
+            addUnique(&dc.Roles, role)
+            addUnique(&dc.Evidence, "dns")
+        }
+    }
+}
+
+// Report returns the Active Directory environment collected
+func (c *Collector) Report() *Report {
+    c.addSRV()
+
+    rpt := &Report{
+        Domains     : []Domain{},
+        Controllers : []Controller{},
+        Users       : []User{},
+        Services    : []Service{},
+        Computers   : []Computer{},
+    }
+
+    for ip, cp := range c.computers {
+        if dc, ok := c.controllers[ip]; ok {
+            if dc.NetBIOS == "" {
+                dc.NetBIOS = cp.NetBIOS
+            }
+            if dc.Hostname == "" {
+                dc.Hostname = cp.Hostname
+            }
+            if dc.Domain == "" {
+                dc.Domain = cp.Domain
+            }
+        }
+        rpt.Computers = append(rpt.Computers, *cp)
+    }
+
+    for _, dc := range c.controllers {
+        // controllers also known by address replace their SRV only entry
+        if dc.IP == "" && c.hasControllerIP(dc.Hostname) {
+            continue
+        }
+        sort.Strings(dc.Roles)
+        sort.Strings(dc.Evidence)
+        rpt.Controllers = append(rpt.Controllers, *dc)
+        if d, ok := c.domains[dc.Domain]; ok {
+            id := dc.IP
+            if id == "" {
+                id = dc.Hostname
+            }
+            addUnique(&d.Controllers, id)
+        }
+    }
+
+    for _, d := range c.domains {
+        sort.Strings(d.Sites)
+        sort.Strings(d.Controllers)
+        sort.Strings(d.Sources)
+        rpt.Domains = append(rpt.Domains, *d)
+    }
+    for _, u := range c.users {
+        sort.Strings(u.Clients)
+        sort.Strings(u.Status)
+        rpt.Users = append(rpt.Users, *u)
+    }
+    for _, s := range c.services {
+        sort.Strings(s.Clients)
+        rpt.Services = append(rpt.Services, *s)
+    }
+
+    sort.Slice(rpt.Domains, func(i, j int) bool { return rpt.Domains[i].Name < rpt.Domains[j].Name })
+    sort.Slice(rpt.Controllers, func(i, j int) bool {
+        a, b := rpt.Controllers[i], rpt.Controllers[j]
+        if a.Domain != b.Domain {
+            return a.Domain < b.Domain
+        }
+        return a.IP + a.Hostname < b.IP + b.Hostname
+    })
+    sort.Slice(rpt.Users, func(i, j int) bool {
+        return strings.ToLower(rpt.Users[i].Principal) < strings.ToLower(rpt.Users[j].Principal)
+    })
+    sort.Slice(rpt.Services, func(i, j int) bool {
+        return strings.ToLower(rpt.Services[i].SPN) < strings.ToLower(rpt.Services[j].SPN)
+    })
+    sort.Slice(rpt.Computers, func(i, j int) bool { return rpt.Computers[i].IP < rpt.Computers[j].IP })
+
+    return rpt
+}
+
+func (c *Collector) hasControllerIP(hostname string) bool {
+    for _, dc := range c.controllers {
+        if dc.IP != "" && strings.EqualFold(dc.Hostname, hostname) {
+            return true
+        }
+    }
+    return false
+}
+
+func addUnique(list *[]string, s string) {
+    for _, v := range *list {
+        if strings.EqualFold(v, s) {
+            return
+        }
+    }
+    *list = append(*list, s)
+}
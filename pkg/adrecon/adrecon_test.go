@@ -0,0 +1,56 @@
+package adrecon
+
+import (
+	"testing"
+
+	"github.com/helviojunior/pcapraptor/pkg/msproto"
+)
+
+func entry(attrs map[string]string) *msproto.LDAPEntry {
+	e := &msproto.LDAPEntry{Attributes: map[string][][]byte{}}
+	for k, v := range attrs {
+		e.Attributes[k] = [][]byte{[]byte(v)}
+	}
+	return e
+}
+
+func TestAddLDAPRootDSE(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		dc    bool
+	}{
+		{
+			name:  "openldap",
+			attrs: map[string]string{"namingcontexts": "dc=example,dc=com", "defaultnamingcontext": "dc=example,dc=com"},
+		},
+		{
+			name:  "search result",
+			attrs: map[string]string{"cn": "John", "samaccountname": "john"},
+		},
+		{
+			name:  "active directory",
+			attrs: map[string]string{"defaultnamingcontext": "DC=corp,DC=local", "dnshostname": "dc01.corp.local"},
+			dc:    true,
+		},
+		{
+			name:  "capability",
+			attrs: map[string]string{"defaultnamingcontext": "DC=corp,DC=local", "supportedcapabilities": adCapabilityOID},
+			dc:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector()
+			c.addLDAP(entry(tt.attrs), "10.0.0.10", false, false)
+			_, ok := c.controllers["10.0.0.10"]
+			if ok != tt.dc {
+				t.Fatalf("expected controller %v, got %v", tt.dc, ok)
+			}
+			if ok && c.controllers["10.0.0.10"].Domain != "corp.local" {
+				t.Fatalf("unexpected domain %q", c.controllers["10.0.0.10"].Domain)
+			}
+		})
+	}
+}
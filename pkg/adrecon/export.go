@@ -0,0 +1,115 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package adrecon
+
+import (
+    "encoding/csv"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// JSONLines writes one record per domain, controller, user, service and
+// computer, the "type" field tells them apart
+func (r *Report) JSONLines() []interface{} {
+    type kind struct {
+        Type    string  `json:"type"`
+    }
+    list := []interface{}{}
+    for _, d := range r.Domains {
+        list = append(list, struct {
+            kind
+            Domain
+        }{ kind{ "domain" }, d })
+    }
+    for _, dc := range r.Controllers {
+        list = append(list, struct {
+            kind
+            Controller
+        }{ kind{ "controller" }, dc })
+    }
+    for _, u := range r.Users {
+        list = append(list, struct {
+            kind
+            User
+        }{ kind{ "user" }, u })
+    }
+    for _, s := range r.Services {
+        list = append(list, struct {
+            kind
+            Service
+        }{ kind{ "service" }, s })
+    }
+    for _, cp := range r.Computers {
+        list = append(list, struct {
+            kind
+            Computer
+        }{ kind{ "computer" }, cp })
+    }
+    return list
+}
+
+var reportCSVHeader = []string{
+    "type", "name", "ip", "domain", "netbios", "forest", "site", "roles", "status",
+    "sources", "clients", "requests",
+}
+
+// WriteCSV writes one row per domain, controller, user, service and
+// computer, multi-valued columns are separated by '; '
+func (r *Report) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(reportCSVHeader); err != nil {
+        return err
+    }
+
+    rows := [][]string{}
+    for _, d := range r.Domains {
+        rows = append(rows, []string{
+            "domain", d.Name, "", d.Name, d.NetBIOS, d.Forest, strings.Join(d.Sites, "; "), "", "",
+            strings.Join(d.Sources, "; "), "", "",
+        })
+    }
+    for _, dc := range r.Controllers {
+        rows = append(rows, []string{
+            "controller", dc.Hostname, dc.IP, dc.Domain, dc.NetBIOS, "", dc.Site, strings.Join(dc.Roles, "; "), "",
+            strings.Join(dc.Evidence, "; "), "", "",
+        })
+    }
+    for _, u := range r.Users {
+        rows = append(rows, []string{
+            "user", u.Principal, "", strings.ToLower(u.Realm), "", "", "", "", strings.Join(u.Status, "; "),
+            "kerberos", strings.Join(u.Clients, "; "), strconv.Itoa(u.Requests),
+        })
+    }
+    for _, s := range r.Services {
+        rows = append(rows, []string{
+            "service", s.SPN, "", strings.ToLower(s.Realm), "", "", "", "", "",
+            "kerberos", strings.Join(s.Clients, "; "), strconv.Itoa(s.Requests),
+        })
+    }
+    for _, cp := range r.Computers {
+        rows = append(rows, []string{
+            "computer", cp.Hostname, cp.IP, cp.Domain, cp.NetBIOS, "", "", "", "",
+            strings.Join(cp.Sources, "; "), "", "",
+        })
+    }
+
+    for _, row := range rows {
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
@@ -0,0 +1,264 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package capinfo
+
+import (
+    "encoding/binary"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// TimeRange holds the first and last packet time of a capture
+type TimeRange struct {
+    First           time.Time       `json:"first"`
+    Last            time.Time       `json:"last"`
+    Duration        float64         `json:"duration_seconds"`
+}
+
+// ProtocolCount is one node of the protocol hierarchy
+type ProtocolCount struct {
+    Path            string          `json:"path"`
+    Protocol        string          `json:"protocol"`
+    Depth           int             `json:"depth"`
+    Packets         int64           `json:"packets"`
+    Bytes           int64           `json:"bytes"`
+}
+
+// Talker holds the traffic counters of one network address
+type Talker struct {
+    Address         string          `json:"address"`
+    Packets         int64           `json:"packets"`
+    Bytes           int64           `json:"bytes"`
+}
+
+// Stats is the capture statistics summary
+type Stats struct {
+    File            string          `json:"file"`
+    Format          string          `json:"format"`
+    Version         string          `json:"version"`
+    Endianness      string          `json:"endianness"`
+    Resolution      string          `json:"timestamp_resolution"`
+    LinkType        string          `json:"link_type"`
+    LinkTypeId      uint32          `json:"link_type_id"`
+    Snaplen         uint32          `json:"snaplen"`
+
+    Packets         int64           `json:"packets"`
+    CapturedBytes   int64           `json:"captured_bytes"`
+    OriginalBytes   int64           `json:"original_bytes"`
+    Truncated       int64           `json:"truncated_packets"`
+
+    Raw             TimeRange       `json:"raw_time"`
+    Corrected       *TimeRange      `json:"corrected_time,omitempty"`
+    TimeShift       float64         `json:"time_shift_seconds,omitempty"`
+
+    AvgPacketSize   float64         `json:"avg_packet_size"`
+    AvgPacketRate   float64         `json:"avg_packets_per_second"`
+    AvgByteRate     float64         `json:"avg_bytes_per_second"`
+    AvgBitRate      float64         `json:"avg_bits_per_second"`
+
+    OutOfOrder      int64           `json:"out_of_order_packets"`
+    MaxBackwardJump float64         `json:"max_backward_jump_seconds"`
+
+    Protocols       []ProtocolCount `json:"protocol_hierarchy"`
+    TopTalkers      []Talker        `json:"top_talkers"`
+}
+
+// Collector accumulates the statistics of a capture file packet by packet
+type Collector struct {
+    header          gopcap.FileHeader
+    linkType        layers.LinkType
+    stats           Stats
+    maxTime         time.Time
+    protocols       map[string]*ProtocolCount
+    talkers         map[string]*Talker
+    TopCount        int
+}
+
+// NewCollector returns a collector for the file opened by the given reader
+func NewCollector(fileName string, r *gopcap.Reader) *Collector {
+    c := &Collector{
+        header      : r.Header,
+        linkType    : layers.LinkType(r.Header.Network),
+        protocols   : map[string]*ProtocolCount{},
+        talkers     : map[string]*Talker{},
+        TopCount    : 10,
+    }
+
+    c.stats.File = fileName
+    c.stats.Format = "pcap"
+    c.stats.Version = fmt.Sprintf("%d.%d", r.Header.VersionMajor, r.Header.VersionMinor)
+    c.stats.Endianness = "little-endian"
+    if r.ByteOrder == binary.BigEndian {
+        c.stats.Endianness = "big-endian"
+    }
+    c.stats.Resolution = "microseconds"
+    if r.Header.IsNanosecond() {
+        c.stats.Resolution = "nanoseconds"
+    }
+    c.stats.LinkType = c.linkType.String()
+    c.stats.LinkTypeId = r.Header.Network
+    c.stats.Snaplen = r.Header.Snaplen
+
+    return c
+}
+
+// Add accounts one packet
+func (c *Collector) Add(h gopcap.PacketHeader, data []byte) {
+    ts := c.header.PacketTime(h)
+
+    c.stats.Packets++
+    c.stats.CapturedBytes += int64(h.CaptureLen)
+    c.stats.OriginalBytes += int64(h.OriginalLen)
+    if h.OriginalLen > h.CaptureLen {
+        c.stats.Truncated++
+    }
+
+    if c.stats.Packets == 1 {
+        c.stats.Raw.First = ts
+        c.stats.Raw.Last = ts
+        c.maxTime = ts
+    } else {
+        if ts.Before(c.stats.Raw.First) {
+            c.stats.Raw.First = ts
+        }
+        if ts.After(c.stats.Raw.Last) {
+            c.stats.Raw.Last = ts
+        }
+        if ts.Before(c.maxTime) {
+            c.stats.OutOfOrder++
+            if jump := c.maxTime.Sub(ts).Seconds(); jump > c.stats.MaxBackwardJump {
+                c.stats.MaxBackwardJump = jump
+            }
+        } else {
+            c.maxTime = ts
+        }
+    }
+
+    packet := gopacket.NewPacket(data, c.linkType, gopacket.NoCopy)
+
+    path := ""
+    for i, l := range packet.Layers() {
+        lt := l.LayerType()
+        if lt == gopacket.LayerTypePayload || lt == gopacket.LayerTypeDecodeFailure {
+            break
+        }
+        if path != "" {
+            path += "/"
+        }
+        path += lt.String()
+
+        pc, ok := c.protocols[path]
+        if !ok {
+            pc = &ProtocolCount{
+                Path        : path,
+                Protocol    : lt.String(),
+                Depth       : i,
+            }
+            c.protocols[path] = pc
+        }
+        pc.Packets++
+        pc.Bytes += int64(h.OriginalLen)
+    }
+
+    if nl := packet.NetworkLayer(); nl != nil {
+        src, dst := nl.NetworkFlow().Endpoints()
+        c.addTalker(src.String(), int64(h.OriginalLen))
+        if dst != src {
+            c.addTalker(dst.String(), int64(h.OriginalLen))
+        }
+    }
+}
+
+func (c *Collector) addTalker(addr string, size int64) {
+    t, ok := c.talkers[addr]
+    if !ok {
+        t = &Talker{ Address: addr }
+        c.talkers[addr] = t
+    }
+    t.Packets++
+    t.Bytes += size
+}
+
+// Stats calculates the final statistics. When shift is not nil the
+// corrected time range is filled too.
+func (c *Collector) Stats(shift *time.Duration) *Stats {
+    st := c.stats
+
+    st.Raw.Duration = st.Raw.Last.Sub(st.Raw.First).Seconds()
+    if shift != nil {
+        st.TimeShift = shift.Seconds()
+        st.Corrected = &TimeRange{
+            First       : st.Raw.First.Add(*shift),
+            Last        : st.Raw.Last.Add(*shift),
+            Duration    : st.Raw.Duration,
+        }
+    }
+
+    if st.Packets > 0 {
+        st.AvgPacketSize = float64(st.OriginalBytes) / float64(st.Packets)
+    }
+    if st.Raw.Duration > 0 {
+        st.AvgPacketRate = float64(st.Packets) / st.Raw.Duration
+        st.AvgByteRate = float64(st.OriginalBytes) / st.Raw.Duration
+        st.AvgBitRate = st.AvgByteRate * 8
+    }
+
+    st.Protocols = []ProtocolCount{}
+    for _, p := range c.protocols {
+        st.Protocols = append(st.Protocols, *p)
+    }
+    sort.Slice(st.Protocols, func(i, j int) bool {
+        return st.Protocols[i].Path < st.Protocols[j].Path
+    })
+
+    st.TopTalkers = []Talker{}
+    for _, t := range c.talkers {
+        st.TopTalkers = append(st.TopTalkers, *t)
+    }
+    sort.Slice(st.TopTalkers, func(i, j int) bool {
+        if st.TopTalkers[i].Bytes == st.TopTalkers[j].Bytes {
+            return st.TopTalkers[i].Address < st.TopTalkers[j].Address
+        }
+        return st.TopTalkers[i].Bytes > st.TopTalkers[j].Bytes
+    })
+    if c.TopCount > 0 && len(st.TopTalkers) > c.TopCount {
+        st.TopTalkers = st.TopTalkers[:c.TopCount]
+    }
+
+    return &st
+}
+
+// ProtocolTree returns the protocol hierarchy as an indented text
+func (st *Stats) ProtocolTree() string {
+    var b strings.Builder
+    for _, p := range st.Protocols {
+        width := 24 - (p.Depth * 2)
+        if width < 1 {
+            width = 1
+        }
+        pct := float64(0)
+        if st.Packets > 0 {
+            pct = float64(p.Packets) * 100 / float64(st.Packets)
+        }
+        fmt.Fprintf(&b, "     %s%-*s %10d pkts %14d bytes %6.2f%%\n",
+            strings.Repeat("  ", p.Depth), width, p.Protocol, p.Packets, p.Bytes, pct)
+    }
+    return b.String()
+}
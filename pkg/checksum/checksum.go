@@ -0,0 +1,276 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package checksum
+
+import (
+    "encoding/binary"
+    "net"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// sum adds data as 16 bit big endian words (RFC 1071)
+func sum(data []byte, initial uint32) uint32 {
+    s := initial
+    n := len(data)
+    for i := 0; i + 1 < n; i += 2 {
+        s += uint32(data[i]) << 8 | uint32(data[i+1])
+    }
+    if n % 2 == 1 {
+        s += uint32(data[n-1]) << 8
+    }
+    return s
+}
+
+// fold returns the one's complement of the folded sum
+func fold(s uint32) uint16 {
+    for s > 0xffff {
+        s = (s >> 16) + (s & 0xffff)
+    }
+    return ^uint16(s)
+}
+
+// pseudoHeader returns the sum of the IPv4/IPv6 pseudo header
+func pseudoHeader(src, dst net.IP, proto layers.IPProtocol, length int) uint32 {
+    var s uint32
+    if ip4 := src.To4(); ip4 != nil && dst.To4() != nil {
+        s = sum(ip4, s)
+        s = sum(dst.To4(), s)
+    } else {
+        s = sum(src.To16(), s)
+        s = sum(dst.To16(), s)
+    }
+    s += uint32(proto)
+    s += uint32(length)
+    return s
+}
+
+// IPv4Header calculates the IPv4 header checksum (checksum field ignored)
+func IPv4Header(header []byte) uint16 {
+    var s uint32
+    s = sum(header[:10], s)
+    s = sum(header[12:], s)
+    return fold(s)
+}
+
+// Transport calculates a TCP/UDP checksum of segment, skipping the
+// checksum field at the given offset
+func Transport(src, dst net.IP, proto layers.IPProtocol, segment []byte, csumOffset int) uint16 {
+    s := pseudoHeader(src, dst, proto, len(segment))
+    s = sum(segment[:csumOffset], s)
+    s = sum(segment[csumOffset+2:], s)
+    return fold(s)
+}
+
+// Protocol is a protocol whose checksum is checked
+type Protocol string
+
+const (
+    ProtoIPv4       Protocol = "IPv4"
+    ProtoTCP        Protocol = "TCP"
+    ProtoUDP        Protocol = "UDP"
+    ProtoICMPv4     Protocol = "ICMPv4"
+    ProtoICMPv6     Protocol = "ICMPv6"
+)
+
+// Protocols lists the checked protocols
+var Protocols = []Protocol{ ProtoIPv4, ProtoTCP, ProtoUDP, ProtoICMPv4, ProtoICMPv6 }
+
+// Status is the result of a checksum verification
+type Status int
+
+const (
+    // checksum is valid
+    StatusOk Status = iota
+    // checksum is invalid
+    StatusBad
+    // checksum left by NIC offload: zero or only the pseudo header sum
+    StatusOffload
+    // UDP checksum disabled (zero over IPv4)
+    StatusDisabled
+    // packet is truncated, checksum cannot be calculated
+    StatusTruncated
+)
+
+func (s Status) String() string {
+    switch s {
+    case StatusOk:
+        return "ok"
+    case StatusBad:
+        return "bad"
+    case StatusOffload:
+        return "offload"
+    case StatusDisabled:
+        return "disabled"
+    case StatusTruncated:
+        return "truncated"
+    }
+    return "unknown"
+}
+
+// Result is the checksum verification of one protocol layer
+type Result struct {
+    Protocol        Protocol
+    Status          Status
+    Found           uint16
+    Expected        uint16
+    // source address of the packet owning the checksum
+    Source          net.IP
+}
+
+// Verify checks the IPv4, TCP, UDP, ICMPv4 and ICMPv6 checksums of a
+// decoded packet without changing it
+func Verify(packet gopacket.Packet) []Result {
+    return walk(packet, false)
+}
+
+// Fix recomputes in place the IPv4, TCP, UDP, ICMPv4 and ICMPv6 checksums
+// of a decoded packet. The packet must have been decoded with
+// gopacket.NoCopy so its layers point to the frame bytes. Returns the
+// number of fixed checksums.
+func Fix(packet gopacket.Packet) int {
+    fixed := 0
+    for _, r := range walk(packet, true) {
+        if r.Status == StatusBad || r.Status == StatusOffload {
+            fixed++
+        }
+    }
+    return fixed
+}
+
+// FixFrame decodes a frame and fixes its checksums, returning the new
+// frame (the original data is not changed) and the number of fixes
+func FixFrame(data []byte, linkType layers.LinkType) ([]byte, int) {
+    buf := make([]byte, len(data))
+    copy(buf, data)
+    n := Fix(gopacket.NewPacket(buf, linkType, gopacket.NoCopy))
+    if n == 0 {
+        return data, 0
+    }
+    return buf, n
+}
+
+// walk verifies (and optionally fixes) every checksum of the packet
+func walk(packet gopacket.Packet, fix bool) []Result {
+    results := []Result{}
+    truncated := packet.Metadata().Truncated
+
+    var src, dst net.IP
+    pl := packet.Layers()
+    for i, l := range pl {
+        switch t := l.(type) {
+        case *layers.IPv4:
+            src, dst = t.SrcIP, t.DstIP
+            hdr := t.LayerContents()
+            if len(hdr) < 20 {
+                continue
+            }
+            found := binary.BigEndian.Uint16(hdr[10:12])
+            r := Result{ Protocol: ProtoIPv4, Found: found, Expected: IPv4Header(hdr), Source: src }
+            r.Status = status(r.Found, r.Expected, 0xffff)
+            if fix && r.Status != StatusOk {
+                binary.BigEndian.PutUint16(hdr[10:12], r.Expected)
+            }
+            results = append(results, r)
+
+        case *layers.IPv6:
+            src, dst = t.SrcIP, t.DstIP
+
+        case *layers.TCP:
+            if r, ok := transport(ProtoTCP, layers.IPProtocolTCP, pl, i, src, dst, 16, 20, truncated, fix); ok {
+                results = append(results, r)
+            }
+
+        case *layers.UDP:
+            if r, ok := transport(ProtoUDP, layers.IPProtocolUDP, pl, i, src, dst, 6, 8, truncated, fix); ok {
+                results = append(results, r)
+            }
+
+        case *layers.ICMPv4:
+            if i == 0 {
+                continue
+            }
+            msg := pl[i-1].LayerPayload()
+            if len(msg) < 4 {
+                continue
+            }
+            found := binary.BigEndian.Uint16(msg[2:4])
+            var s uint32
+            s = sum(msg[:2], s)
+            s = sum(msg[4:], s)
+            r := Result{ Protocol: ProtoICMPv4, Found: found, Expected: fold(s), Source: src }
+            r.Status = status(r.Found, r.Expected, 0xffff)
+            if truncated && r.Status != StatusOk {
+                r.Status = StatusTruncated
+            } else if fix && r.Status != StatusOk {
+                binary.BigEndian.PutUint16(msg[2:4], r.Expected)
+            }
+            results = append(results, r)
+
+        case *layers.ICMPv6:
+            if r, ok := transport(ProtoICMPv6, layers.IPProtocolICMPv6, pl, i, src, dst, 2, 4, truncated, fix); ok {
+                results = append(results, r)
+            }
+        }
+    }
+
+    return results
+}
+
+// transport verifies a checksum covered by the IP pseudo header
+func transport(proto Protocol, ipProto layers.IPProtocol, pl []gopacket.Layer, i int, src, dst net.IP, csumOffset int, minSize int, truncated bool, fix bool) (Result, bool) {
+    if src == nil || i == 0 {
+        return Result{}, false
+    }
+    seg := pl[i-1].LayerPayload()
+    if len(seg) < minSize {
+        return Result{}, false
+    }
+
+    found := binary.BigEndian.Uint16(seg[csumOffset:csumOffset+2])
+    r := Result{ Protocol: proto, Found: found, Source: src }
+
+    if proto == ProtoUDP && found == 0 && src.To4() != nil {
+        r.Status = StatusDisabled
+        return r, true
+    }
+
+    r.Expected = Transport(src, dst, ipProto, seg, csumOffset)
+    if proto == ProtoUDP && r.Expected == 0 {
+        r.Expected = 0xffff
+    }
+
+    // NIC offload leaves the (not complemented) pseudo header sum
+    partial := ^fold(pseudoHeader(src, dst, ipProto, len(seg)))
+    r.Status = status(r.Found, r.Expected, partial)
+
+    if truncated && r.Status != StatusOk {
+        r.Status = StatusTruncated
+    } else if fix && r.Status != StatusOk {
+        binary.BigEndian.PutUint16(seg[csumOffset:csumOffset+2], r.Expected)
+    }
+
+    return r, true
+}
+
+func status(found, expected, partial uint16) Status {
+    if found == expected {
+        return StatusOk
+    }
+    if found == 0 || found == partial {
+        return StatusOffload
+    }
+    return StatusBad
+}
@@ -0,0 +1,153 @@
+package checksum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestFold(t *testing.T) {
+	// RFC 1071 section 3 example
+	data := []byte{0x00, 0x01, 0xf2, 0x03, 0xf4, 0xf5, 0xf6, 0xf7}
+	if got := fold(sum(data, 0)); got != ^uint16(0xddf2) {
+		t.Errorf("expected %04x, got %04x", ^uint16(0xddf2), got)
+	}
+	// odd length, the last byte is padded with zero
+	if got := fold(sum([]byte{0x01, 0x02, 0x03}, 0)); got != ^uint16(0x0402) {
+		t.Errorf("expected %04x, got %04x", ^uint16(0x0402), got)
+	}
+}
+
+var (
+	srcIPv4 = net.IP{192, 168, 10, 5}
+	dstIPv4 = net.IP{10, 0, 0, 20}
+	srcIPv6 = net.ParseIP("2001:db8::5")
+	dstIPv6 = net.ParseIP("2001:db8::20")
+)
+
+// pseudoHeaderLayer is a layer whose checksum covers the IP pseudo header
+type pseudoHeaderLayer interface {
+	SetNetworkLayerForChecksum(gopacket.NetworkLayer) error
+}
+
+// frame serializes the layers with valid checksums behind an Ethernet
+// header
+func frame(t *testing.T, l ...gopacket.SerializableLayer) []byte {
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}}
+	eth.EthernetType = layers.EthernetTypeIPv4
+	if _, ok := l[0].(*layers.IPv6); ok {
+		eth.EthernetType = layers.EthernetTypeIPv6
+	}
+	for _, n := range l[1:] {
+		if c, ok := n.(pseudoHeaderLayer); ok {
+			c.SetNetworkLayerForChecksum(l[0].(gopacket.NetworkLayer))
+		}
+	}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, append([]gopacket.SerializableLayer{eth}, l...)...); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func ipv4(proto layers.IPProtocol) *layers.IPv4 {
+	return &layers.IPv4{Version: 4, TTL: 64, Id: 1, Protocol: proto, SrcIP: srcIPv4, DstIP: dstIPv4}
+}
+
+func ipv6(proto layers.IPProtocol) *layers.IPv6 {
+	return &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: proto, SrcIP: srcIPv6, DstIP: dstIPv6}
+}
+
+var payload = gopacket.Payload("checksum test payload")
+
+func TestVerify(t *testing.T) {
+	tcp := func() *layers.TCP { return &layers.TCP{SrcPort: 40000, DstPort: 80, Seq: 1, ACK: true, Window: 1000} }
+	udp := func() *layers.UDP { return &layers.UDP{SrcPort: 40000, DstPort: 53} }
+	echo := layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoRequest, 0)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		proto []Protocol
+		// offset of the checksum field in the frame
+		csum int
+	}{
+		{"ipv4 tcp", frame(t, ipv4(layers.IPProtocolTCP), tcp(), payload), []Protocol{ProtoIPv4, ProtoTCP}, 14 + 20 + 16},
+		{"ipv4 udp", frame(t, ipv4(layers.IPProtocolUDP), udp(), payload), []Protocol{ProtoIPv4, ProtoUDP}, 14 + 20 + 6},
+		{"ipv4 icmp", frame(t, ipv4(layers.IPProtocolICMPv4), &layers.ICMPv4{TypeCode: echo, Id: 1, Seq: 1}, payload), []Protocol{ProtoIPv4, ProtoICMPv4}, 14 + 20 + 2},
+		{"ipv6 tcp", frame(t, ipv6(layers.IPProtocolTCP), tcp(), payload), []Protocol{ProtoTCP}, 14 + 40 + 16},
+		{"ipv6 udp", frame(t, ipv6(layers.IPProtocolUDP), udp(), payload), []Protocol{ProtoUDP}, 14 + 40 + 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Verify(gopacket.NewPacket(tt.data, layers.LinkTypeEthernet, gopacket.Default))
+			if len(results) != len(tt.proto) {
+				t.Fatalf("expected %d results, got %d", len(tt.proto), len(results))
+			}
+			for i, r := range results {
+				if r.Protocol != tt.proto[i] || r.Status != StatusOk {
+					t.Errorf("expected %s ok, got %s %s", tt.proto[i], r.Protocol, r.Status)
+				}
+			}
+
+			bad := append([]byte{}, tt.data...)
+			bad[tt.csum] ^= 0x5a
+			last := len(tt.proto) - 1
+			results = Verify(gopacket.NewPacket(bad, layers.LinkTypeEthernet, gopacket.Default))
+			if results[last].Status != StatusBad {
+				t.Errorf("expected bad, got %s", results[last].Status)
+			}
+
+			fixed, n := FixFrame(bad, layers.LinkTypeEthernet)
+			if n != 1 || !bytes.Equal(fixed, tt.data) {
+				t.Errorf("expected one fix restoring the frame, got %d fixes", n)
+			}
+			if bad[tt.csum] == tt.data[tt.csum] {
+				t.Error("FixFrame changed the original frame")
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tcp := &layers.TCP{SrcPort: 40000, DstPort: 80, Seq: 1, ACK: true, Window: 1000}
+	data := frame(t, ipv4(layers.IPProtocolTCP), tcp, payload)
+	csum := 14 + 20 + 16
+	segment := data[14+20:]
+
+	partial := ^fold(pseudoHeader(srcIPv4, dstIPv4, layers.IPProtocolTCP, len(segment)))
+	offload := append([]byte{}, data...)
+	binary.BigEndian.PutUint16(offload[csum:], partial)
+	zero := append([]byte{}, data...)
+	binary.BigEndian.PutUint16(zero[csum:], 0)
+
+	udpZero := frame(t, ipv4(layers.IPProtocolUDP), &layers.UDP{SrcPort: 40000, DstPort: 53}, payload)
+	binary.BigEndian.PutUint16(udpZero[14+20+6:], 0)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		truncated bool
+		want      Status
+	}{
+		{"pseudo header sum", offload, false, StatusOffload},
+		{"zero", zero, false, StatusOffload},
+		{"udp disabled", udpZero, false, StatusDisabled},
+		{"truncated", zero[:len(zero)-4], true, StatusTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := gopacket.NewPacket(tt.data, layers.LinkTypeEthernet, gopacket.Default)
+			packet.Metadata().Truncated = tt.truncated
+			results := Verify(packet)
+			if got := results[len(results)-1].Status; got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package credentials
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// Credentials is the list of credentials found
+type Credentials []Credential
+
+var CredentialsCSVHeader = []string{
+    "protocol", "client", "server", "service", "username", "domain", "password", "hash",
+    "hashcat_mode", "info", "status", "count", "first_seen", "last_seen",
+}
+
+// WriteCSV writes the credentials as CSV
+func (l Credentials) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(CredentialsCSVHeader); err != nil {
+        return err
+    }
+    for _, c := range l {
+        mode := ""
+        if c.HashcatMode > 0 {
+            mode = strconv.Itoa(c.HashcatMode)
+        }
+        err := cw.Write([]string{
+            c.Protocol, c.Client, c.Server, c.Service, c.Username, c.Domain, c.Password, c.Hash,
+            mode, c.Info, c.Status, strconv.Itoa(c.Count),
+            c.FirstSeen.UTC().Format(time.RFC3339Nano), c.LastSeen.UTC().Format(time.RFC3339Nano),
+        })
+        if err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+// Redact replaces the passwords and the secret part of the hashes
+func (l Credentials) Redact() {
+    for i := range l {
+        l[i].Redact()
+    }
+}
+
+// WriteHashcat writes the hashes to dir, one file per hashcat mode named
+// <mode>_<protocol>.txt, returning the files written
+func (l Credentials) WriteHashcat(dir string) ([]string, error) {
+    modes := map[string][]string{}
+    seen := map[string]bool{}
+    for _, c := range l {
+        if c.Hash == "" || seen[c.Hash] {
+            continue
+        }
+        seen[c.Hash] = true
+        name := fmt.Sprintf("%d_%s.txt", c.HashcatMode, c.Protocol)
+        modes[name] = append(modes[name], c.Hash)
+    }
+    files := []string{}
+    if len(modes) == 0 {
+        return files, nil
+    }
+
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, err
+    }
+    for name := range modes {
+        files = append(files, filepath.Join(dir, name))
+    }
+    sort.Strings(files)
+    for _, file := range files {
+        f, err := os.Create(file)
+        if err != nil {
+            return nil, err
+        }
+        for _, h := range modes[filepath.Base(file)] {
+            if _, err = fmt.Fprintln(f, h); err != nil {
+                break
+            }
+        }
+        f.Close()
+        if err != nil {
+            return nil, err
+        }
+    }
+    return files, nil
+}
@@ -0,0 +1,474 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package credentials
+
+import (
+    "bytes"
+    "encoding/base64"
+    "net"
+    "regexp"
+    "strings"
+    "time"
+)
+
+// Clear text protocol ports
+const (
+    ftpPort             = 21
+    telnetPort          = 23
+    smtpPort            = 25
+    pop3Port            = 110
+    imapPort            = 143
+    submissionPort      = 587
+    snmpPort            = 161
+    snmpTrapPort        = 162
+)
+
+var httpBasicRe = regexp.MustCompile(`(?im)^(?:proxy-)?authorization:\s*basic\s+([A-Za-z0-9+/=]+)`)
+var httpAuthRe = regexp.MustCompile(`(?im)^(?:proxy-)?(?:authorization|www-authenticate|authenticate):\s*(?:ntlm|negotiate)\s+([A-Za-z0-9+/=]+)`)
+var httpRequestRe = regexp.MustCompile(`^([A-Z]+) (\S+) HTTP/1\.[01]`)
+var httpHostRe = regexp.MustCompile(`(?im)^host:\s*(\S+)`)
+var httpStatusRe = regexp.MustCompile(`^HTTP/1\.[01] (\d)\d\d`)
+
+// httpAuthBlobs returns the decoded NTLM and Negotiate tokens of the HTTP
+// authentication headers
+func httpAuthBlobs(payload []byte) [][]byte {
+    list := [][]byte{}
+    for _, m := range httpAuthRe.FindAllSubmatch(payload, -1) {
+        if data, err := base64.StdEncoding.DecodeString(string(m[1])); err == nil {
+            list = append(list, data)
+        }
+    }
+    return list
+}
+
+// lines splits the payload in lines without the line breaks
+func lines(payload []byte) []string {
+    list := []string{}
+    for _, l := range strings.Split(string(payload), "\n") {
+        if l = strings.TrimRight(l, "\r"); l != "" {
+            list = append(list, l)
+        }
+    }
+    return list
+}
+
+// command splits a protocol line in its upper case command and argument
+func command(line string) (string, string) {
+    parts := strings.SplitN(line, " ", 2)
+    arg := ""
+    if len(parts) > 1 {
+        arg = strings.TrimSpace(parts[1])
+    }
+    return strings.ToUpper(parts[0]), arg
+}
+
+func decodeB64(s string) string {
+    data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+    if err != nil {
+        return ""
+    }
+    return string(data)
+}
+
+// addClientData extracts the credentials sent by the client
+func (c *Collector) addClientData(f *flow, serverPort int, payload []byte, ts time.Time) {
+    if m := httpBasicRe.FindSubmatch(payload); m != nil {
+        if user, pass, ok := strings.Cut(decodeB64(string(m[1])), ":"); ok {
+            info := ""
+            if r := httpRequestRe.FindSubmatch(payload); r != nil {
+                info = string(r[1]) + " "
+                if h := httpHostRe.FindSubmatch(payload); h != nil && strings.HasPrefix(string(r[2]), "/") {
+                    info += "http://" + string(h[1])
+                }
+                info += string(r[2])
+            }
+            f.pending = c.newCredential(f, ProtoHTTPBasic, user, pass, info, ts)
+        }
+        return
+    }
+
+    switch serverPort {
+    case ftpPort, pop3Port:
+        proto := ProtoFTP
+        if serverPort == pop3Port {
+            proto = ProtoPOP3
+        }
+        for _, l := range lines(payload) {
+            cmd, arg := command(l)
+            switch cmd {
+            case "USER":
+                f.user = arg
+            case "PASS":
+                f.pending = c.newCredential(f, proto, f.user, arg, "", ts)
+            }
+        }
+
+    case imapPort:
+        for _, l := range lines(payload) {
+            if f.expect != "" {
+                c.addSASLPlain(f, ProtoIMAP, l, ts)
+                continue
+            }
+            parts := strings.SplitN(l, " ", 2)
+            if len(parts) < 2 {
+                continue
+            }
+            tag := parts[0]
+            cmd, arg := command(parts[1])
+            switch cmd {
+            case "LOGIN":
+                args := imapArgs(arg)
+                if len(args) >= 2 {
+                    f.imapTag = tag
+                    f.pending = c.newCredential(f, ProtoIMAP, args[0], args[1], "LOGIN", ts)
+                }
+            case "AUTHENTICATE":
+                mech, initial := command(arg)
+                if mech == "PLAIN" {
+                    f.imapTag = tag
+                    f.expect = "plain"
+                    if initial != "" {
+                        c.addSASLPlain(f, ProtoIMAP, initial, ts)
+                    }
+                }
+            }
+        }
+
+    case smtpPort, submissionPort:
+        for _, l := range lines(payload) {
+            switch f.expect {
+            case "plain":
+                c.addSASLPlain(f, ProtoSMTP, l, ts)
+                continue
+            case "login-user":
+                f.user = decodeB64(l)
+                f.expect = "login-pass"
+                continue
+            case "login-pass":
+                f.expect = ""
+                f.pending = c.newCredential(f, ProtoSMTP, f.user, decodeB64(l), "AUTH LOGIN", ts)
+                continue
+            }
+            cmd, arg := command(l)
+            if cmd != "AUTH" {
+                continue
+            }
+            mech, initial := command(arg)
+            switch mech {
+            case "PLAIN":
+                f.expect = "plain"
+                if initial != "" {
+                    c.addSASLPlain(f, ProtoSMTP, initial, ts)
+                }
+            case "LOGIN":
+                f.expect = "login-user"
+                if initial != "" {
+                    f.user = decodeB64(initial)
+                    f.expect = "login-pass"
+                }
+            }
+        }
+
+    case telnetPort:
+        c.addTelnetClient(f, payload, ts)
+    }
+}
+
+// addSASLPlain adds the SASL PLAIN (authzid, authcid and password)
+// credential of the client line
+func (c *Collector) addSASLPlain(f *flow, proto string, line string, ts time.Time) {
+    f.expect = ""
+    parts := strings.Split(decodeB64(line), "\x00")
+    if len(parts) != 3 {
+        return
+    }
+    f.pending = c.newCredential(f, proto, parts[1], parts[2], "AUTH PLAIN", ts)
+}
+
+// imapArgs splits the IMAP atoms and quoted strings
+func imapArgs(s string) []string {
+    args := []string{}
+    for len(s) > 0 {
+        s = strings.TrimLeft(s, " ")
+        if s == "" {
+            break
+        }
+        if s[0] == '"' {
+            var b strings.Builder
+            i := 1
+            for ; i < len(s) && s[i] != '"'; i++ {
+                if s[i] == '\\' && i + 1 < len(s) {
+                    i++
+                }
+                b.WriteByte(s[i])
+            }
+            args = append(args, b.String())
+            s = s[min(i + 1, len(s)):]
+            continue
+        }
+        idx := strings.Index(s, " ")
+        if idx < 0 {
+            idx = len(s)
+        }
+        args = append(args, s[:idx])
+        s = s[idx:]
+    }
+    return args
+}
+
+func (c *Collector) newCredential(f *flow, proto, user, pass, info string, ts time.Time) *Credential {
+    return c.add(&Credential{
+        Protocol    : proto,
+        Client      : f.client,
+        Server      : f.server,
+        Service     : f.service,
+        Username    : user,
+        Password    : pass,
+        Info        : info,
+    }, ts)
+}
+
+// addServerData sets the status of the credential waiting for the
+// server answer
+func (c *Collector) addServerData(f *flow, serverPort int, payload []byte) {
+    if serverPort == telnetPort {
+        c.addTelnetServer(f, payload)
+        return
+    }
+    if f.pending == nil {
+        return
+    }
+
+    if m := httpStatusRe.FindSubmatch(payload); m != nil && f.pending.Protocol == ProtoHTTPBasic {
+        f.pending.Status = StatusSuccess
+        if m[1][0] == '4' || m[1][0] == '5' {
+            f.pending.Status = StatusFailed
+        }
+        f.pending = nil
+        return
+    }
+
+    for _, l := range lines(payload) {
+        status := ""
+        switch f.pending.Protocol {
+        case ProtoFTP:
+            if strings.HasPrefix(l, "230") {
+                status = StatusSuccess
+            } else if strings.HasPrefix(l, "530") {
+                status = StatusFailed
+            }
+        case ProtoPOP3:
+            if strings.HasPrefix(l, "+OK") {
+                status = StatusSuccess
+            } else if strings.HasPrefix(l, "-ERR") {
+                status = StatusFailed
+            }
+        case ProtoIMAP:
+            tag, rest := command(l)
+            if !strings.EqualFold(tag, f.imapTag) {
+                continue
+            }
+            if resp, _ := command(rest); resp == "OK" {
+                status = StatusSuccess
+            } else {
+                status = StatusFailed
+            }
+        case ProtoSMTP:
+            if strings.HasPrefix(l, "235") {
+                status = StatusSuccess
+            } else if strings.HasPrefix(l, "5") {
+                status = StatusFailed
+            }
+        }
+        if status != "" {
+            f.pending.Status = status
+            f.pending = nil
+            return
+        }
+    }
+}
+
+// Telnet login prompts
+var telnetUserPrompts = []string{ "login:", "username:", "user name:", "user:" }
+var telnetFailures = []string{ "incorrect", "failed", "denied", "invalid" }
+
+// telnetState is the login dialog of a telnet connection
+type telnetState struct {
+    // 0 none, 1 typing the user name and 2 typing the password
+    field           int
+    buf             []byte
+}
+
+// stripIAC removes the telnet commands (IAC sequences) from data
+func stripIAC(data []byte) []byte {
+    out := []byte{}
+    for i := 0; i < len(data); i++ {
+        if data[i] != 0xff {
+            out = append(out, data[i])
+            continue
+        }
+        if i + 1 >= len(data) {
+            break
+        }
+        switch cmd := data[i + 1]; {
+        case cmd == 0xff:
+            out = append(out, 0xff)
+            i++
+        case cmd == 0xfa:
+            // sub negotiation up to IAC SE
+            end := bytes.Index(data[i:], []byte{ 0xff, 0xf0 })
+            if end < 0 {
+                return out
+            }
+            i += end + 1
+        case cmd >= 0xfb:
+            i += 2
+        default:
+            i++
+        }
+    }
+    return out
+}
+
+func (c *Collector) addTelnetServer(f *flow, payload []byte) {
+    text := strings.ToLower(strings.TrimRight(string(stripIAC(payload)), " \r\n\t\x00"))
+    if text == "" {
+        return
+    }
+
+    if f.pending != nil {
+        for _, s := range telnetFailures {
+            if strings.Contains(text, s) {
+                f.pending.Status = StatusFailed
+                f.pending = nil
+                break
+            }
+        }
+    }
+
+    switch {
+    case strings.HasSuffix(text, "password:"):
+        f.telnet = telnetState{ field: 2 }
+        return
+    case hasAnySuffix(text, telnetUserPrompts):
+        if f.pending != nil {
+            f.pending.Status = StatusFailed
+            f.pending = nil
+        }
+        f.telnet = telnetState{ field: 1 }
+        return
+    }
+
+    if f.pending != nil && (strings.Contains(text, "last login") || hasAnySuffix(text, []string{ "$", "#", ">", "%" })) {
+        f.pending.Status = StatusSuccess
+        f.pending = nil
+    }
+}
+
+func (c *Collector) addTelnetClient(f *flow, payload []byte, ts time.Time) {
+    if f.telnet.field == 0 {
+        return
+    }
+    for _, b := range stripIAC(payload) {
+        switch {
+        case b == '\r' || b == '\n':
+            value := string(f.telnet.buf)
+            if f.telnet.field == 1 {
+                f.user = value
+            } else {
+                f.pending = c.newCredential(f, ProtoTelnet, f.user, value, "", ts)
+            }
+            f.telnet = telnetState{}
+            return
+        case b == 0x08 || b == 0x7f:
+            if len(f.telnet.buf) > 0 {
+                f.telnet.buf = f.telnet.buf[:len(f.telnet.buf) - 1]
+            }
+        case b >= 0x20 && b < 0x7f:
+            f.telnet.buf = append(f.telnet.buf, b)
+        }
+    }
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+    for _, suffix := range suffixes {
+        if strings.HasSuffix(s, suffix) {
+            return true
+        }
+    }
+    return false
+}
+
+// snmpCommunity returns the version and community of a SNMP v1 or v2c
+// message: SEQUENCE { version INTEGER, community OCTET STRING, pdu }
+func snmpCommunity(data []byte) (string, string, bool) {
+    tlv := func(data []byte, tag byte) ([]byte, []byte, bool) {
+        if len(data) < 2 || data[0] != tag {
+            return nil, nil, false
+        }
+        size, p := int(data[1]), 2
+        if size & 0x80 != 0 {
+            n := size & 0x7f
+            if n == 0 || n > 4 || len(data) < 2 + n {
+                return nil, nil, false
+            }
+            size = 0
+            for _, b := range data[2:2 + n] {
+                size = size << 8 | int(b)
+            }
+            p += n
+        }
+        if len(data) < p + size {
+            return nil, nil, false
+        }
+        return data[p:p + size], data[p + size:], true
+    }
+
+    msg, _, ok := tlv(data, 0x30)
+    if !ok {
+        return "", "", false
+    }
+    version, rest, ok := tlv(msg, 0x02)
+    if !ok || len(version) != 1 || version[0] > 1 {
+        return "", "", false
+    }
+    community, _, ok := tlv(rest, 0x04)
+    if !ok {
+        return "", "", false
+    }
+    return []string{ "v1", "v2c" }[version[0]], string(community), true
+}
+
+func (c *Collector) addSNMP(payload []byte, src, dst net.IP, sport, dport int, ts time.Time) {
+    version, community, ok := snmpCommunity(payload)
+    if !ok {
+        return
+    }
+    if sport == snmpPort {
+        // agent response, the community is valid
+        req := Credential{ Protocol: ProtoSNMP, Client: dst.String(), Server: hostPort(src, sport), Password: community }
+        if cred, ok := c.creds[req.key()]; ok {
+            cred.Status = StatusSuccess
+        }
+        return
+    }
+    c.add(&Credential{
+        Protocol    : ProtoSNMP,
+        Client      : src.String(),
+        Server      : hostPort(dst, dport),
+        Service     : serviceName("udp", dport),
+        Password    : community,
+        Info        : version,
+    }, ts)
+}
@@ -0,0 +1,318 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package decap
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "net"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/dot11"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// Encap is an encapsulation type that can be stripped
+type Encap string
+
+const (
+    EncapVLAN   Encap = "vlan"
+    EncapMPLS   Encap = "mpls"
+    EncapGRE    Encap = "gre"
+    EncapERSPAN Encap = "erspan"
+    EncapVXLAN  Encap = "vxlan"
+    EncapGENEVE Encap = "geneve"
+    EncapDot11  Encap = "dot11"
+)
+
+// AllEncaps lists every supported encapsulation
+var AllEncaps = []Encap{ EncapVLAN, EncapMPLS, EncapGRE, EncapERSPAN, EncapVXLAN, EncapGENEVE, EncapDot11 }
+
+// GRE protocol type of ERSPAN type III (not decoded by gopacket)
+const ethernetTypeERSPANIII = layers.EthernetType(0x22eb)
+
+// max number of nested encapsulations to strip
+const maxDepth = 8
+
+// Set is a set of enabled encapsulations
+type Set map[Encap]bool
+
+// default set used by NewPacket, empty means disabled
+var defaultSet = Set{}
+
+// ParseSet parses a comma-separated list of encapsulations ("all" enables
+// every supported one)
+func ParseSet(list string) (Set, error) {
+    s := Set{}
+    for _, e := range strings.Split(strings.ToLower(list), ",") {
+        e = strings.TrimSpace(e)
+        if e == "" {
+            continue
+        }
+        if e == "all" {
+            for _, a := range AllEncaps {
+                s[a] = true
+            }
+            continue
+        }
+        if e == "qinq" || e == "dot1q" {
+            e = string(EncapVLAN)
+        }
+        if e == "wifi" || e == "802.11" || e == "radiotap" {
+            e = string(EncapDot11)
+        }
+        found := false
+        for _, a := range AllEncaps {
+            if Encap(e) == a {
+                s[a] = true
+                found = true
+            }
+        }
+        if !found {
+            return nil, errors.New(fmt.Sprintf("unsupported encapsulation (%s)", e))
+        }
+    }
+    return s, nil
+}
+
+// String returns the set as a comma-separated list
+func (s Set) String() string {
+    l := []string{}
+    for _, a := range AllEncaps {
+        if s[a] {
+            l = append(l, string(a))
+        }
+    }
+    return strings.Join(l, ",")
+}
+
+// SetDefault sets the encapsulations stripped by NewPacket
+func SetDefault(s Set) {
+    defaultSet = s
+}
+
+// Enabled returns true if NewPacket strips any encapsulation
+func Enabled() bool {
+    return len(defaultSet) > 0
+}
+
+// NewPacket decodes a packet, looking inside the tunnels enabled with
+// SetDefault. Without enabled encapsulations it behaves like
+// gopacket.NewPacket.
+func NewPacket(data []byte, linkType layers.LinkType) gopacket.Packet {
+    if len(defaultSet) > 0 {
+        if inner, lt, n := Strip(data, linkType, defaultSet); n > 0 {
+            return gopacket.NewPacket(inner, lt, gopacket.NoCopy)
+        }
+    }
+    return gopacket.NewPacket(data, linkType, gopacket.NoCopy)
+}
+
+// Strip removes the enabled encapsulations from a frame, returning the
+// inner frame, its link type and the number of layers stripped
+func Strip(data []byte, linkType layers.LinkType, enabled Set) ([]byte, layers.LinkType, int) {
+    count := 0
+    for count < maxDepth {
+        inner, lt, ok := stripOne(data, linkType, enabled)
+        if !ok {
+            break
+        }
+        data = inner
+        linkType = lt
+        count++
+    }
+    return data, linkType, count
+}
+
+// stripOne removes the outermost enabled encapsulation
+func stripOne(data []byte, linkType layers.LinkType, enabled Set) ([]byte, layers.LinkType, bool) {
+    if dot11.IsWireless(linkType) {
+        // unprotected (or decrypted) 802.11 data frames
+        if !enabled[EncapDot11] {
+            return nil, 0, false
+        }
+        f, err := dot11.Parse(data, linkType)
+        if err != nil {
+            return nil, 0, false
+        }
+        frame, ok := f.Ethernet()
+        return frame, layers.LinkTypeEthernet, ok
+    }
+
+    packet := gopacket.NewPacket(data, linkType, gopacket.NoCopy)
+
+    var eth *layers.Ethernet
+    pl := packet.Layers()
+    for i, l := range pl {
+        switch t := l.(type) {
+        case *layers.Ethernet:
+            if eth == nil {
+                eth = t
+            }
+
+        case *layers.Dot1Q:
+            // only tags right after the outer Ethernet header
+            if !enabled[EncapVLAN] || eth == nil || i != 1 {
+                continue
+            }
+            // strip all consecutive tags (QinQ)
+            last := t
+            for j := i + 1; j < len(pl); j++ {
+                d, ok := pl[j].(*layers.Dot1Q)
+                if !ok {
+                    break
+                }
+                last = d
+            }
+            return buildEthernet(eth.DstMAC, eth.SrcMAC, last.Type, last.LayerPayload()), layers.LinkTypeEthernet, true
+
+        case *layers.MPLS:
+            if !enabled[EncapMPLS] {
+                continue
+            }
+            // look for the bottom of the stack
+            last := t
+            for j := i + 1; j < len(pl); j++ {
+                d, ok := pl[j].(*layers.MPLS)
+                if !ok {
+                    break
+                }
+                last = d
+            }
+            payload := last.LayerPayload()
+            if len(payload) == 0 {
+                return nil, 0, false
+            }
+            return rawIP(eth, payload)
+
+        case *layers.GRE:
+            if enabled[EncapERSPAN] && t.Protocol == ethernetTypeERSPANIII {
+                if inner, ok := erspanIII(t.LayerPayload()); ok {
+                    return inner, layers.LinkTypeEthernet, true
+                }
+                continue
+            }
+            if !enabled[EncapGRE] || t.Protocol == layers.EthernetTypeERSPAN {
+                continue
+            }
+            return byEthernetType(eth, t.Protocol, t.LayerPayload())
+
+        case *layers.ERSPANII:
+            if !enabled[EncapERSPAN] {
+                continue
+            }
+            return t.LayerPayload(), layers.LinkTypeEthernet, true
+
+        case *layers.VXLAN:
+            if !enabled[EncapVXLAN] {
+                continue
+            }
+            return t.LayerPayload(), layers.LinkTypeEthernet, true
+
+        case *layers.Geneve:
+            if !enabled[EncapGENEVE] {
+                continue
+            }
+            return byEthernetType(eth, t.Protocol, t.LayerPayload())
+        }
+    }
+
+    return nil, 0, false
+}
+
+// byEthernetType returns the tunnel payload as an Ethernet frame
+func byEthernetType(eth *layers.Ethernet, et layers.EthernetType, payload []byte) ([]byte, layers.LinkType, bool) {
+    if len(payload) == 0 {
+        return nil, 0, false
+    }
+    switch et {
+    case layers.EthernetTypeTransparentEthernetBridging:
+        return payload, layers.LinkTypeEthernet, true
+    case layers.EthernetTypeIPv4, layers.EthernetTypeIPv6:
+        return buildEthernet(outerDst(eth), outerSrc(eth), et, payload), layers.LinkTypeEthernet, true
+    }
+    return nil, 0, false
+}
+
+// rawIP wraps an IP packet (guessed by version) into an Ethernet frame
+func rawIP(eth *layers.Ethernet, payload []byte) ([]byte, layers.LinkType, bool) {
+    switch payload[0] >> 4 {
+    case 4:
+        return buildEthernet(outerDst(eth), outerSrc(eth), layers.EthernetTypeIPv4, payload), layers.LinkTypeEthernet, true
+    case 6:
+        return buildEthernet(outerDst(eth), outerSrc(eth), layers.EthernetTypeIPv6, payload), layers.LinkTypeEthernet, true
+    }
+    return nil, 0, false
+}
+
+// erspanIII parses the ERSPAN type III header and returns the mirrored frame
+func erspanIII(data []byte) ([]byte, bool) {
+    if len(data) < 12 {
+        return nil, false
+    }
+    size := 12
+    // O flag: optional platform specific sub header
+    if data[11] & 0x01 != 0 {
+        size += 8
+    }
+    // FT (frame type): 0 = Ethernet
+    if (data[10] >> 2) & 0x1f != 0 {
+        return nil, false
+    }
+    if len(data) <= size {
+        return nil, false
+    }
+    return data[size:], true
+}
+
+func outerDst(eth *layers.Ethernet) net.HardwareAddr {
+    if eth == nil {
+        return make(net.HardwareAddr, 6)
+    }
+    return eth.DstMAC
+}
+
+func outerSrc(eth *layers.Ethernet) net.HardwareAddr {
+    if eth == nil {
+        return make(net.HardwareAddr, 6)
+    }
+    return eth.SrcMAC
+}
+
+func buildEthernet(dst, src net.HardwareAddr, et layers.EthernetType, payload []byte) []byte {
+    frame := make([]byte, 14 + len(payload))
+    copy(frame[0:6], dst)
+    copy(frame[6:12], src)
+    binary.BigEndian.PutUint16(frame[12:14], uint16(et))
+    copy(frame[14:], payload)
+    return frame
+}
+
+// ToRawIP removes the Ethernet header of a frame to be written with
+// LINKTYPE_RAW, returning false when the frame has no IP payload
+func ToRawIP(data []byte, linkType layers.LinkType) ([]byte, bool) {
+    if linkType == layers.LinkTypeRaw {
+        return data, true
+    }
+    if linkType != layers.LinkTypeEthernet || len(data) < 14 {
+        return nil, false
+    }
+    switch layers.EthernetType(binary.BigEndian.Uint16(data[12:14])) {
+    case layers.EthernetTypeIPv4, layers.EthernetTypeIPv6:
+        return data[14:], true
+    }
+    return nil, false
+}
@@ -0,0 +1,501 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dnsmap
+
+import (
+    "encoding/binary"
+    "encoding/csv"
+    "fmt"
+    "io"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+const dnsPort = 53
+
+// Query is a question sent by a client to a DNS server
+type Query struct {
+    Name            string              `json:"name"`
+    Type            string              `json:"type"`
+    Client          string              `json:"client"`
+    Server          string              `json:"server"`
+    Count           int                 `json:"count"`
+    // response code of the last response, empty if never answered
+    RCode           string              `json:"rcode"`
+    FirstSeen       time.Time           `json:"first_seen"`
+    LastSeen        time.Time           `json:"last_seen"`
+}
+
+// Record is a resource record found at the DNS responses, SRV values are
+// "priority weight port target"
+type Record struct {
+    Name            string              `json:"name"`
+    Type            string              `json:"type"`
+    Value           string              `json:"value"`
+    TTL             uint32              `json:"ttl"`
+    Server          string              `json:"server"`
+    Count           int                 `json:"count"`
+    FirstSeen       time.Time           `json:"first_seen"`
+    LastSeen        time.Time           `json:"last_seen"`
+}
+
+// Server is a DNS server used by the clients of the capture
+type Server struct {
+    IP              string              `json:"ip"`
+    Queries         int                 `json:"queries"`
+    Responses       int                 `json:"responses"`
+    NXDomain        int                 `json:"nxdomain"`
+    Clients         []string            `json:"clients"`
+}
+
+// ResolverEntry is the names an address resolves to
+type ResolverEntry struct {
+    IP              string              `json:"ip"`
+    Names           []string            `json:"names"`
+}
+
+// Result is the DNS activity found at the capture
+type Result struct {
+    Servers         []Server            `json:"servers"`
+    Queries         []Query             `json:"queries"`
+    Records         []Record            `json:"records"`
+    // Active Directory SRV records (_ldap, _kerberos, _gc, _kpasswd and
+    // _msdcs names)
+    ADRecords       []Record            `json:"ad_records"`
+    // internal domain names: AD domains and the domains of names with
+    // private addresses
+    Domains         []string            `json:"domains"`
+    Resolver        []ResolverEntry     `json:"resolver"`
+}
+
+type serverEntry struct {
+    queries         int
+    responses       int
+    nxdomain        int
+    clients         map[string]bool
+}
+
+// Collector extracts the DNS activity packet by packet
+type Collector struct {
+    queries         map[string]*Query
+    records         map[string]*Record
+    servers         map[string]*serverEntry
+}
+
+func NewCollector() *Collector {
+    return &Collector{
+        queries     : map[string]*Query{},
+        records     : map[string]*Record{},
+        servers     : map[string]*serverEntry{},
+    }
+}
+
+func (c *Collector) server(ip string) *serverEntry {
+    s, ok := c.servers[ip]
+    if !ok {
+        s = &serverEntry{ clients: map[string]bool{} }
+        c.servers[ip] = s
+    }
+    return s
+}
+
+// Add collects the DNS messages of the packet (UDP and TCP port 53)
+func (c *Collector) Add(packet gopacket.Packet, ts time.Time) {
+    var src, dst net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        src, dst = ipv4.SrcIP, ipv4.DstIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        src, dst = ipv6.SrcIP, ipv6.DstIP
+    }
+    if src == nil {
+        return
+    }
+
+    if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
+        udp := udpLayer.(*layers.UDP)
+        if udp.SrcPort != dnsPort && udp.DstPort != dnsPort {
+            return
+        }
+        dns := &layers.DNS{}
+        if err := dns.DecodeFromBytes(udp.Payload, gopacket.NilDecodeFeedback); err == nil {
+            c.addMessage(dns, src.String(), dst.String(), ts)
+        }
+        return
+    }
+
+    //DNS over TCP, messages are prefixed by their length
+    if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+        tcp := tcpLayer.(*layers.TCP)
+        if tcp.SrcPort != dnsPort && tcp.DstPort != dnsPort {
+            return
+        }
+        data := tcp.Payload
+        for len(data) > 2 {
+            size := int(binary.BigEndian.Uint16(data[0:2]))
+            if size == 0 || len(data) < 2 + size {
+                return
+            }
+            dns := &layers.DNS{}
+            if err := dns.DecodeFromBytes(data[2:2 + size], gopacket.NilDecodeFeedback); err == nil {
+                c.addMessage(dns, src.String(), dst.String(), ts)
+            }
+            data = data[2 + size:]
+        }
+    }
+}
+
+func (c *Collector) addMessage(dns *layers.DNS, src, dst string, ts time.Time) {
+    client, server := src, dst
+    if dns.QR {
+        client, server = dst, src
+    }
+
+    s := c.server(server)
+    s.clients[client] = true
+    if !dns.QR {
+        s.queries++
+    } else {
+        s.responses++
+        if dns.ResponseCode == layers.DNSResponseCodeNXDomain {
+            s.nxdomain++
+        }
+    }
+
+    for _, q := range dns.Questions {
+        name := normalize(string(q.Name))
+        key := fmt.Sprintf("%s|%s|%s|%s", name, q.Type, client, server)
+        e, ok := c.queries[key]
+        if !ok {
+            e = &Query{ Name: name, Type: q.Type.String(), Client: client, Server: server, FirstSeen: ts }
+            c.queries[key] = e
+        }
+        if !dns.QR {
+            e.Count++
+        } else {
+            e.RCode = dns.ResponseCode.String()
+        }
+        if ts.Before(e.FirstSeen) {
+            e.FirstSeen = ts
+        }
+        if ts.After(e.LastSeen) {
+            e.LastSeen = ts
+        }
+    }
+
+    if !dns.QR {
+        return
+    }
+    records := append([]layers.DNSResourceRecord{}, dns.Answers...)
+    records = append(records, dns.Additionals...)
+    for _, rr := range records {
+        value := recordValue(rr)
+        if value == "" {
+            continue
+        }
+        name := normalize(string(rr.Name))
+        key := fmt.Sprintf("%s|%s|%s", name, rr.Type, value)
+        e, ok := c.records[key]
+        if !ok {
+            e = &Record{ Name: name, Type: rr.Type.String(), Value: value, Server: server, FirstSeen: ts }
+            c.records[key] = e
+        }
+        e.Count++
+        e.TTL = rr.TTL
+        if ts.Before(e.FirstSeen) {
+            e.FirstSeen = ts
+        }
+        if ts.After(e.LastSeen) {
+            e.LastSeen = ts
+        }
+    }
+}
+
+// recordValue returns the value of the A, AAAA, CNAME, PTR, SRV, NS and
+// MX records, other types are ignored
+func recordValue(rr layers.DNSResourceRecord) string {
+    switch rr.Type {
+    case layers.DNSTypeA, layers.DNSTypeAAAA:
+        if rr.IP != nil {
+            return rr.IP.String()
+        }
+    case layers.DNSTypeCNAME:
+        return normalize(string(rr.CNAME))
+    case layers.DNSTypePTR:
+        return normalize(string(rr.PTR))
+    case layers.DNSTypeNS:
+        return normalize(string(rr.NS))
+    case layers.DNSTypeMX:
+        return fmt.Sprintf("%d %s", rr.MX.Preference, normalize(string(rr.MX.Name)))
+    case layers.DNSTypeSRV:
+        return fmt.Sprintf("%d %d %d %s", rr.SRV.Priority, rr.SRV.Weight, rr.SRV.Port, normalize(string(rr.SRV.Name)))
+    }
+    return ""
+}
+
+func normalize(name string) string {
+    return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// adServices are the SRV services registered by Active Directory
+var adServices = []string{ "_ldap.", "_kerberos.", "_kpasswd.", "_gc." }
+
+// IsADName returns true for the SRV names registered by Active Directory
+// domain controllers (e.g. _ldap._tcp.dc._msdcs.corp.local)
+func IsADName(name string) bool {
+    name = normalize(name)
+    if strings.Contains(name, "._msdcs.") || strings.HasPrefix(name, "_msdcs.") {
+        return true
+    }
+    for _, s := range adServices {
+        if strings.HasPrefix(name, s) {
+            return true
+        }
+    }
+    return false
+}
+
+// ADDomain returns the domain of an AD SRV name, or an empty string
+func ADDomain(name string) string {
+    name = normalize(name)
+    if idx := strings.Index(name, "_msdcs."); idx >= 0 {
+        return name[idx + len("_msdcs."):]
+    }
+    labels := strings.Split(name, ".")
+    i := 0
+    for i < len(labels) && strings.HasPrefix(labels[i], "_") {
+        i++
+    }
+    // _ldap._tcp.<site>._sites.<domain>
+    for j := i; j < len(labels); j++ {
+        if labels[j] == "_sites" {
+            i = j + 1
+        }
+    }
+    if i == 0 || i >= len(labels) {
+        return ""
+    }
+    return strings.Join(labels[i:], ".")
+}
+
+// parentDomain returns the name without its first label, or an empty
+// string for single label names
+func parentDomain(name string) string {
+    if idx := strings.Index(name, "."); idx > 0 && idx < len(name) - 1 {
+        return name[idx + 1:]
+    }
+    return ""
+}
+
+// Result returns the DNS activity collected
+func (c *Collector) Result() *Result {
+    res := &Result{
+        Servers     : []Server{},
+        Queries     : []Query{},
+        Records     : []Record{},
+        ADRecords   : []Record{},
+        Domains     : []string{},
+        Resolver    : []ResolverEntry{},
+    }
+
+    for ip, s := range c.servers {
+        if s.responses == 0 && s.queries < 2 {
+            continue
+        }
+        clients := []string{}
+        for cl := range s.clients {
+            clients = append(clients, cl)
+        }
+        sort.Strings(clients)
+        res.Servers = append(res.Servers, Server{
+            IP          : ip,
+            Queries     : s.queries,
+            Responses   : s.responses,
+            NXDomain    : s.nxdomain,
+            Clients     : clients,
+        })
+    }
+    sort.Slice(res.Servers, func(i, j int) bool {
+        if res.Servers[i].Queries != res.Servers[j].Queries {
+            return res.Servers[i].Queries > res.Servers[j].Queries
+        }
+        return res.Servers[i].IP < res.Servers[j].IP
+    })
+
+    for _, q := range c.queries {
+        res.Queries = append(res.Queries, *q)
+    }
+    sort.Slice(res.Queries, func(i, j int) bool {
+        a, b := res.Queries[i], res.Queries[j]
+        if !a.FirstSeen.Equal(b.FirstSeen) {
+            return a.FirstSeen.Before(b.FirstSeen)
+        }
+        return a.Name + a.Type + a.Client < b.Name + b.Type + b.Client
+    })
+
+    domains := map[string]bool{}
+    for _, r := range c.records {
+        res.Records = append(res.Records, *r)
+        if r.Type == layers.DNSTypeSRV.String() && IsADName(r.Name) {
+            res.ADRecords = append(res.ADRecords, *r)
+            if d := ADDomain(r.Name); d != "" {
+                domains[d] = true
+            }
+        }
+    }
+    for _, q := range c.queries {
+        if IsADName(q.Name) {
+            if d := ADDomain(q.Name); d != "" {
+                domains[d] = true
+            }
+        }
+    }
+    sortRecords(res.Records)
+    sortRecords(res.ADRecords)
+
+    resolver := c.Resolver()
+    for _, ip := range resolver.IPs() {
+        res.Resolver = append(res.Resolver, ResolverEntry{ IP: ip, Names: resolver.Names(ip) })
+        if !netcalc.IsPrivateIP(net.ParseIP(ip)) {
+            continue
+        }
+        for _, name := range resolver.Names(ip) {
+            if d := parentDomain(name); d != "" {
+                domains[d] = true
+            }
+        }
+    }
+    for d := range domains {
+        res.Domains = append(res.Domains, d)
+    }
+    sort.Strings(res.Domains)
+
+    return res
+}
+
+func sortRecords(list []Record) {
+    sort.Slice(list, func(i, j int) bool {
+        if list[i].Name != list[j].Name {
+            return list[i].Name < list[j].Name
+        }
+        if list[i].Type != list[j].Type {
+            return list[i].Type < list[j].Type
+        }
+        return list[i].Value < list[j].Value
+    })
+}
+
+// Resolver returns the address to names map built from the A, AAAA
+// (including the CNAME aliases of the name) and PTR records
+func (c *Collector) Resolver() Resolver {
+    r := Resolver{}
+    aliases := map[string][]string{}
+    for _, rec := range c.records {
+        if rec.Type == layers.DNSTypeCNAME.String() {
+            aliases[rec.Value] = append(aliases[rec.Value], rec.Name)
+        }
+    }
+
+    for _, rec := range c.records {
+        switch rec.Type {
+        case layers.DNSTypeA.String(), layers.DNSTypeAAAA.String():
+            r.Add(rec.Value, rec.Name)
+            seen := map[string]bool{ rec.Name: true }
+            queue := append([]string{}, aliases[rec.Name]...)
+            for len(queue) > 0 {
+                alias := queue[0]
+                queue = queue[1:]
+                if seen[alias] {
+                    continue
+                }
+                seen[alias] = true
+                r.Add(rec.Value, alias)
+                queue = append(queue, aliases[alias]...)
+            }
+        case layers.DNSTypePTR.String():
+            if ip := netcalc.ReverseNameToIP(rec.Name); ip != nil {
+                r.Add(ip.String(), rec.Value)
+            }
+        }
+    }
+    return r
+}
+
+// JSONLines writes one record per server, query, resource record and
+// domain, the "kind" field (server, query, record or domain) tells them
+// apart
+func (r *Result) JSONLines() []interface{} {
+    type kind struct {
+        Kind    string  `json:"kind"`
+    }
+    list := []interface{}{}
+    for _, s := range r.Servers {
+        list = append(list, struct {
+            kind
+            Server
+        }{ kind{ "server" }, s })
+    }
+    for _, q := range r.Queries {
+        list = append(list, struct {
+            kind
+            Query
+        }{ kind{ "query" }, q })
+    }
+    for _, rec := range r.Records {
+        list = append(list, struct {
+            kind
+            Record
+        }{ kind{ "record" }, rec })
+    }
+    for _, d := range r.Domains {
+        list = append(list, struct {
+            kind
+            Domain  string  `json:"domain"`
+        }{ kind{ "domain" }, d })
+    }
+    return list
+}
+
+var recordsCSVHeader = []string{
+    "name", "type", "value", "ttl", "server", "count", "ad", "first_seen", "last_seen",
+}
+
+// WriteCSV writes the resource records as CSV
+func (r *Result) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(recordsCSVHeader); err != nil {
+        return err
+    }
+    for _, rec := range r.Records {
+        ad := rec.Type == layers.DNSTypeSRV.String() && IsADName(rec.Name)
+        row := []string{
+            rec.Name, rec.Type, rec.Value, strconv.FormatUint(uint64(rec.TTL), 10), rec.Server,
+            strconv.Itoa(rec.Count), strconv.FormatBool(ad),
+            rec.FirstSeen.UTC().Format(time.RFC3339Nano), rec.LastSeen.UTC().Format(time.RFC3339Nano),
+        }
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
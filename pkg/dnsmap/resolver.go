@@ -0,0 +1,125 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dnsmap
+
+import (
+    "bufio"
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "os"
+    "sort"
+    "strings"
+)
+
+// Resolver maps addresses to host names. It is saved and loaded in the
+// hosts file format, one address per line followed by its names:
+//
+//   10.0.0.10   dc01.corp.local
+//   10.0.0.20   fs01.corp.local files.corp.local
+type Resolver map[string][]string
+
+// Add adds a name to the address
+func (r Resolver) Add(ip string, name string) {
+    parsed := net.ParseIP(ip)
+    if parsed == nil || name == "" {
+        return
+    }
+    ip = parsed.String()
+    name = normalize(name)
+    for _, n := range r[ip] {
+        if n == name {
+            return
+        }
+    }
+    r[ip] = append(r[ip], name)
+}
+
+// Names returns the names of the address
+func (r Resolver) Names(ip string) []string {
+    if parsed := net.ParseIP(ip); parsed != nil {
+        ip = parsed.String()
+    }
+    names := append([]string{}, r[ip]...)
+    sort.Strings(names)
+    return names
+}
+
+// IPs returns the addresses with names, IPv4 first
+func (r Resolver) IPs() []string {
+    ips := []net.IP{}
+    for a := range r {
+        if ip := net.ParseIP(a); ip != nil {
+            ips = append(ips, ip)
+        }
+    }
+    sort.Slice(ips, func(i, j int) bool {
+        if a4, b4 := ips[i].To4() != nil, ips[j].To4() != nil; a4 != b4 {
+            return a4
+        }
+        return bytes.Compare(ips[i].To16(), ips[j].To16()) < 0
+    })
+    list := []string{}
+    for _, ip := range ips {
+        list = append(list, ip.String())
+    }
+    return list
+}
+
+// WriteHosts writes the resolver in the hosts file format
+func (r Resolver) WriteHosts(w io.Writer) error {
+    for _, ip := range r.IPs() {
+        if _, err := fmt.Fprintf(w, "%-39s %s\n", ip, strings.Join(r.Names(ip), " ")); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// LoadResolver reads a resolver saved in the hosts file format
+func LoadResolver(fileName string) (Resolver, error) {
+    f, err := os.Open(fileName)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    r := Resolver{}
+    scanner := bufio.NewScanner(f)
+    lineNum := 0
+    for scanner.Scan() {
+        lineNum++
+        line := scanner.Text()
+        if idx := strings.Index(line, "#"); idx >= 0 {
+            line = line[:idx]
+        }
+        fields := strings.Fields(line)
+        if len(fields) == 0 {
+            continue
+        }
+        if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+            return nil, errors.New(fmt.Sprintf("line %d: entry must be: <ip> <name> [name...]", lineNum))
+        }
+        for _, name := range fields[1:] {
+            r.Add(fields[0], name)
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+
+    return r, nil
+}
@@ -0,0 +1,311 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package dot11 decodes the Radiotap and IEEE 802.11 headers of wireless
+// captures, the management frame elements and the security (RSN/WPA)
+// parameters.
+package dot11
+
+import (
+    "encoding/binary"
+    "errors"
+    "net"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// Frame types
+const (
+    TypeManagement  = 0
+    TypeControl     = 1
+    TypeData        = 2
+)
+
+// Management frame subtypes
+const (
+    SubtypeAssocRequest     = 0
+    SubtypeAssocResponse    = 1
+    SubtypeReassocRequest   = 2
+    SubtypeReassocResponse  = 3
+    SubtypeProbeRequest     = 4
+    SubtypeProbeResponse    = 5
+    SubtypeBeacon           = 8
+    SubtypeDisassoc         = 10
+    SubtypeAuth             = 11
+    SubtypeDeauth           = 12
+)
+
+// Frame control flags
+const (
+    FlagToDS        = 0x01
+    FlagFromDS      = 0x02
+    FlagProtected   = 0x40
+    FlagOrder       = 0x80
+)
+
+var errFrame = errors.New("invalid 802.11 frame")
+
+// Frame is a decoded 802.11 frame
+type Frame struct {
+    Type            int
+    Subtype         int
+    Flags           uint8
+    Addr1           net.HardwareAddr
+    Addr2           net.HardwareAddr
+    Addr3           net.HardwareAddr
+    Addr4           net.HardwareAddr
+    // frame body, without the FCS
+    Body            []byte
+    // Radiotap channel frequency (MHz) and signal (dBm), zero when absent
+    Frequency       int
+    Signal          int
+    HasSignal       bool
+}
+
+// IsWireless tells if the link type carries 802.11 frames
+func IsWireless(linkType layers.LinkType) bool {
+    return linkType == layers.LinkTypeIEEE80211Radio || linkType == layers.LinkTypeIEEE802_11
+}
+
+// Parse decodes a frame of a Radiotap (127) or plain 802.11 (105)
+// capture. Plain 802.11 frames are expected without FCS
+func Parse(data []byte, linkType layers.LinkType) (*Frame, error) {
+    f := &Frame{}
+    fcs, pad := false, false
+
+    switch linkType {
+    case layers.LinkTypeIEEE80211Radio:
+        rt := &layers.RadioTap{}
+        if err := rt.DecodeFromBytes(data, gopacket.NilDecodeFeedback); err != nil {
+            return nil, err
+        }
+        if int(rt.Length) > len(data) {
+            return nil, errFrame
+        }
+        if rt.Present.Channel() {
+            f.Frequency = int(rt.ChannelFrequency)
+        }
+        if rt.Present.DBMAntennaSignal() {
+            f.Signal, f.HasSignal = int(rt.DBMAntennaSignal), true
+        }
+        if rt.Present.Flags() {
+            if rt.Flags.BadFCS() {
+                return nil, errFrame
+            }
+            fcs, pad = rt.Flags.FCS(), rt.Flags.Datapad()
+        }
+        data = data[rt.Length:]
+    case layers.LinkTypeIEEE802_11:
+    default:
+        return nil, errFrame
+    }
+
+    if fcs {
+        if len(data) < 4 {
+            return nil, errFrame
+        }
+        data = data[:len(data) - 4]
+    }
+    if len(data) < 10 {
+        return nil, errFrame
+    }
+
+    f.Type = int(data[0] >> 2) & 0x03
+    f.Subtype = int(data[0] >> 4)
+    f.Flags = data[1]
+    f.Addr1 = net.HardwareAddr(data[4:10])
+    if f.Type == TypeControl {
+        return f, nil
+    }
+
+    size := 24
+    if f.Type == TypeData {
+        if f.Flags & FlagToDS != 0 && f.Flags & FlagFromDS != 0 {
+            size += 6
+        }
+        // QoS data subtypes have the QoS control field
+        if f.Subtype & 0x08 != 0 {
+            size += 2
+            if f.Flags & FlagOrder != 0 {
+                size += 4
+            }
+        }
+    } else if f.Flags & FlagOrder != 0 {
+        size += 4
+    }
+    if pad && size % 4 != 0 {
+        size += 4 - size % 4
+    }
+    if len(data) < size {
+        return nil, errFrame
+    }
+
+    f.Addr2 = net.HardwareAddr(data[10:16])
+    f.Addr3 = net.HardwareAddr(data[16:22])
+    if size >= 30 && f.Flags & FlagToDS != 0 && f.Flags & FlagFromDS != 0 {
+        f.Addr4 = net.HardwareAddr(data[24:30])
+    }
+    f.Body = data[size:]
+    return f, nil
+}
+
+// Protected tells if the frame body is encrypted
+func (f *Frame) Protected() bool {
+    return f.Flags & FlagProtected != 0
+}
+
+// BSSID returns the BSS of the frame, nil for frames between access
+// points (WDS) and control frames
+func (f *Frame) BSSID() net.HardwareAddr {
+    switch {
+    case f.Type == TypeControl:
+        return nil
+    case f.Type == TypeManagement:
+        return f.Addr3
+    case f.Flags & FlagToDS != 0 && f.Flags & FlagFromDS != 0:
+        return nil
+    case f.Flags & FlagToDS != 0:
+        return f.Addr1
+    case f.Flags & FlagFromDS != 0:
+        return f.Addr2
+    }
+    return f.Addr3
+}
+
+// Source and Destination return the addresses of the frame payload
+// sender and receiver (SA and DA)
+func (f *Frame) Source() net.HardwareAddr {
+    switch {
+    case f.Flags & FlagToDS != 0 && f.Flags & FlagFromDS != 0:
+        return f.Addr4
+    case f.Flags & FlagFromDS != 0:
+        return f.Addr3
+    }
+    return f.Addr2
+}
+
+func (f *Frame) Destination() net.HardwareAddr {
+    if f.Flags & FlagToDS != 0 {
+        return f.Addr3
+    }
+    return f.Addr1
+}
+
+// Ethernet returns the payload of an unprotected data frame as an
+// Ethernet frame, false for other frames (e.g. still encrypted)
+func (f *Frame) Ethernet() ([]byte, bool) {
+    // subtypes with bit 2 set carry no data
+    if f.Type != TypeData || f.Subtype & 0x04 != 0 || f.Protected() {
+        return nil, false
+    }
+    b := f.Body
+    // LLC/SNAP header with RFC 1042 or bridge tunnel encapsulation
+    if len(b) < 8 || b[0] != 0xaa || b[1] != 0xaa || b[2] != 0x03 {
+        return nil, false
+    }
+    if b[3] != 0 || b[4] != 0 || (b[5] != 0 && b[5] != 0xf8) {
+        return nil, false
+    }
+    src, dst := f.Source(), f.Destination()
+    if src == nil || dst == nil {
+        return nil, false
+    }
+    frame := make([]byte, 14 + len(b) - 8)
+    copy(frame[0:6], dst)
+    copy(frame[6:12], src)
+    copy(frame[12:14], b[6:8])
+    copy(frame[14:], b[8:])
+    return frame, true
+}
+
+// Timestamp returns the TSF timer (microseconds) of a beacon or probe
+// response
+func (f *Frame) Timestamp() (uint64, bool) {
+    if f.Type != TypeManagement || (f.Subtype != SubtypeBeacon && f.Subtype != SubtypeProbeResponse) {
+        return 0, false
+    }
+    if len(f.Body) < 8 {
+        return 0, false
+    }
+    return binary.LittleEndian.Uint64(f.Body), true
+}
+
+// Capability returns the capability information of beacons, probe
+// responses and association requests
+func (f *Frame) Capability() (uint16, bool) {
+    off := -1
+    switch f.Subtype {
+    case SubtypeBeacon, SubtypeProbeResponse:
+        off = 10
+    case SubtypeAssocRequest, SubtypeReassocRequest:
+        off = 0
+    }
+    if f.Type != TypeManagement || off < 0 || len(f.Body) < off + 2 {
+        return 0, false
+    }
+    return binary.LittleEndian.Uint16(f.Body[off:]), true
+}
+
+// Elements returns the information elements of a management frame
+func (f *Frame) Elements() Elements {
+    if f.Type != TypeManagement {
+        return nil
+    }
+    skip := 0
+    switch f.Subtype {
+    case SubtypeBeacon, SubtypeProbeResponse:
+        skip = 12
+    case SubtypeAssocRequest:
+        skip = 4
+    case SubtypeReassocRequest:
+        skip = 10
+    case SubtypeAssocResponse, SubtypeReassocResponse:
+        skip = 6
+    case SubtypeProbeRequest:
+    default:
+        return nil
+    }
+    if len(f.Body) < skip {
+        return nil
+    }
+    return ParseElements(f.Body[skip:])
+}
+
+// Channel returns the channel number of a frequency (MHz)
+func Channel(freq int) int {
+    switch {
+    case freq == 2484:
+        return 14
+    case freq >= 2412 && freq < 2484:
+        return (freq - 2407) / 5
+    case freq >= 5955 && freq <= 7115:
+        return (freq - 5950) / 5
+    case freq >= 5000 && freq < 5955:
+        return (freq - 5000) / 5
+    }
+    return 0
+}
+
+// Band returns the band name of a frequency (MHz)
+func Band(freq int) string {
+    switch {
+    case freq >= 2400 && freq < 2500:
+        return "2.4GHz"
+    case freq >= 5955 && freq <= 7115:
+        return "6GHz"
+    case freq >= 5000 && freq < 5955:
+        return "5GHz"
+    }
+    return ""
+}
@@ -0,0 +1,227 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package dot11
+
+import (
+    "bytes"
+    "encoding/binary"
+    "sort"
+    "strings"
+)
+
+// Information element IDs
+const (
+    ElementSSID         = 0
+    ElementDSParams     = 3
+    ElementRSN          = 48
+    ElementHTOperation  = 61
+    ElementVendor       = 221
+)
+
+// OUIs of the RSN and WPA (vendor element) suites
+var (
+    ouiRSN          = []byte{ 0x00, 0x0f, 0xac }
+    ouiMicrosoft    = []byte{ 0x00, 0x50, 0xf2 }
+)
+
+// cipher suite types
+var ciphers = map[byte]string{
+    1   : "WEP-40",
+    2   : "TKIP",
+    4   : "CCMP",
+    5   : "WEP-104",
+    8   : "GCMP",
+    9   : "GCMP-256",
+    10  : "CCMP-256",
+}
+
+// AKM suite types
+var akms = map[byte]string{
+    1   : "802.1X",
+    2   : "PSK",
+    3   : "FT-802.1X",
+    4   : "FT-PSK",
+    5   : "802.1X-SHA256",
+    6   : "PSK-SHA256",
+    8   : "SAE",
+    9   : "FT-SAE",
+    11  : "802.1X-SuiteB",
+    12  : "802.1X-SuiteB-192",
+    18  : "OWE",
+    24  : "SAE-EXT",
+}
+
+// Element is an information element
+type Element struct {
+    ID              int
+    Data            []byte
+}
+
+// Elements is the list of elements of a management frame
+type Elements []Element
+
+// ParseElements parses the elements, stopping at the first truncated one
+func ParseElements(data []byte) Elements {
+    list := Elements{}
+    for len(data) >= 2 {
+        size := int(data[1])
+        if len(data) < 2 + size {
+            break
+        }
+        list = append(list, Element{ ID: int(data[0]), Data: data[2:2 + size] })
+        data = data[2 + size:]
+    }
+    return list
+}
+
+// Get returns the data of the first element with this ID
+func (l Elements) Get(id int) ([]byte, bool) {
+    for _, e := range l {
+        if e.ID == id {
+            return e.Data, true
+        }
+    }
+    return nil, false
+}
+
+// SSID returns the network name, hidden is true when it is empty or
+// blanked out with zeros
+func (l Elements) SSID() (ssid string, hidden bool, ok bool) {
+    data, ok := l.Get(ElementSSID)
+    if !ok {
+        return "", false, false
+    }
+    if len(bytes.Trim(data, "\x00")) == 0 {
+        return "", true, true
+    }
+    return string(data), false, true
+}
+
+// Channel returns the channel announced by the DS parameter set or the
+// HT operation elements
+func (l Elements) Channel() int {
+    if data, ok := l.Get(ElementDSParams); ok && len(data) >= 1 {
+        return int(data[0])
+    }
+    if data, ok := l.Get(ElementHTOperation); ok && len(data) >= 1 {
+        return int(data[0])
+    }
+    return 0
+}
+
+// Security is the protection announced by an access point
+type Security struct {
+    // Open, WEP, WPA, WPA2, WPA3 or a transition mode (e.g. WPA2/WPA3)
+    Encryption      string
+    Ciphers         []string
+    Auth            []string
+}
+
+// suiteList reads a suite count and list, returning the known names and
+// the data left
+func suiteList(data []byte, oui []byte, names map[byte]string, set map[string]bool) []byte {
+    if len(data) < 2 {
+        return nil
+    }
+    n := int(binary.LittleEndian.Uint16(data))
+    data = data[2:]
+    for i := 0; i < n && len(data) >= 4; i++ {
+        if bytes.Equal(data[:3], oui) {
+            if name, ok := names[data[3]]; ok {
+                set[name] = true
+            }
+        }
+        data = data[4:]
+    }
+    return data
+}
+
+// parseSuites parses the RSN or WPA element body after the version:
+// group cipher, pairwise ciphers and AKM suites
+func parseSuites(data []byte, oui []byte, cipherSet, authSet map[string]bool) {
+    if len(data) < 4 {
+        return
+    }
+    if bytes.Equal(data[:3], oui) {
+        if c, ok := ciphers[data[3]]; ok {
+            cipherSet[c] = true
+        }
+    }
+    data = suiteList(data[4:], oui, ciphers, cipherSet)
+    suiteList(data, oui, akms, authSet)
+}
+
+// Security returns the protection announced by the RSN and WPA elements
+// and the capability privacy bit
+func (l Elements) Security(capability uint16) Security {
+    const privacy = 0x0010
+    cipherSet, authSet := map[string]bool{}, map[string]bool{}
+    // the RSN AKMs tell WPA2 from WPA3, the WPA element is WPA only
+    rsnAuth := map[string]bool{}
+    wpa, rsn := false, false
+
+    for _, e := range l {
+        switch {
+        case e.ID == ElementRSN && len(e.Data) >= 2:
+            rsn = true
+            parseSuites(e.Data[2:], ouiRSN, cipherSet, rsnAuth)
+        case e.ID == ElementVendor && len(e.Data) >= 6 && bytes.Equal(e.Data[:3], ouiMicrosoft) && e.Data[3] == 1:
+            wpa = true
+            parseSuites(e.Data[6:], ouiMicrosoft, cipherSet, authSet)
+        }
+    }
+    for a := range rsnAuth {
+        authSet[a] = true
+    }
+
+    s := Security{ Ciphers: sortedSet(cipherSet), Auth: sortedSet(authSet) }
+    wpa3, wpa2 := false, false
+    for a := range rsnAuth {
+        switch a {
+        case "SAE", "FT-SAE", "SAE-EXT", "OWE", "802.1X-SuiteB-192":
+            wpa3 = true
+        default:
+            wpa2 = true
+        }
+    }
+    versions := []string{}
+    if wpa {
+        versions = append(versions, "WPA")
+    }
+    if rsn && (wpa2 || !wpa3) {
+        versions = append(versions, "WPA2")
+    }
+    if rsn && wpa3 {
+        versions = append(versions, "WPA3")
+    }
+
+    switch {
+    case len(versions) > 0:
+        s.Encryption = strings.Join(versions, "/")
+    case capability & privacy != 0:
+        s.Encryption = "WEP"
+    default:
+        s.Encryption = "Open"
+    }
+    return s
+}
+
+func sortedSet(set map[string]bool) []string {
+    list := []string{}
+    for k := range set {
+        list = append(list, k)
+    }
+    sort.Strings(list)
+    return list
+}
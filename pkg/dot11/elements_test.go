@@ -0,0 +1,52 @@
+package dot11
+
+import "testing"
+
+// suites builds an RSN or WPA element body: group cipher, pairwise
+// ciphers and AKM suites
+func suites(oui []byte, group byte, pairwise []byte, auth []byte) []byte {
+	out := append(append([]byte{}, oui...), group)
+	out = append(out, byte(len(pairwise)), 0)
+	for _, c := range pairwise {
+		out = append(append(out, oui...), c)
+	}
+	out = append(out, byte(len(auth)), 0)
+	for _, a := range auth {
+		out = append(append(out, oui...), a)
+	}
+	return out
+}
+
+func rsnElement(auth ...byte) Element {
+	return Element{ID: ElementRSN, Data: append([]byte{1, 0}, suites(ouiRSN, 4, []byte{4}, auth)...)}
+}
+
+func wpaElement(auth ...byte) Element {
+	hdr := append(append([]byte{}, ouiMicrosoft...), 1, 1, 0)
+	return Element{ID: ElementVendor, Data: append(hdr, suites(ouiMicrosoft, 2, []byte{2}, auth)...)}
+}
+
+func TestSecurity(t *testing.T) {
+	tests := []struct {
+		name       string
+		elements   Elements
+		capability uint16
+		want       string
+	}{
+		{"open", nil, 0, "Open"},
+		{"wep", nil, 0x0010, "WEP"},
+		{"wpa", Elements{wpaElement(2)}, 0x0010, "WPA"},
+		{"wpa2", Elements{rsnElement(2)}, 0x0010, "WPA2"},
+		{"wpa3", Elements{rsnElement(8)}, 0x0010, "WPA3"},
+		{"wpa2 wpa3 transition", Elements{rsnElement(2, 8)}, 0x0010, "WPA2/WPA3"},
+		{"wpa psk with rsn sae", Elements{wpaElement(2), rsnElement(8)}, 0x0010, "WPA/WPA3"},
+		{"wpa and wpa2", Elements{wpaElement(2), rsnElement(2)}, 0x0010, "WPA/WPA2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.elements.Security(tt.capability).Encryption; got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -17,6 +17,17 @@
 
 package gopcap
 
+import "time"
+
+// PCAP magic numbers, as read in little endian byte order
+const (
+	MagicMicroseconds        uint32 = 0xa1b2c3d4
+	MagicNanoseconds         uint32 = 0xa1b23c4d
+	MagicMicrosecondsSwapped uint32 = 0xd4c3b2a1
+	MagicNanosecondsSwapped  uint32 = 0x4d3cb2a1
+	MagicPcapNG              uint32 = 0x0a0d0d0a
+)
+
 /////////////////////////////
 // Data Structures
 /////////////////////////////
@@ -51,3 +62,26 @@ type PacketHeader struct {
 	// actual length of packet
 	OriginalLen int32 // 12
 } // 16
+
+// IsNanosecond returns true if packet timestamps have nanosecond resolution
+func (h FileHeader) IsNanosecond() bool {
+	return h.MagicNumber == MagicNanoseconds
+}
+
+// PacketTime returns the packet timestamp honoring the file time resolution
+func (h FileHeader) PacketTime(p PacketHeader) time.Time {
+	if h.IsNanosecond() {
+		return time.Unix(int64(p.TsSec), int64(p.TsUsec))
+	}
+	return time.Unix(int64(p.TsSec), int64(p.TsUsec)*1e3)
+}
+
+// SetPacketTime updates the packet timestamp honoring the file time resolution
+func (h FileHeader) SetPacketTime(p *PacketHeader, t time.Time) {
+	p.TsSec = int32(t.Unix())
+	if h.IsNanosecond() {
+		p.TsUsec = int32(t.Nanosecond())
+	} else {
+		p.TsUsec = int32(t.Nanosecond() / 1e3)
+	}
+}
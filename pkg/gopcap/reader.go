@@ -20,6 +20,7 @@ package gopcap
 import (
 	"bufio"
 	"encoding/binary"
+	"errors"
 	//"fmt"
 	"io"
 	"os"
@@ -37,8 +38,16 @@ type Reader struct {
 	FileHandle *os.File
 	Buffer     *bufio.Reader
 	Header     FileHeader
+	// byte order used by the file (detected from magic number)
+	ByteOrder binary.ByteOrder
 }
 
+// ErrPcapNG is returned when the file is a PCAPNG capture
+var ErrPcapNG = errors.New("pcapng file format is not supported, convert it to pcap first")
+
+// ErrInvalidMagic is returned when the file is not a PCAP capture
+var ErrInvalidMagic = errors.New("invalid pcap file (unknown magic number)")
+
 // Open pcap file
 func Open(filename string) (*Reader, error) {
 
@@ -54,17 +63,31 @@ func Open(filename string) (*Reader, error) {
 
 	var buff [24]byte
 	if _, err := io.ReadFull(r.FileHandle, buff[:]); err != nil {
+		r.FileHandle.Close()
 		return nil, err
 	}
 
+	switch binary.LittleEndian.Uint32(buff[:4]) {
+	case MagicMicroseconds, MagicNanoseconds:
+		r.ByteOrder = binary.LittleEndian
+	case MagicMicrosecondsSwapped, MagicNanosecondsSwapped:
+		r.ByteOrder = binary.BigEndian
+	case MagicPcapNG:
+		r.FileHandle.Close()
+		return nil, ErrPcapNG
+	default:
+		r.FileHandle.Close()
+		return nil, ErrInvalidMagic
+	}
+
 	r.Header = FileHeader{
-		MagicNumber:  binary.LittleEndian.Uint32(buff[:4]),
-		VersionMajor: binary.LittleEndian.Uint16(buff[4:6]),
-		VersionMinor: binary.LittleEndian.Uint16(buff[6:8]),
-		Thiszone:     int32(binary.LittleEndian.Uint32(buff[8:12])),
-		Sigfigs:      binary.LittleEndian.Uint32(buff[12:16]),
-		Snaplen:      binary.LittleEndian.Uint32(buff[16:20]),
-		Network:      binary.LittleEndian.Uint32(buff[20:24]),
+		MagicNumber:  r.ByteOrder.Uint32(buff[:4]),
+		VersionMajor: r.ByteOrder.Uint16(buff[4:6]),
+		VersionMinor: r.ByteOrder.Uint16(buff[6:8]),
+		Thiszone:     int32(r.ByteOrder.Uint32(buff[8:12])),
+		Sigfigs:      r.ByteOrder.Uint32(buff[12:16]),
+		Snaplen:      r.ByteOrder.Uint32(buff[16:20]),
+		Network:      r.ByteOrder.Uint32(buff[20:24]),
 	}
 
 	r.Buffer = bufio.NewReader(r.FileHandle)
@@ -80,10 +103,10 @@ func (r *Reader) ReadNextPacket() (PacketHeader, []byte, error) {
 	}
 
 	pcaprecHdr := PacketHeader{
-		TsSec:       int32(binary.LittleEndian.Uint32(buff[0:4])),
-		TsUsec:      int32(binary.LittleEndian.Uint32(buff[4:8])),
-		CaptureLen:  int32(binary.LittleEndian.Uint32(buff[8:12])),
-		OriginalLen: int32(binary.LittleEndian.Uint32(buff[12:16])),
+		TsSec:       int32(r.ByteOrder.Uint32(buff[0:4])),
+		TsUsec:      int32(r.ByteOrder.Uint32(buff[4:8])),
+		CaptureLen:  int32(r.ByteOrder.Uint32(buff[8:12])),
+		OriginalLen: int32(r.ByteOrder.Uint32(buff[12:16])),
 	}
 
 	var buf []byte
@@ -117,10 +140,10 @@ func (r *Reader) ReadNextPacketHeader() (PacketHeader, []byte, error) {
 	}
 
 	pcaprecHdr := PacketHeader{
-		TsSec:       int32(binary.LittleEndian.Uint32(buff[0:4])),
-		TsUsec:      int32(binary.LittleEndian.Uint32(buff[4:8])),
-		CaptureLen:  int32(binary.LittleEndian.Uint32(buff[8:12])),
-		OriginalLen: int32(binary.LittleEndian.Uint32(buff[12:16])),
+		TsSec:       int32(r.ByteOrder.Uint32(buff[0:4])),
+		TsUsec:      int32(r.ByteOrder.Uint32(buff[4:8])),
+		CaptureLen:  int32(r.ByteOrder.Uint32(buff[8:12])),
+		OriginalLen: int32(r.ByteOrder.Uint32(buff[12:16])),
 	}
 
 	var buf = make([]byte, pcaprecHdr.CaptureLen)
@@ -0,0 +1,185 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package httpinv
+
+import (
+    "encoding/csv"
+    "io"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Host is a HTTP virtual host
+type Host struct {
+    Name            string      `json:"name"`
+    Servers         []string    `json:"servers"`
+    Banners         []string    `json:"banners"`
+    Requests        int         `json:"requests"`
+}
+
+// UserAgent is a User-Agent header and the clients sending it
+type UserAgent struct {
+    Value           string      `json:"value"`
+    Clients         []string    `json:"clients"`
+    Requests        int         `json:"requests"`
+}
+
+// Report is the HTTP inventory of the capture
+type Report struct {
+    Hosts           []Host          `json:"hosts"`
+    UserAgents      []UserAgent     `json:"user_agents"`
+    Transactions    []*Transaction  `json:"transactions"`
+}
+
+func sortedKeys(set map[string]bool) []string {
+    list := []string{}
+    for k := range set {
+        list = append(list, k)
+    }
+    sort.Strings(list)
+    return list
+}
+
+// Report returns the hosts, user agents and transactions found
+func (c *Collector) Report() *Report {
+    rpt := &Report{ Transactions: c.Transactions() }
+
+    type hostSets struct {
+        servers, banners map[string]bool
+        requests         int
+    }
+    type agentSets struct {
+        clients  map[string]bool
+        requests int
+    }
+    hosts := map[string]*hostSets{}
+    agents := map[string]*agentSets{}
+    for _, t := range rpt.Transactions {
+        h := hosts[t.Host]
+        if h == nil {
+            h = &hostSets{ servers: map[string]bool{}, banners: map[string]bool{} }
+            hosts[t.Host] = h
+        }
+        h.servers[t.Server] = true
+        if t.ServerBanner != "" {
+            h.banners[t.ServerBanner] = true
+        }
+        h.requests++
+
+        if t.UserAgent == "" {
+            continue
+        }
+        a := agents[t.UserAgent]
+        if a == nil {
+            a = &agentSets{ clients: map[string]bool{} }
+            agents[t.UserAgent] = a
+        }
+        if ip, _, err := net.SplitHostPort(t.Client); err == nil {
+            a.clients[ip] = true
+        }
+        a.requests++
+    }
+
+    for name, h := range hosts {
+        rpt.Hosts = append(rpt.Hosts, Host{
+            Name        : name,
+            Servers     : sortedKeys(h.servers),
+            Banners     : sortedKeys(h.banners),
+            Requests    : h.requests,
+        })
+    }
+    sort.Slice(rpt.Hosts, func(i, j int) bool { return rpt.Hosts[i].Name < rpt.Hosts[j].Name })
+    for value, a := range agents {
+        rpt.UserAgents = append(rpt.UserAgents, UserAgent{
+            Value       : value,
+            Clients     : sortedKeys(a.clients),
+            Requests    : a.requests,
+        })
+    }
+    sort.Slice(rpt.UserAgents, func(i, j int) bool {
+        if rpt.UserAgents[i].Requests != rpt.UserAgents[j].Requests {
+            return rpt.UserAgents[i].Requests > rpt.UserAgents[j].Requests
+        }
+        return rpt.UserAgents[i].Value < rpt.UserAgents[j].Value
+    })
+    return rpt
+}
+
+// JSONLines writes one record per host, user agent and transaction, the
+// "type" field tells them apart
+func (r *Report) JSONLines() []interface{} {
+    type kind struct {
+        Type    string  `json:"type"`
+    }
+    list := []interface{}{}
+    for _, h := range r.Hosts {
+        list = append(list, struct {
+            kind
+            Host
+        }{ kind{ "host" }, h })
+    }
+    for _, ua := range r.UserAgents {
+        list = append(list, struct {
+            kind
+            UserAgent
+        }{ kind{ "user_agent" }, ua })
+    }
+    for _, t := range r.Transactions {
+        list = append(list, struct {
+            kind
+            *Transaction
+        }{ kind{ "transaction" }, t })
+    }
+    return list
+}
+
+var transactionsCSVHeader = []string{
+    "time", "client", "server", "method", "host", "url", "version", "user_agent", "referer",
+    "status", "server_banner", "content_type", "request_size", "response_size", "file",
+}
+
+// WriteCSV writes one row per transaction
+func (r *Report) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(transactionsCSVHeader); err != nil {
+        return err
+    }
+    for _, t := range r.Transactions {
+        status := ""
+        if t.Status != 0 {
+            status = strconv.Itoa(t.Status)
+        }
+        row := []string{
+            t.Time.UTC().Format(time.RFC3339Nano), t.Client, t.Server, t.Method, t.Host, t.URL,
+            t.Version, t.UserAgent, t.Referer, status, t.ServerBanner, t.ContentType,
+            strconv.FormatInt(t.RequestSize, 10), strconv.FormatInt(t.ResponseSize, 10), t.File,
+        }
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+// mediaType returns the content type without its parameters
+func mediaType(contentType string) string {
+    if idx := strings.IndexByte(contentType, ';'); idx >= 0 {
+        contentType = contentType[:idx]
+    }
+    return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,182 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package httpinv
+
+import (
+    "crypto/sha256"
+    "encoding/csv"
+    "encoding/hex"
+    "os"
+    "path/filepath"
+    "strconv"
+    "time"
+)
+
+// ManifestFile is the name of the manifest written by Extract
+const ManifestFile = "manifest.csv"
+
+var extensions = map[string]string{
+    "text/html"                         : ".html",
+    "text/plain"                        : ".txt",
+    "text/css"                          : ".css",
+    "text/csv"                          : ".csv",
+    "text/xml"                          : ".xml",
+    "text/javascript"                   : ".js",
+    "application/javascript"            : ".js",
+    "application/x-javascript"          : ".js",
+    "application/json"                  : ".json",
+    "application/xml"                   : ".xml",
+    "application/pdf"                   : ".pdf",
+    "application/zip"                   : ".zip",
+    "application/gzip"                  : ".gz",
+    "application/x-gzip"                : ".gz",
+    "application/x-tar"                 : ".tar",
+    "application/x-msdownload"          : ".exe",
+    "application/x-dosexec"             : ".exe",
+    "application/vnd.ms-cab-compressed" : ".cab",
+    "application/x-www-form-urlencoded" : ".txt",
+    "application/x-x509-ca-cert"        : ".crt",
+    "application/pkix-cert"             : ".crt",
+    "application/pkix-crl"              : ".crl",
+    "application/ocsp-request"          : ".der",
+    "application/ocsp-response"         : ".der",
+    "image/png"                         : ".png",
+    "image/jpeg"                        : ".jpg",
+    "image/gif"                         : ".gif",
+    "image/webp"                        : ".webp",
+    "image/svg+xml"                     : ".svg",
+    "image/x-icon"                      : ".ico",
+    "image/vnd.microsoft.icon"          : ".ico",
+    "font/woff"                         : ".woff",
+    "font/woff2"                        : ".woff2",
+}
+
+// Object is a HTTP body carved from the capture
+type Object struct {
+    File            string      `json:"file"`
+    SHA256          string      `json:"sha256"`
+    Size            int         `json:"size"`
+    ContentType     string      `json:"content_type"`
+    // request or response
+    Direction       string      `json:"direction"`
+    Truncated       bool        `json:"truncated"`
+    URL             string      `json:"url"`
+    Method          string      `json:"method"`
+    Status          int         `json:"status"`
+    Client          string      `json:"client"`
+    Server          string      `json:"server"`
+    Time            time.Time   `json:"time"`
+}
+
+var manifestCSVHeader = []string{
+    "file", "sha256", "size", "content_type", "direction", "truncated", "url", "method",
+    "status", "client", "server", "time",
+}
+
+// save writes the body of the message to the extract directory, named by
+// its SHA256 hash, once decoded from its transfer and content encodings.
+// It returns nil when nothing was written
+func (c *Collector) save(t *Transaction, m *message, direction string) *Object {
+    if c.extractDir == "" || c.err != nil || len(m.body) == 0 {
+        return nil
+    }
+    if c.written == nil {
+        if c.err = os.MkdirAll(c.extractDir, 0755); c.err != nil {
+            return nil
+        }
+        c.written = map[string]bool{}
+    }
+
+    body := decodeBody(m, DefaultMaxBody)
+    m.body = nil
+    if len(body) == 0 {
+        return nil
+    }
+    sum := sha256.Sum256(body)
+    hash := hex.EncodeToString(sum[:])
+    contentType := mediaType(m.header.Get("Content-Type"))
+    ext, ok := extensions[contentType]
+    if !ok {
+        ext = ".bin"
+    }
+    name := hash + ext
+    if !c.written[name] {
+        if c.err = os.WriteFile(filepath.Join(c.extractDir, name), body, 0644); c.err != nil {
+            return nil
+        }
+        c.written[name] = true
+    }
+    o := &Object{
+        File        : name,
+        SHA256      : hash,
+        Size        : len(body),
+        ContentType : contentType,
+        Direction   : direction,
+        Truncated   : m.truncated,
+        URL         : t.URL,
+        Method      : t.Method,
+        Status      : t.Status,
+        Client      : t.Client,
+        Server      : t.Server,
+        Time        : m.time,
+    }
+    c.objects = append(c.objects, o)
+    return o
+}
+
+// Objects writes the manifest.csv of the bodies saved to the extract
+// directory, listing where each one came from, and returns them
+func (c *Collector) Objects() ([]Object, error) {
+    c.finish()
+    if c.err != nil {
+        return nil, c.err
+    }
+    if err := os.MkdirAll(c.extractDir, 0755); err != nil {
+        return nil, err
+    }
+    objects := []Object{}
+    for _, o := range c.objects {
+        objects = append(objects, *o)
+    }
+    return objects, writeManifest(filepath.Join(c.extractDir, ManifestFile), objects)
+}
+
+func writeManifest(file string, objects []Object) error {
+    f, err := os.Create(file)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    cw := csv.NewWriter(f)
+    if err = cw.Write(manifestCSVHeader); err != nil {
+        return err
+    }
+    for _, o := range objects {
+        status := ""
+        if o.Status != 0 {
+            status = strconv.Itoa(o.Status)
+        }
+        row := []string{
+            o.File, o.SHA256, strconv.Itoa(o.Size), o.ContentType, o.Direction,
+            strconv.FormatBool(o.Truncated), o.URL, o.Method, status, o.Client, o.Server,
+            o.Time.UTC().Format(time.RFC3339Nano),
+        }
+        if err = cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
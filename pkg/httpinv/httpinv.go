@@ -0,0 +1,315 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package httpinv
+
+import (
+    "bytes"
+    "compress/flate"
+    "compress/gzip"
+    "compress/zlib"
+    "io"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/tcpstream"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// maxStreamSize is the most unparsed bytes buffered by direction
+const maxStreamSize = 4 * 1024 * 1024
+
+// DefaultMaxBody is the biggest body kept for extraction
+const DefaultMaxBody = 64 * 1024 * 1024
+
+var methods = []string{
+    "GET", "POST", "HEAD", "PUT", "DELETE", "OPTIONS", "PATCH", "CONNECT", "TRACE",
+    "PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK", "REPORT",
+}
+
+// Transaction is a HTTP request and its response
+type Transaction struct {
+    Client          string      `json:"client"`
+    Server          string      `json:"server"`
+    Method          string      `json:"method"`
+    Host            string      `json:"host"`
+    URL             string      `json:"url"`
+    Version         string      `json:"version"`
+    UserAgent       string      `json:"user_agent"`
+    Referer         string      `json:"referer"`
+    // zero when the response was not seen
+    Status          int         `json:"status"`
+    // Server response header
+    ServerBanner    string      `json:"server_banner"`
+    ContentType     string      `json:"content_type"`
+    RequestSize     int64       `json:"request_size"`
+    ResponseSize    int64       `json:"response_size"`
+    // response body saved to the extract directory
+    File            string      `json:"file,omitempty"`
+    Time            time.Time   `json:"time"`
+
+    // request body saved, its status is set with the response
+    requestObject   *Object
+}
+
+// conn is a HTTP connection
+type conn struct {
+    client          string
+    server          string
+    clientStream    *tcpstream.Stream
+    serverStream    *tcpstream.Stream
+    requests        *parser
+    responses       *parser
+    // requests waiting for their response
+    waiting         []*Transaction
+    methods         []string
+    broken          bool
+}
+
+// Collector extracts the HTTP transactions packet by packet
+type Collector struct {
+    conns           map[string]*conn
+    transactions    []*Transaction
+    maxBody         int64
+    finished        bool
+    // bodies are saved there as each message completes
+    extractDir      string
+    // files saved, created with the directory
+    written         map[string]bool
+    objects         []*Object
+    // first error saving the bodies, extraction stops there
+    err             error
+}
+
+// NewCollector returns a collector saving the bodies to extractDir, no
+// body is kept when it is empty
+func NewCollector(extractDir string) *Collector {
+    c := &Collector{
+        conns       : map[string]*conn{},
+        extractDir  : extractDir,
+    }
+    if extractDir != "" {
+        c.maxBody = DefaultMaxBody
+    }
+    return c
+}
+
+// isRequest tells if the payload starts with a HTTP/1.x request line
+func isRequest(payload []byte) bool {
+    end := bytes.IndexByte(payload, '\n')
+    if end < 0 {
+        end = len(payload)
+    }
+    line := string(payload[:end])
+    sp := strings.IndexByte(line, ' ')
+    if sp < 0 {
+        return false
+    }
+    method := line[:sp]
+    for _, m := range methods {
+        if m == method {
+            return strings.Contains(line, " HTTP/1.")
+        }
+    }
+    return false
+}
+
+// Add collects the HTTP messages of the packet
+func (c *Collector) Add(packet gopacket.Packet, ts time.Time) {
+    var src, dst net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        src, dst = ipv4.SrcIP, ipv4.DstIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        src, dst = ipv6.SrcIP, ipv6.DstIP
+    }
+    tcpLayer := packet.Layer(layers.LayerTypeTCP)
+    if src == nil || tcpLayer == nil {
+        return
+    }
+    tcp := tcpLayer.(*layers.TCP)
+
+    a := net.JoinHostPort(src.String(), strconv.Itoa(int(tcp.SrcPort)))
+    b := net.JoinHostPort(dst.String(), strconv.Itoa(int(tcp.DstPort)))
+
+    fromClient := true
+    cn := c.conns[a + "|" + b]
+    if cn == nil {
+        if cn = c.conns[b + "|" + a]; cn != nil {
+            fromClient = false
+        }
+    }
+    if cn == nil {
+        if len(tcp.Payload) == 0 || !isRequest(tcp.Payload) {
+            return
+        }
+        cn = &conn{
+            client          : a,
+            server          : b,
+            clientStream    : tcpstream.New(tcp.Seq, maxStreamSize),
+        }
+        cn.requests = &parser{ maxBody: c.maxBody, methods: &cn.methods }
+        cn.responses = &parser{ response: true, maxBody: c.maxBody, methods: &cn.methods }
+        c.conns[a + "|" + b] = cn
+    }
+    if cn.broken {
+        return
+    }
+
+    final := tcp.FIN || tcp.RST
+    if fromClient {
+        if cn.clientStream.Add(tcp.Seq, tcp.Payload) {
+            c.parse(cn, cn.clientStream, cn.requests, false, ts)
+        }
+        return
+    }
+    if cn.serverStream == nil {
+        if len(tcp.Payload) == 0 {
+            return
+        }
+        cn.serverStream = tcpstream.New(tcp.Seq, maxStreamSize)
+    }
+    if cn.serverStream.Add(tcp.Seq, tcp.Payload) || final {
+        c.parse(cn, cn.serverStream, cn.responses, final, ts)
+    }
+}
+
+func (c *Collector) parse(cn *conn, s *tcpstream.Stream, p *parser, final bool, ts time.Time) {
+    n, msgs, err := p.feed(s.Data(), final, ts)
+    s.Skip(n)
+    for _, m := range msgs {
+        if p.response {
+            c.addResponse(cn, m)
+        } else {
+            c.addRequest(cn, m)
+        }
+    }
+    if err != nil {
+        // not HTTP anymore (e.g. upgraded to a websocket)
+        cn.broken = true
+        cn.clientStream.Close()
+        if cn.serverStream != nil {
+            cn.serverStream.Close()
+        }
+    }
+}
+
+func (c *Collector) addRequest(cn *conn, m *message) {
+    parts := strings.SplitN(m.firstLine, " ", 3)
+    if len(parts) < 3 {
+        return
+    }
+    host := m.header.Get("Host")
+    if host == "" {
+        host, _, _ = net.SplitHostPort(cn.server)
+    }
+    url := parts[1]
+    if strings.HasPrefix(url, "/") {
+        url = "http://" + host + url
+    }
+    t := &Transaction{
+        Client      : cn.client,
+        Server      : cn.server,
+        Method      : parts[0],
+        Host        : host,
+        URL         : url,
+        Version     : strings.TrimPrefix(parts[2], "HTTP/"),
+        UserAgent   : m.header.Get("User-Agent"),
+        Referer     : m.header.Get("Referer"),
+        RequestSize : m.size,
+        Time        : m.time,
+    }
+    t.requestObject = c.save(t, m, "request")
+    c.transactions = append(c.transactions, t)
+    cn.waiting = append(cn.waiting, t)
+    cn.methods = append(cn.methods, t.Method)
+}
+
+func (c *Collector) addResponse(cn *conn, m *message) {
+    status := statusCode(m.firstLine)
+    if status / 100 == 1 {
+        // interim response, the final one follows
+        return
+    }
+    if len(cn.waiting) == 0 {
+        return
+    }
+    t := cn.waiting[0]
+    cn.waiting = cn.waiting[1:]
+
+    t.Status = status
+    t.ServerBanner = m.header.Get("Server")
+    t.ContentType = m.header.Get("Content-Type")
+    t.ResponseSize = m.size
+    if t.requestObject != nil {
+        t.requestObject.Status = status
+    }
+    if o := c.save(t, m, "response"); o != nil {
+        t.File = o.File
+    }
+}
+
+// finish parses the responses delimited by the end of the capture
+func (c *Collector) finish() {
+    if c.finished {
+        return
+    }
+    c.finished = true
+    for _, cn := range c.conns {
+        if cn.broken || cn.serverStream == nil {
+            continue
+        }
+        c.parse(cn, cn.serverStream, cn.responses, true, time.Time{})
+        if m := cn.responses.flush(); m != nil {
+            c.addResponse(cn, m)
+        }
+    }
+}
+
+// Transactions returns the transactions found, ordered by time
+func (c *Collector) Transactions() []*Transaction {
+    c.finish()
+    list := append([]*Transaction{}, c.transactions...)
+    sort.SliceStable(list, func(i, j int) bool { return list[i].Time.Before(list[j].Time) })
+    return list
+}
+
+// decodeBody returns the body without its content encoding (gzip and
+// deflate), or as is when it can not be decoded
+func decodeBody(m *message, max int64) []byte {
+    var r io.Reader
+    var err error
+    switch strings.ToLower(strings.TrimSpace(m.header.Get("Content-Encoding"))) {
+    case "gzip", "x-gzip":
+        r, err = gzip.NewReader(bytes.NewReader(m.body))
+    case "deflate":
+        if r, err = zlib.NewReader(bytes.NewReader(m.body)); err != nil {
+            r, err = flate.NewReader(bytes.NewReader(m.body)), nil
+        }
+    default:
+        return m.body
+    }
+    if err != nil {
+        return m.body
+    }
+    data, err := io.ReadAll(io.LimitReader(r, max))
+    if err != nil && len(data) == 0 {
+        return m.body
+    }
+    return data
+}
@@ -0,0 +1,253 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package httpinv
+
+import (
+    "bufio"
+    "bytes"
+    "errors"
+    "net/textproto"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// maxHeaderSize is the biggest header block accepted
+const maxHeaderSize = 64 * 1024
+
+var errMessage = errors.New("invalid HTTP message")
+
+// parser states
+const (
+    stateHeader         = iota
+    stateBody
+    stateChunkSize
+    stateChunkData
+    stateChunkEnd
+    stateTrailer
+    stateUntilClose
+)
+
+// message is a HTTP request or response
+type message struct {
+    firstLine       string
+    header          textproto.MIMEHeader
+    body            []byte
+    // body size on the wire (chunks decoded)
+    size            int64
+    truncated       bool
+    time            time.Time
+}
+
+// parser parses the messages of one direction of a connection as the
+// data arrives
+type parser struct {
+    response        bool
+    state           int
+    remaining       int64
+    msg             *message
+    // biggest body kept by message
+    maxBody         int64
+    // methods of the requests waiting for their response, HEAD responses
+    // have no body
+    methods         *[]string
+}
+
+// feed parses data, returning the bytes consumed and the complete
+// messages. final tells the connection was closed
+func (p *parser) feed(data []byte, final bool, ts time.Time) (int, []*message, error) {
+    consumed := 0
+    done := []*message{}
+    for {
+        buf := data[consumed:]
+        switch p.state {
+        case stateHeader:
+            end := bytes.Index(buf, []byte("\r\n\r\n"))
+            if end < 0 {
+                if len(buf) > maxHeaderSize {
+                    return consumed, done, errMessage
+                }
+                return consumed, done, nil
+            }
+            msg, err := p.parseHeader(buf[:end + 4], ts)
+            if err != nil {
+                return consumed, done, err
+            }
+            consumed += end + 4
+            p.msg = msg
+            if p.startBody() {
+                done = p.complete(done)
+            }
+
+        case stateBody:
+            n := min(int64(len(buf)), p.remaining)
+            p.addBody(buf[:n])
+            consumed += int(n)
+            p.remaining -= n
+            if p.remaining > 0 {
+                return consumed, done, nil
+            }
+            done = p.complete(done)
+
+        case stateChunkSize:
+            end := bytes.Index(buf, []byte("\r\n"))
+            if end < 0 {
+                return consumed, done, nil
+            }
+            line := string(buf[:end])
+            if idx := strings.Index(line, ";"); idx >= 0 {
+                line = line[:idx]
+            }
+            size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+            if err != nil || size < 0 {
+                return consumed, done, errMessage
+            }
+            consumed += end + 2
+            p.remaining = size
+            p.state = stateChunkData
+            if size == 0 {
+                p.state = stateTrailer
+            }
+
+        case stateChunkData:
+            n := min(int64(len(buf)), p.remaining)
+            p.addBody(buf[:n])
+            consumed += int(n)
+            p.remaining -= n
+            if p.remaining > 0 {
+                return consumed, done, nil
+            }
+            p.state = stateChunkEnd
+
+        case stateChunkEnd:
+            if len(buf) < 2 {
+                return consumed, done, nil
+            }
+            consumed += 2
+            p.state = stateChunkSize
+
+        case stateTrailer:
+            end := bytes.Index(buf, []byte("\r\n"))
+            if end < 0 {
+                return consumed, done, nil
+            }
+            consumed += end + 2
+            if end == 0 {
+                done = p.complete(done)
+            }
+
+        case stateUntilClose:
+            p.addBody(buf)
+            consumed += len(buf)
+            if final {
+                done = p.complete(done)
+            }
+            return consumed, done, nil
+        }
+    }
+}
+
+// complete adds the message parsed to done, a final response also takes
+// its request method out of the queue
+func (p *parser) complete(done []*message) []*message {
+    if p.response && statusCode(p.msg.firstLine) / 100 != 1 && len(*p.methods) > 0 {
+        *p.methods = (*p.methods)[1:]
+    }
+    done = append(done, p.msg)
+    p.msg, p.state = nil, stateHeader
+    return done
+}
+
+// flush returns the message being parsed when the connection ends
+func (p *parser) flush() *message {
+    if p.msg == nil {
+        return nil
+    }
+    msg := p.msg
+    if p.state != stateUntilClose {
+        msg.truncated = true
+    }
+    p.complete(nil)
+    return msg
+}
+
+func (p *parser) parseHeader(block []byte, ts time.Time) (*message, error) {
+    r := textproto.NewReader(bufio.NewReader(bytes.NewReader(block)))
+    first, err := r.ReadLine()
+    if err != nil {
+        return nil, errMessage
+    }
+    if p.response != strings.HasPrefix(first, "HTTP/") {
+        return nil, errMessage
+    }
+    header, err := r.ReadMIMEHeader()
+    if err != nil && len(header) == 0 {
+        header = textproto.MIMEHeader{}
+    }
+    return &message{ firstLine: first, header: header, time: ts }, nil
+}
+
+// startBody sets the body state of the message just parsed, returning
+// true when it has no body
+func (p *parser) startBody() bool {
+    h := p.msg.header
+    if p.response {
+        status := statusCode(p.msg.firstLine)
+        method := ""
+        if len(*p.methods) > 0 {
+            method = (*p.methods)[0]
+        }
+        if status / 100 == 1 || status == 204 || status == 304 || method == "HEAD" {
+            return true
+        }
+    }
+
+    if strings.Contains(strings.ToLower(h.Get("Transfer-Encoding")), "chunked") {
+        p.state = stateChunkSize
+        return false
+    }
+    if cl := h.Get("Content-Length"); cl != "" {
+        n, err := strconv.ParseInt(strings.TrimSpace(cl), 10, 64)
+        if err == nil && n > 0 {
+            p.state, p.remaining = stateBody, n
+            return false
+        }
+        return true
+    }
+    if p.response {
+        p.state = stateUntilClose
+        return false
+    }
+    return true
+}
+
+func (p *parser) addBody(data []byte) {
+    p.msg.size += int64(len(data))
+    room := p.maxBody - int64(len(p.msg.body))
+    if room < int64(len(data)) {
+        p.msg.truncated = true
+        data = data[:max(room, 0)]
+    }
+    p.msg.body = append(p.msg.body, data...)
+}
+
+// statusCode returns the status code of a status line
+func statusCode(line string) int {
+    parts := strings.SplitN(line, " ", 3)
+    if len(parts) < 2 {
+        return 0
+    }
+    code, _ := strconv.Atoi(parts[1])
+    return code
+}
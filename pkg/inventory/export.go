@@ -0,0 +1,96 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package inventory
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// CSVHeader lists the columns written by WriteCSV
+var CSVHeader = []string{
+    "ips", "macs", "vendors", "hostnames", "domains", "first_seen",
+    "last_seen", "vlans", "services", "roles", "packets", "os",
+}
+
+// HostnameList returns the host names as "name (source)" strings
+func (h Host) HostnameList() []string {
+    list := []string{}
+    for _, n := range h.Hostnames {
+        list = append(list, fmt.Sprintf("%s (%s)", n.Name, n.Source))
+    }
+    return list
+}
+
+// ServiceList returns the services as strings
+func (h Host) ServiceList() []string {
+    list := []string{}
+    for _, s := range h.Services {
+        list = append(list, s.String())
+    }
+    return list
+}
+
+// VLANList returns the VLAN IDs as strings
+func (h Host) VLANList() []string {
+    list := []string{}
+    for _, v := range h.VLANs {
+        list = append(list, strconv.Itoa(v))
+    }
+    return list
+}
+
+// Hosts is a host list, written as CSV by WriteCSV
+type Hosts []Host
+
+// WriteCSV writes the hosts as CSV
+func (l Hosts) WriteCSV(w io.Writer) error {
+    return WriteCSV(w, l)
+}
+
+// WriteCSV writes the inventory as CSV, multi-valued columns are
+// separated by '; '
+func WriteCSV(w io.Writer, hosts []Host) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(CSVHeader); err != nil {
+        return err
+    }
+
+    for _, h := range hosts {
+        err := cw.Write([]string{
+            strings.Join(h.IPs, "; "),
+            strings.Join(h.MACs, "; "),
+            strings.Join(h.Vendors, "; "),
+            strings.Join(h.HostnameList(), "; "),
+            strings.Join(h.Domains, "; "),
+            h.FirstSeen.UTC().Format(time.RFC3339Nano),
+            h.LastSeen.UTC().Format(time.RFC3339Nano),
+            strings.Join(h.VLANList(), "; "),
+            strings.Join(h.ServiceList(), "; "),
+            strings.Join(h.Roles, "; "),
+            strconv.FormatInt(h.Packets, 10),
+            h.OS,
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    cw.Flush()
+    return cw.Error()
+}
@@ -0,0 +1,679 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package inventory
+
+import (
+    "bytes"
+    "fmt"
+    "net"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/decap"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/helviojunior/pcapraptor/pkg/osfp"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+    "github.com/google/gopacket/macs"
+)
+
+// Host roles
+const (
+    RoleGateway     = "gateway"
+    RoleDNS         = "dns"
+    RoleDHCP        = "dhcp"
+    RoleDC          = "dc"
+)
+
+// NameSourceDHCP is the source of host names taken from DHCP option 12
+const NameSourceDHCP netcalc.NameSource = "dhcp"
+
+// A MAC address sending traffic from this many addresses it is not bound
+// to (by ARP, DHCP or NDP) is considered a router
+const routerThreshold = 3
+
+// Name is a host name and the protocol it was learned from
+type Name struct {
+    Name            string              `json:"name"`
+    Source          string              `json:"source"`
+}
+
+// Service is an open port observed on a host
+type Service struct {
+    Proto           string              `json:"proto"`
+    Port            uint16              `json:"port"`
+    Name            string              `json:"name,omitempty"`
+}
+
+func (s Service) String() string {
+    if s.Name == "" {
+        return fmt.Sprintf("%s/%d", s.Proto, s.Port)
+    }
+    return fmt.Sprintf("%s/%d (%s)", s.Proto, s.Port, s.Name)
+}
+
+// Host is one inventory entry
+type Host struct {
+    IPs             []string            `json:"ips"`
+    MACs            []string            `json:"macs"`
+    Vendors         []string            `json:"vendors"`
+    Hostnames       []Name              `json:"hostnames"`
+    Domains         []string            `json:"domains"`
+    FirstSeen       time.Time           `json:"first_seen"`
+    LastSeen        time.Time           `json:"last_seen"`
+    VLANs           []int               `json:"vlans"`
+    Services        []Service           `json:"services"`
+    Roles           []string            `json:"roles"`
+    Packets         int64               `json:"packets"`
+    OS              string              `json:"os"`
+}
+
+// ipEntry holds everything seen about one address
+type ipEntry struct {
+    ip              net.IP
+    macs            map[string]bool
+    names           map[string]Name
+    domains         map[string]bool
+    vlans           map[int]bool
+    services        map[string]Service
+    roles           map[string]bool
+    first           time.Time
+    last            time.Time
+    packets         int64
+}
+
+// macEntry holds the addresses bound to a MAC address
+type macEntry struct {
+    bound           map[string]bool
+    seen            map[string]bool
+    names           map[string]Name
+}
+
+// Collector builds the host inventory packet by packet
+type Collector struct {
+    header          gopcap.FileHeader
+    linkType        layers.LinkType
+    ips             map[string]*ipEntry
+    macs            map[string]*macEntry
+    fingerprints    *osfp.Collector
+}
+
+// NewCollector returns a collector for the file opened by the given reader
+func NewCollector(r *gopcap.Reader) *Collector {
+    return &Collector{
+        header          : r.Header,
+        linkType        : layers.LinkType(r.Header.Network),
+        ips             : map[string]*ipEntry{},
+        macs            : map[string]*macEntry{},
+        fingerprints    : osfp.NewCollector(),
+    }
+}
+
+// Vendor returns the OUI vendor of a MAC address
+func Vendor(mac net.HardwareAddr) string {
+    if len(mac) < 3 {
+        return ""
+    }
+    if v, ok := macs.ValidMACPrefixMap[[3]byte{ mac[0], mac[1], mac[2] }]; ok {
+        return v
+    }
+    if mac[0] & 0x02 != 0 {
+        return "Locally administered"
+    }
+    return ""
+}
+
+// isHostIP returns false for addresses that cannot identify a host
+func isHostIP(ip net.IP) bool {
+    if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsMulticast() {
+        return false
+    }
+    if ip.Equal(net.IPv4bcast) {
+        return false
+    }
+    return true
+}
+
+func (c *Collector) ip(ip net.IP) *ipEntry {
+    k := ip.String()
+    e, ok := c.ips[k]
+    if !ok {
+        e = &ipEntry{
+            ip          : append(net.IP{}, ip...),
+            macs        : map[string]bool{},
+            names       : map[string]Name{},
+            domains     : map[string]bool{},
+            vlans       : map[int]bool{},
+            services    : map[string]Service{},
+            roles       : map[string]bool{},
+        }
+        c.ips[k] = e
+    }
+    return e
+}
+
+func (c *Collector) mac(mac net.HardwareAddr) *macEntry {
+    k := mac.String()
+    e, ok := c.macs[k]
+    if !ok {
+        e = &macEntry{
+            bound       : map[string]bool{},
+            seen        : map[string]bool{},
+            names       : map[string]Name{},
+        }
+        c.macs[k] = e
+    }
+    return e
+}
+
+// bind records an authoritative MAC to IP binding (ARP, DHCP, NDP)
+func (c *Collector) bind(mac net.HardwareAddr, ip net.IP, ts time.Time) {
+    if len(mac) != 6 || !isHostIP(ip) {
+        return
+    }
+    c.seen(ip, ts)
+    c.mac(mac).bound[ip.String()] = true
+}
+
+// seen updates the first/last seen times of an address
+func (c *Collector) seen(ip net.IP, ts time.Time) *ipEntry {
+    e := c.ip(ip)
+    if e.first.IsZero() || ts.Before(e.first) {
+        e.first = ts
+    }
+    if ts.After(e.last) {
+        e.last = ts
+    }
+    return e
+}
+
+// role sets a role of an address
+func (c *Collector) role(ip net.IP, role string) {
+    if isHostIP(ip) {
+        c.ip(ip).roles[role] = true
+    }
+}
+
+// Add accounts one packet
+func (c *Collector) Add(h gopcap.PacketHeader, data []byte) {
+    packet := decap.NewPacket(data, c.linkType)
+    c.AddPacket(h, packet, netcalc.GetNamesFromPacket(packet))
+}
+
+// AddPacket accounts one decoded packet and the host names found in it,
+// callers sharing the packet with the subnet inference pass the names of
+// netcalc.GetEvidenceFromPacket so they are parsed once
+func (c *Collector) AddPacket(h gopcap.PacketHeader, packet gopacket.Packet, names []netcalc.HostName) {
+    ts := c.header.PacketTime(h)
+
+    var srcMAC net.HardwareAddr
+    if ethLayer := packet.Layer(layers.LayerTypeEthernet); ethLayer != nil {
+        srcMAC = ethLayer.(*layers.Ethernet).SrcMAC
+    }
+
+    vlans := []int{}
+    for _, l := range packet.Layers() {
+        if q, ok := l.(*layers.Dot1Q); ok {
+            vlans = append(vlans, int(q.VLANIdentifier))
+        }
+    }
+
+    if arpLayer := packet.Layer(layers.LayerTypeARP); arpLayer != nil {
+        arp := arpLayer.(*layers.ARP)
+        if arp.Protocol == layers.EthernetTypeIPv4 && len(arp.SourceProtAddress) == 4 {
+            c.bind(arp.SourceHwAddress, net.IP(arp.SourceProtAddress), ts)
+            c.addVLANs(net.IP(arp.SourceProtAddress), vlans)
+        }
+    }
+
+    var srcIP, dstIP net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        srcIP, dstIP = ipv4.SrcIP, ipv4.DstIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        srcIP, dstIP = ipv6.SrcIP, ipv6.DstIP
+    }
+    if srcIP == nil {
+        return
+    }
+
+    if isHostIP(srcIP) {
+        e := c.seen(srcIP, ts)
+        e.packets++
+        if len(srcMAC) == 6 {
+            e.macs[srcMAC.String()] = true
+            c.mac(srcMAC).seen[srcIP.String()] = true
+        }
+        c.addVLANs(srcIP, vlans)
+    }
+    if isHostIP(dstIP) {
+        if e, ok := c.ips[dstIP.String()]; ok {
+            e.packets++
+        }
+    }
+
+    c.addServices(packet, srcIP)
+    c.addDHCP(packet, srcIP, ts)
+    c.addNDP(packet, srcIP, ts)
+    c.fingerprints.Add(packet)
+
+    for _, n := range names {
+        if !isHostIP(n.IP) {
+            continue
+        }
+        switch n.Type {
+        case netcalc.NameHost:
+            e := c.ip(n.IP)
+            e.names[strings.ToLower(n.Name)] = Name{ Name: n.Name, Source: string(n.Source) }
+        case netcalc.NameDomain:
+            e := c.ip(n.IP)
+            e.domains[strings.ToUpper(n.Name)] = true
+            if strings.HasPrefix(n.Info, "<1C>") || strings.HasPrefix(n.Info, "<1B>") {
+                e.roles[RoleDC] = true
+            }
+        }
+    }
+}
+
+func (c *Collector) addVLANs(ip net.IP, vlans []int) {
+    if len(vlans) == 0 || !isHostIP(ip) {
+        return
+    }
+    e := c.ip(ip)
+    for _, v := range vlans {
+        e.vlans[v] = true
+    }
+}
+
+// addServices records the services answered by the source address
+func (c *Collector) addServices(packet gopacket.Packet, srcIP net.IP) {
+    if !isHostIP(srcIP) {
+        return
+    }
+
+    if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+        tcp := tcpLayer.(*layers.TCP)
+        if tcp.SYN && tcp.ACK {
+            c.addService(srcIP, "tcp", uint16(tcp.SrcPort))
+        }
+        return
+    }
+
+    udpLayer := packet.Layer(layers.LayerTypeUDP)
+    if udpLayer == nil {
+        return
+    }
+    udp := udpLayer.(*layers.UDP)
+    port := uint16(udp.SrcPort)
+    if _, ok := udpServices[port]; !ok {
+        return
+    }
+
+    switch port {
+    case 53:
+        if dnsLayer := packet.Layer(layers.LayerTypeDNS); dnsLayer == nil || !dnsLayer.(*layers.DNS).QR {
+            return
+        }
+        c.role(srcIP, RoleDNS)
+    case 123:
+        if ntpLayer := packet.Layer(layers.LayerTypeNTP); ntpLayer == nil || ntpLayer.(*layers.NTP).Mode != 4 {
+            return
+        }
+    case 67, 547:
+        // handled by addDHCP
+        return
+    default:
+        if udp.DstPort < 1024 && udp.DstPort != udp.SrcPort {
+            return
+        }
+    }
+    c.addService(srcIP, "udp", port)
+}
+
+func (c *Collector) addService(ip net.IP, proto string, port uint16) {
+    name := ServiceName(proto, port)
+    e := c.ip(ip)
+    e.services[fmt.Sprintf("%s/%d", proto, port)] = Service{ Proto: proto, Port: port, Name: name }
+
+    switch {
+    case port == 88 || port == 3268 || port == 3269:
+        e.roles[RoleDC] = true
+    case port == 53:
+        e.roles[RoleDNS] = true
+    }
+}
+
+// addDHCP records DHCP bindings, host names, servers and routers
+func (c *Collector) addDHCP(packet gopacket.Packet, srcIP net.IP, ts time.Time) {
+    if dhcpLayer := packet.Layer(layers.LayerTypeDHCPv4); dhcpLayer != nil {
+        dhcp := dhcpLayer.(*layers.DHCPv4)
+        if len(dhcp.ClientHWAddr) != 6 {
+            return
+        }
+
+        var msgType layers.DHCPMsgType
+        var hostname string
+        requested := dhcp.ClientIP
+        for _, o := range dhcp.Options {
+            switch o.Type {
+            case layers.DHCPOptMessageType:
+                if len(o.Data) == 1 {
+                    msgType = layers.DHCPMsgType(o.Data[0])
+                }
+            case layers.DHCPOptHostname:
+                hostname = strings.TrimRight(string(o.Data), "\x00")
+            case layers.DHCPOptRequestIP:
+                if len(o.Data) == 4 {
+                    requested = net.IP(o.Data)
+                }
+            }
+        }
+
+        if hostname != "" {
+            c.mac(dhcp.ClientHWAddr).names[strings.ToLower(hostname)] = Name{ Name: hostname, Source: string(NameSourceDHCP) }
+        }
+
+        if dhcp.Operation == layers.DHCPOpRequest {
+            if msgType == layers.DHCPMsgTypeRequest || msgType == layers.DHCPMsgTypeInform {
+                c.bind(dhcp.ClientHWAddr, requested, ts)
+            }
+            return
+        }
+
+        c.role(srcIP, RoleDHCP)
+        c.addService(srcIP, "udp", 67)
+        for _, o := range dhcp.Options {
+            switch o.Type {
+            case layers.DHCPOptServerID:
+                if len(o.Data) == 4 {
+                    c.role(net.IP(o.Data), RoleDHCP)
+                }
+            case layers.DHCPOptRouter:
+                for i := 0; i + 4 <= len(o.Data); i += 4 {
+                    c.role(net.IP(o.Data[i:i+4]), RoleGateway)
+                }
+            case layers.DHCPOptDNS:
+                for i := 0; i + 4 <= len(o.Data); i += 4 {
+                    c.role(net.IP(o.Data[i:i+4]), RoleDNS)
+                }
+            }
+        }
+        if msgType == layers.DHCPMsgTypeAck {
+            c.bind(dhcp.ClientHWAddr, dhcp.YourClientIP, ts)
+        }
+        return
+    }
+
+    if dhcpLayer := packet.Layer(layers.LayerTypeDHCPv6); dhcpLayer != nil {
+        dhcp := dhcpLayer.(*layers.DHCPv6)
+        if dhcp.MsgType == layers.DHCPv6MsgTypeAdverstise || dhcp.MsgType == layers.DHCPv6MsgTypeReply {
+            c.role(srcIP, RoleDHCP)
+            c.addService(srcIP, "udp", 547)
+        }
+    }
+}
+
+// addNDP records IPv6 neighbor bindings and routers
+func (c *Collector) addNDP(packet gopacket.Packet, srcIP net.IP, ts time.Time) {
+    if l := packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement); l != nil {
+        na := l.(*layers.ICMPv6NeighborAdvertisement)
+        for _, o := range na.Options {
+            if o.Type == layers.ICMPv6OptTargetAddress {
+                c.bind(net.HardwareAddr(o.Data), na.TargetAddress, ts)
+            }
+        }
+        if na.Router() {
+            c.role(na.TargetAddress, RoleGateway)
+        }
+    }
+
+    if l := packet.Layer(layers.LayerTypeICMPv6NeighborSolicitation); l != nil {
+        ns := l.(*layers.ICMPv6NeighborSolicitation)
+        for _, o := range ns.Options {
+            if o.Type == layers.ICMPv6OptSourceAddress {
+                c.bind(net.HardwareAddr(o.Data), srcIP, ts)
+            }
+        }
+    }
+
+    if l := packet.Layer(layers.LayerTypeICMPv6RouterAdvertisement); l != nil {
+        ra := l.(*layers.ICMPv6RouterAdvertisement)
+        for _, o := range ra.Options {
+            if o.Type == layers.ICMPv6OptSourceAddress {
+                c.bind(net.HardwareAddr(o.Data), srcIP, ts)
+            }
+        }
+        c.role(srcIP, RoleGateway)
+    }
+}
+
+// Hosts returns the inventory. Addresses bound to the same MAC address
+// are merged into one host, timestamps are corrected by shift (if set).
+func (c *Collector) Hosts(shift *time.Duration) []Host {
+
+    // routers forward traffic of addresses not bound to their MAC
+    routers := map[string]bool{}
+    for m, me := range c.macs {
+        foreign4, foreign6 := 0, 0
+        for ip := range me.seen {
+            if me.bound[ip] {
+                continue
+            }
+            if a := net.ParseIP(ip); a.To4() != nil {
+                foreign4++
+            } else if !a.IsLinkLocalUnicast() {
+                foreign6++
+            }
+        }
+        if foreign4 >= routerThreshold || foreign6 >= routerThreshold {
+            routers[m] = true
+            for ip := range me.bound {
+                c.ips[ip].roles[RoleGateway] = true
+            }
+        }
+    }
+
+    // union of the addresses of the same MAC (only the bound ones
+    // for routers)
+    parent := map[string]string{}
+    var find func(string) string
+    find = func(k string) string {
+        if p, ok := parent[k]; ok && p != k {
+            parent[k] = find(p)
+            return parent[k]
+        }
+        return k
+    }
+    union := func(ips map[string]bool) {
+        first := ""
+        for ip := range ips {
+            if first == "" {
+                first = find(ip)
+                continue
+            }
+            if r := find(ip); r != first {
+                parent[r] = first
+            }
+        }
+    }
+    for m, me := range c.macs {
+        all := map[string]bool{}
+        for ip := range me.bound {
+            all[ip] = true
+        }
+        if !routers[m] {
+            for ip := range me.seen {
+                all[ip] = true
+            }
+        }
+        union(all)
+    }
+
+    groups := map[string][]*ipEntry{}
+    for k, e := range c.ips {
+        r := find(k)
+        groups[r] = append(groups[r], e)
+    }
+
+    hosts := []Host{}
+    for _, entries := range groups {
+        h := Host{
+            IPs         : []string{},
+            MACs        : []string{},
+            Vendors     : []string{},
+            Hostnames   : []Name{},
+            Domains     : []string{},
+            VLANs       : []int{},
+            Services    : []Service{},
+            Roles       : []string{},
+        }
+
+        sort.Slice(entries, func(i, j int) bool {
+            return compareIP(entries[i].ip, entries[j].ip) < 0
+        })
+
+        macSet := map[string]bool{}
+        names := map[string]Name{}
+        domains := map[string]bool{}
+        vlans := map[int]bool{}
+        services := map[string]Service{}
+        roles := map[string]bool{}
+
+        for _, e := range entries {
+            h.IPs = append(h.IPs, e.ip.String())
+            h.Packets += e.packets
+            if !e.first.IsZero() && (h.FirstSeen.IsZero() || e.first.Before(h.FirstSeen)) {
+                h.FirstSeen = e.first
+            }
+            if e.last.After(h.LastSeen) {
+                h.LastSeen = e.last
+            }
+            for m := range e.macs {
+                me := c.macs[m]
+                // routed addresses are seen behind the router MAC
+                if routers[m] && !me.bound[e.ip.String()] {
+                    continue
+                }
+                macSet[m] = true
+            }
+            for m, me := range c.macs {
+                if me.bound[e.ip.String()] {
+                    macSet[m] = true
+                }
+            }
+            for k, n := range e.names {
+                names[k] = n
+            }
+            for d := range e.domains {
+                domains[d] = true
+            }
+            for v := range e.vlans {
+                vlans[v] = true
+            }
+            for k, s := range e.services {
+                services[k] = s
+            }
+            for r := range e.roles {
+                roles[r] = true
+            }
+        }
+
+        for m := range macSet {
+            h.MACs = append(h.MACs, m)
+            for k, n := range c.macs[m].names {
+                if _, ok := names[k]; !ok {
+                    names[k] = n
+                }
+            }
+        }
+        sort.Strings(h.MACs)
+        for _, m := range h.MACs {
+            hw, _ := net.ParseMAC(m)
+            if v := Vendor(hw); v != "" && !contains(h.Vendors, v) {
+                h.Vendors = append(h.Vendors, v)
+            }
+        }
+
+        for _, n := range names {
+            h.Hostnames = append(h.Hostnames, n)
+        }
+        sort.Slice(h.Hostnames, func(i, j int) bool {
+            if h.Hostnames[i].Name == h.Hostnames[j].Name {
+                return h.Hostnames[i].Source < h.Hostnames[j].Source
+            }
+            return h.Hostnames[i].Name < h.Hostnames[j].Name
+        })
+        for d := range domains {
+            h.Domains = append(h.Domains, d)
+        }
+        sort.Strings(h.Domains)
+        for v := range vlans {
+            h.VLANs = append(h.VLANs, v)
+        }
+        sort.Ints(h.VLANs)
+        for _, s := range services {
+            h.Services = append(h.Services, s)
+        }
+        sort.Slice(h.Services, func(i, j int) bool {
+            if h.Services[i].Proto == h.Services[j].Proto {
+                return h.Services[i].Port < h.Services[j].Port
+            }
+            return h.Services[i].Proto < h.Services[j].Proto
+        })
+        for r := range roles {
+            h.Roles = append(h.Roles, r)
+        }
+        sort.Strings(h.Roles)
+        h.OS = c.fingerprints.Guess(append(append([]string{}, h.IPs...), h.MACs...)...)
+
+        if shift != nil {
+            h.FirstSeen = h.FirstSeen.Add(*shift)
+            h.LastSeen = h.LastSeen.Add(*shift)
+        }
+
+        hosts = append(hosts, h)
+    }
+
+    sort.Slice(hosts, func(i, j int) bool {
+        return compareIP(net.ParseIP(hosts[i].IPs[0]), net.ParseIP(hosts[j].IPs[0])) < 0
+    })
+
+    return hosts
+}
+
+// compareIP sorts IPv4 addresses before IPv6 ones
+func compareIP(a, b net.IP) int {
+    a4, b4 := a.To4(), b.To4()
+    switch {
+    case a4 != nil && b4 == nil:
+        return -1
+    case a4 == nil && b4 != nil:
+        return 1
+    case a4 != nil:
+        return bytes.Compare(a4, b4)
+    }
+    return bytes.Compare(a.To16(), b.To16())
+}
+
+func contains(list []string, s string) bool {
+    for _, i := range list {
+        if i == s {
+            return true
+        }
+    }
+    return false
+}
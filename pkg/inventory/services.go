@@ -0,0 +1,82 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package inventory
+
+// Well known TCP services
+var tcpServices = map[uint16]string{
+    21      : "ftp",
+    22      : "ssh",
+    23      : "telnet",
+    25      : "smtp",
+    53      : "dns",
+    80      : "http",
+    88      : "kerberos",
+    110     : "pop3",
+    111     : "rpcbind",
+    135     : "msrpc",
+    139     : "netbios-ssn",
+    143     : "imap",
+    389     : "ldap",
+    443     : "https",
+    445     : "microsoft-ds",
+    464     : "kpasswd",
+    465     : "smtps",
+    587     : "submission",
+    631     : "ipp",
+    636     : "ldaps",
+    993     : "imaps",
+    995     : "pop3s",
+    1433    : "mssql",
+    1521    : "oracle",
+    2049    : "nfs",
+    3268    : "globalcatalog",
+    3269    : "globalcatalog-ssl",
+    3306    : "mysql",
+    3389    : "rdp",
+    5432    : "postgresql",
+    5900    : "vnc",
+    5985    : "winrm",
+    5986    : "winrm-ssl",
+    8080    : "http-alt",
+    8443    : "https-alt",
+    9100    : "jetdirect",
+}
+
+// Well known UDP services
+var udpServices = map[uint16]string{
+    53      : "dns",
+    67      : "dhcp",
+    69      : "tftp",
+    88      : "kerberos",
+    123     : "ntp",
+    161     : "snmp",
+    389     : "cldap",
+    464     : "kpasswd",
+    500     : "isakmp",
+    514     : "syslog",
+    547     : "dhcpv6",
+    1812    : "radius",
+    1900    : "ssdp",
+    4500    : "ipsec-nat-t",
+    5060    : "sip",
+}
+
+// ServiceName returns the well known name of the tcp or udp port, or an
+// empty string
+func ServiceName(proto string, port uint16) string {
+    if proto == "udp" {
+        return udpServices[port]
+    }
+    return tcpServices[port]
+}
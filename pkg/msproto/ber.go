@@ -0,0 +1,147 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package msproto
+
+import (
+    "errors"
+)
+
+// BER classes
+const (
+    classUniversal      = 0
+    classApplication    = 1
+    classContext        = 2
+)
+
+// BER universal SEQUENCE tag
+const tagSequence = 16
+
+var errBER = errors.New("invalid BER data")
+
+// berValue is a BER (and DER) encoded element. Kerberos, LDAP and SPNEGO
+// are all BER encoded, and LDAP servers (Active Directory) use non
+// minimal lengths that encoding/asn1 refuses, so a tiny reader is used
+type berValue struct {
+    Class       int
+    Tag         int
+    Compound    bool
+    Data        []byte
+}
+
+// readBER reads the element at the start of data, returning it and the
+// data after it. Only definite lengths are supported
+func readBER(data []byte) (berValue, []byte, error) {
+    var v berValue
+
+    if len(data) < 2 {
+        return v, nil, errBER
+    }
+    v.Class = int(data[0] >> 6)
+    v.Compound = data[0] & 0x20 != 0
+    v.Tag = int(data[0] & 0x1f)
+    p := 1
+    if v.Tag == 0x1f {
+        v.Tag = 0
+        for {
+            if p >= len(data) || p > 4 {
+                return v, nil, errBER
+            }
+            b := data[p]
+            p++
+            v.Tag = v.Tag << 7 | int(b & 0x7f)
+            if b & 0x80 == 0 {
+                break
+            }
+        }
+    }
+
+    if p >= len(data) {
+        return v, nil, errBER
+    }
+    size := int(data[p])
+    p++
+    if size & 0x80 != 0 {
+        n := size & 0x7f
+        if n == 0 || n > 4 || p + n > len(data) {
+            return v, nil, errBER
+        }
+        size = 0
+        for i := 0; i < n; i++ {
+            size = size << 8 | int(data[p + i])
+        }
+        p += n
+    }
+    if size < 0 || p + size > len(data) {
+        return v, nil, errBER
+    }
+
+    v.Data = data[p:p + size]
+    return v, data[p + size:], nil
+}
+
+// children returns the elements of a compound element
+func (v berValue) children() ([]berValue, error) {
+    list := []berValue{}
+    data := v.Data
+    for len(data) > 0 {
+        c, rest, err := readBER(data)
+        if err != nil {
+            return nil, err
+        }
+        list = append(list, c)
+        data = rest
+    }
+    return list, nil
+}
+
+// fields returns the explicitly tagged ([n]) fields of a SEQUENCE, with
+// the element inside each tag
+func (v berValue) fields() (map[int]berValue, error) {
+    if v.Class != classUniversal || v.Tag != tagSequence {
+        return nil, errBER
+    }
+    list, err := v.children()
+    if err != nil {
+        return nil, err
+    }
+    fields := map[int]berValue{}
+    for _, c := range list {
+        if c.Class != classContext {
+            continue
+        }
+        inner, _, err := readBER(c.Data)
+        if err != nil {
+            return nil, err
+        }
+        fields[c.Tag] = inner
+    }
+    return fields, nil
+}
+
+// int returns the value of an INTEGER or ENUMERATED element
+func (v berValue) int() int64 {
+    var n int64
+    for i, b := range v.Data {
+        if i == 0 && b & 0x80 != 0 {
+            n = -1
+        }
+        n = n << 8 | int64(b)
+    }
+    return n
+}
+
+// str returns the content of a string element
+func (v berValue) str() string {
+    return string(v.Data)
+}
@@ -0,0 +1,228 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package msproto
+
+import (
+    "encoding/binary"
+    "fmt"
+    "strings"
+)
+
+// Kerberos message types
+const (
+    KrbASReq            = 10
+    KrbASRep            = 11
+    KrbTGSReq           = 12
+    KrbTGSRep           = 13
+    KrbError            = 30
+)
+
+// Kerberos error codes
+const (
+    KrbErrPrincipalUnknown  = 6
+    KrbErrClientRevoked     = 18
+    KrbErrKeyExpired        = 23
+    KrbErrPreauthFailed     = 24
+    KrbErrPreauthRequired   = 25
+)
+
+// paEncTimestamp is the PA-DATA type of the encrypted timestamp sent to
+// pre-authenticate AS-REQs
+const paEncTimestamp = 2
+
+// KerberosPort is the KDC port
+const KerberosPort = 88
+
+// EncryptedData is a Kerberos encrypted blob
+type EncryptedData struct {
+    EType           int
+    Cipher          []byte
+}
+
+// KerberosMessage is the clear text part of a Kerberos message
+type KerberosMessage struct {
+    Type            int
+    // request realm, client realm of replies or server realm of errors
+    Realm           string
+    // client principal (AS-REQ, replies and errors)
+    CName           []string
+    // server principal (requests, ticket of replies and errors)
+    SName           []string
+    // AS-REQ with an encrypted timestamp
+    PreAuth         *EncryptedData
+    // enc-part of AS-REP and TGS-REP
+    EncPart         *EncryptedData
+    // enc-part of the ticket of AS-REP and TGS-REP, encrypted with the
+    // service key
+    Ticket          *EncryptedData
+    ErrorCode       int
+}
+
+// Client returns the client principal name
+func (m *KerberosMessage) Client() string {
+    return strings.Join(m.CName, "/")
+}
+
+// Service returns the server principal name
+func (m *KerberosMessage) Service() string {
+    return strings.Join(m.SName, "/")
+}
+
+// ParseKerberos decodes a Kerberos message, TCP messages must be given
+// without the record mark (see SplitKerberosTCP)
+func ParseKerberos(data []byte) (*KerberosMessage, error) {
+    app, _, err := readBER(data)
+    if err != nil {
+        return nil, err
+    }
+    if app.Class != classApplication || !app.Compound {
+        return nil, errBER
+    }
+    seq, _, err := readBER(app.Data)
+    if err != nil {
+        return nil, err
+    }
+    fields, err := seq.fields()
+    if err != nil {
+        return nil, err
+    }
+
+    m := &KerberosMessage{ Type: app.Tag }
+    switch app.Tag {
+    case KrbASReq, KrbTGSReq:
+        // KDC-REQ: pvno[1], msg-type[2], padata[3], req-body[4]
+        if padata, ok := fields[3]; ok {
+            list, err := padata.children()
+            if err != nil {
+                return nil, err
+            }
+            for _, pa := range list {
+                pf, err := pa.fields()
+                if err != nil {
+                    return nil, err
+                }
+                if pf[1].int() == paEncTimestamp {
+                    enc, _, err := readBER(pf[2].Data)
+                    if err != nil {
+                        return nil, err
+                    }
+                    if m.PreAuth, err = parseEncryptedData(enc); err != nil {
+                        return nil, err
+                    }
+                }
+            }
+        }
+        body, ok := fields[4]
+        if !ok {
+            return nil, errBER
+        }
+        // KDC-REQ-BODY: kdc-options[0], cname[1], realm[2], sname[3]
+        bf, err := body.fields()
+        if err != nil {
+            return nil, err
+        }
+        if cname, ok := bf[1]; ok {
+            m.CName = parsePrincipal(cname)
+        }
+        m.Realm = bf[2].str()
+        if sname, ok := bf[3]; ok {
+            m.SName = parsePrincipal(sname)
+        }
+
+    case KrbASRep, KrbTGSRep:
+        // KDC-REP: pvno[0], msg-type[1], padata[2], crealm[3], cname[4],
+        // ticket[5], enc-part[6]
+        m.Realm = fields[3].str()
+        m.CName = parsePrincipal(fields[4])
+        if ticket, ok := fields[5]; ok {
+            // Ticket: [APPLICATION 1] tkt-vno[0], realm[1], sname[2], enc-part[3]
+            if tseq, _, err := readBER(ticket.Data); err == nil {
+                if tf, err := tseq.fields(); err == nil {
+                    m.SName = parsePrincipal(tf[2])
+                    if enc, ok := tf[3]; ok {
+                        m.Ticket, _ = parseEncryptedData(enc)
+                    }
+                }
+            }
+        }
+        if enc, ok := fields[6]; ok {
+            if m.EncPart, err = parseEncryptedData(enc); err != nil {
+                return nil, err
+            }
+        }
+
+    case KrbError:
+        // KRB-ERROR: pvno[0], msg-type[1], ctime[2], cusec[3], stime[4],
+        // susec[5], error-code[6], crealm[7], cname[8], realm[9], sname[10]
+        m.ErrorCode = int(fields[6].int())
+        m.Realm = fields[9].str()
+        if crealm, ok := fields[7]; ok {
+            m.Realm = crealm.str()
+        }
+        if cname, ok := fields[8]; ok {
+            m.CName = parsePrincipal(cname)
+        }
+        m.SName = parsePrincipal(fields[10])
+
+    default:
+        return nil, fmt.Errorf("unsupported kerberos message (%d)", app.Tag)
+    }
+
+    return m, nil
+}
+
+// parsePrincipal decodes a PrincipalName: name-type[0], name-string[1]
+func parsePrincipal(v berValue) []string {
+    names := []string{}
+    f, err := v.fields()
+    if err != nil {
+        return names
+    }
+    list, err := f[1].children()
+    if err != nil {
+        return names
+    }
+    for _, n := range list {
+        names = append(names, n.str())
+    }
+    return names
+}
+
+// parseEncryptedData decodes an EncryptedData: etype[0], kvno[1], cipher[2]
+func parseEncryptedData(v berValue) (*EncryptedData, error) {
+    f, err := v.fields()
+    if err != nil {
+        return nil, err
+    }
+    cipher, ok := f[2]
+    if !ok {
+        return nil, errBER
+    }
+    return &EncryptedData{ EType: int(f[0].int()), Cipher: cipher.Data }, nil
+}
+
+// SplitKerberosTCP returns the messages of a TCP segment, each one is
+// prefixed by its 4 bytes length (record mark)
+func SplitKerberosTCP(data []byte) [][]byte {
+    list := [][]byte{}
+    for len(data) > 4 {
+        size := int(binary.BigEndian.Uint32(data[0:4]) & 0x7fffffff)
+        if size == 0 || len(data) < 4 + size {
+            break
+        }
+        list = append(list, data[4:4 + size])
+        data = data[4 + size:]
+    }
+    return list
+}
@@ -0,0 +1,226 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package msproto
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// LDAP ports, CLDAP (netlogon ping) uses UDP 389
+const (
+    LDAPPort            = 389
+    GlobalCatalogPort   = 3268
+)
+
+// ldapSearchResEntry is the protocolOp tag of search result entries
+const ldapSearchResEntry = 4
+
+// LDAPEntry is a search result entry, attribute names are lower case
+type LDAPEntry struct {
+    DN              string
+    Attributes      map[string][][]byte
+}
+
+// Value returns the first value of the attribute
+func (e *LDAPEntry) Value(name string) string {
+    if v := e.Attributes[strings.ToLower(name)]; len(v) > 0 {
+        return string(v[0])
+    }
+    return ""
+}
+
+// ParseLDAPEntries returns the search result entries of the LDAP messages
+// found at data (a CLDAP datagram or a LDAP TCP segment)
+func ParseLDAPEntries(data []byte) []*LDAPEntry {
+    list := []*LDAPEntry{}
+    for len(data) > 0 {
+        // LDAPMessage: messageID, protocolOp, controls
+        msg, rest, err := readBER(data)
+        if err != nil || msg.Class != classUniversal || msg.Tag != tagSequence {
+            return list
+        }
+        data = rest
+
+        items, err := msg.children()
+        if err != nil || len(items) < 2 {
+            continue
+        }
+        op := items[1]
+        if op.Class != classApplication || op.Tag != ldapSearchResEntry {
+            continue
+        }
+        // SearchResultEntry: objectName, attributes
+        parts, err := op.children()
+        if err != nil || len(parts) < 2 {
+            continue
+        }
+        entry := &LDAPEntry{ DN: parts[0].str(), Attributes: map[string][][]byte{} }
+        attrs, err := parts[1].children()
+        if err != nil {
+            continue
+        }
+        for _, a := range attrs {
+            // PartialAttribute: type, vals
+            av, err := a.children()
+            if err != nil || len(av) < 2 {
+                continue
+            }
+            vals, err := av[1].children()
+            if err != nil {
+                continue
+            }
+            name := strings.ToLower(av[0].str())
+            for _, v := range vals {
+                entry.Attributes[name] = append(entry.Attributes[name], v.Data)
+            }
+        }
+        list = append(list, entry)
+    }
+    return list
+}
+
+// DNToDomain converts a naming context (DC=corp,DC=local) to its DNS
+// domain name (corp.local)
+func DNToDomain(dn string) string {
+    labels := []string{}
+    for _, rdn := range strings.Split(dn, ",") {
+        kv := strings.SplitN(strings.TrimSpace(rdn), "=", 2)
+        if len(kv) == 2 && strings.EqualFold(kv[0], "dc") {
+            labels = append(labels, strings.ToLower(kv[1]))
+        }
+    }
+    return strings.Join(labels, ".")
+}
+
+// Netlogon response opcodes
+const (
+    netlogonResponseEx      = 23
+    netlogonUserUnknownEx   = 25
+)
+
+var errNetlogon = errors.New("invalid netlogon response")
+
+// Netlogon flags of the domain controller
+var netlogonFlags = []struct {
+    flag    uint32
+    role    string
+}{
+    { 0x00000001, "pdc" },
+    { 0x00000004, "gc" },
+    { 0x00000008, "ldap" },
+    { 0x00000010, "ds" },
+    { 0x00000020, "kdc" },
+    { 0x00000040, "timeserv" },
+    { 0x00000100, "writable" },
+}
+
+// Netlogon is the NETLOGON_SAM_LOGON_RESPONSE_EX returned by domain
+// controllers to CLDAP netlogon pings
+type Netlogon struct {
+    Flags           uint32
+    DomainGUID      string
+    Forest          string
+    Domain          string
+    HostName        string
+    NetBIOSDomain   string
+    NetBIOSComputer string
+    UserName        string
+    DCSite          string
+    ClientSite      string
+}
+
+// Roles returns the domain controller roles set at the flags
+func (n *Netlogon) Roles() []string {
+    roles := []string{}
+    for _, f := range netlogonFlags {
+        if n.Flags & f.flag != 0 {
+            roles = append(roles, f.role)
+        }
+    }
+    return roles
+}
+
+// ParseNetlogon decodes the netlogon attribute of a CLDAP response
+func ParseNetlogon(data []byte) (*Netlogon, error) {
+    if len(data) < 24 {
+        return nil, errNetlogon
+    }
+    opcode := binary.LittleEndian.Uint16(data[0:2])
+    if opcode != netlogonResponseEx && opcode != netlogonUserUnknownEx {
+        return nil, errNetlogon
+    }
+
+    g := data[8:24]
+    n := &Netlogon{
+        Flags       : binary.LittleEndian.Uint32(data[4:8]),
+        DomainGUID  : fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+            binary.LittleEndian.Uint32(g[0:4]), binary.LittleEndian.Uint16(g[4:6]),
+            binary.LittleEndian.Uint16(g[6:8]), g[8:10], g[10:16]),
+    }
+
+    pos := 24
+    for _, field := range []*string{
+        &n.Forest, &n.Domain, &n.HostName, &n.NetBIOSDomain, &n.NetBIOSComputer,
+        &n.UserName, &n.DCSite, &n.ClientSite,
+    } {
+        name, next, err := readCompressedName(data, pos)
+        if err != nil {
+            return nil, err
+        }
+        *field = name
+        pos = next
+    }
+
+    return n, nil
+}
+
+// readCompressedName reads a DNS style compressed name at pos, pointers
+// are relative to the start of data
+func readCompressedName(data []byte, pos int) (string, int, error) {
+    labels := []string{}
+    next := -1
+    for jumps := 0; ; {
+        if pos >= len(data) {
+            return "", 0, errNetlogon
+        }
+        size := int(data[pos])
+        if size == 0 {
+            pos++
+            break
+        }
+        if size & 0xc0 == 0xc0 {
+            if pos + 1 >= len(data) || jumps > 16 {
+                return "", 0, errNetlogon
+            }
+            if next < 0 {
+                next = pos + 2
+            }
+            pos = int(binary.BigEndian.Uint16(data[pos:pos + 2]) & 0x3fff)
+            jumps++
+            continue
+        }
+        if pos + 1 + size > len(data) {
+            return "", 0, errNetlogon
+        }
+        labels = append(labels, string(data[pos + 1:pos + 1 + size]))
+        pos += 1 + size
+    }
+    if next < 0 {
+        next = pos
+    }
+    return strings.Join(labels, "."), next, nil
+}
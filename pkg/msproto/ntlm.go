@@ -0,0 +1,177 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package msproto
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "unicode/utf16"
+)
+
+// NTLM message types
+const (
+    NTLMNegotiate       = 1
+    NTLMChallenge       = 2
+    NTLMAuthenticate    = 3
+)
+
+const ntlmUnicode = 0x00000001
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+var errNTLM = errors.New("invalid NTLMSSP message")
+
+// NTLM AV_PAIR ids of the challenge target info
+const (
+    avEOL               = 0
+    avNbComputerName    = 1
+    avNbDomainName      = 2
+    avDnsComputerName   = 3
+    avDnsDomainName     = 4
+    avDnsTreeName       = 5
+)
+
+// NTLMMessage is a NTLMSSP message. Challenge messages describe the
+// server, authenticate messages the client and its responses
+type NTLMMessage struct {
+    Type                int
+    Flags               uint32
+
+    // CHALLENGE
+    ServerChallenge     []byte
+    TargetName          string
+    NbComputerName      string
+    NbDomainName        string
+    DnsComputerName     string
+    DnsDomainName       string
+    // forest
+    DnsTreeName         string
+
+    // AUTHENTICATE
+    LmResponse          []byte
+    NtResponse          []byte
+    Domain              string
+    User                string
+    Workstation         string
+}
+
+// FindNTLM returns the NTLMSSP messages found at the payload, as they
+// are carried as is by SMB, LDAP, DCE/RPC and SPNEGO blobs
+func FindNTLM(payload []byte) []*NTLMMessage {
+    list := []*NTLMMessage{}
+    for {
+        idx := bytes.Index(payload, ntlmSignature)
+        if idx < 0 {
+            return list
+        }
+        payload = payload[idx:]
+        if m, err := ParseNTLM(payload); err == nil {
+            list = append(list, m)
+        }
+        payload = payload[len(ntlmSignature):]
+    }
+}
+
+// ParseNTLM decodes the NTLMSSP message at the start of data
+func ParseNTLM(data []byte) (*NTLMMessage, error) {
+    if len(data) < 12 || !bytes.Equal(data[0:8], ntlmSignature) {
+        return nil, errNTLM
+    }
+    m := &NTLMMessage{ Type: int(binary.LittleEndian.Uint32(data[8:12])) }
+
+    switch m.Type {
+    case NTLMNegotiate:
+        if len(data) < 16 {
+            return nil, errNTLM
+        }
+        m.Flags = binary.LittleEndian.Uint32(data[12:16])
+
+    case NTLMChallenge:
+        if len(data) < 48 {
+            return nil, errNTLM
+        }
+        m.Flags = binary.LittleEndian.Uint32(data[20:24])
+        m.ServerChallenge = append([]byte{}, data[24:32]...)
+        m.TargetName = m.str(ntlmField(data, 12))
+        info := ntlmField(data, 40)
+        for len(info) >= 4 {
+            id := binary.LittleEndian.Uint16(info[0:2])
+            size := int(binary.LittleEndian.Uint16(info[2:4]))
+            if id == avEOL || len(info) < 4 + size {
+                break
+            }
+            value := decodeUTF16(info[4:4 + size])
+            switch id {
+            case avNbComputerName:
+                m.NbComputerName = value
+            case avNbDomainName:
+                m.NbDomainName = value
+            case avDnsComputerName:
+                m.DnsComputerName = value
+            case avDnsDomainName:
+                m.DnsDomainName = value
+            case avDnsTreeName:
+                m.DnsTreeName = value
+            }
+            info = info[4 + size:]
+        }
+
+    case NTLMAuthenticate:
+        if len(data) < 64 {
+            return nil, errNTLM
+        }
+        m.Flags = binary.LittleEndian.Uint32(data[60:64])
+        m.LmResponse = append([]byte{}, ntlmField(data, 12)...)
+        m.NtResponse = append([]byte{}, ntlmField(data, 20)...)
+        m.Domain = m.str(ntlmField(data, 28))
+        m.User = m.str(ntlmField(data, 36))
+        m.Workstation = m.str(ntlmField(data, 44))
+
+    default:
+        return nil, errNTLM
+    }
+
+    return m, nil
+}
+
+// ntlmField returns the payload of the fields (length, max length and
+// offset) structure at pos, or nil if it is out of the message
+func ntlmField(data []byte, pos int) []byte {
+    if pos + 8 > len(data) {
+        return nil
+    }
+    size := int(binary.LittleEndian.Uint16(data[pos:pos + 2]))
+    offset := int(binary.LittleEndian.Uint32(data[pos + 4:pos + 8]))
+    if size == 0 || offset < 0 || offset + size > len(data) {
+        return nil
+    }
+    return data[offset:offset + size]
+}
+
+// str decodes a string field, UTF-16 when unicode was negotiated
+func (m *NTLMMessage) str(data []byte) string {
+    if m.Flags & ntlmUnicode != 0 {
+        return decodeUTF16(data)
+    }
+    return string(data)
+}
+
+func decodeUTF16(data []byte) string {
+    u := make([]uint16, len(data) / 2)
+    for i := range u {
+        u[i] = binary.LittleEndian.Uint16(data[i * 2:])
+    }
+    return string(utf16.Decode(u))
+}
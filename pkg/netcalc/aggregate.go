@@ -0,0 +1,263 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "bytes"
+    "errors"
+    "math/big"
+    "net"
+    "sort"
+)
+
+const (
+    // GroupSubnets distance heuristic
+    AggregateDistance   = "distance"
+    // minimal CIDR cover, see AggregateSubnets
+    AggregateExact      = "exact"
+)
+
+// Supernet is a network grouping observed subnets
+type Supernet struct {
+    Network         net.IPNet
+    Subnets         []net.IPNet
+    // addresses of the supernet not covered by any observed subnet
+    Unobserved      *big.Int
+    Size            *big.Int
+}
+
+// UnobservedPct returns the unobserved address space in percent
+func (s Supernet) UnobservedPct() float64 {
+    if s.Size == nil || s.Size.Sign() == 0 {
+        return 0
+    }
+    r, _ := new(big.Rat).SetFrac(s.Unobserved, s.Size).Float64()
+    return r * 100
+}
+
+// SetGroupDistance sets the maximum distance, in addresses, between two
+// IPv4 subnets grouped by GroupSubnets (default 512, 2x /24)
+func SetGroupDistance(distance uint32) error {
+    if distance == 0 {
+        return errors.New("distance must be greater than zero")
+    }
+    _DISTANCE = distance
+    return nil
+}
+
+// SetMinPrefix sets the minimum IPv4 supernet prefix length (8 to 32)
+// without changing the GroupSubnets distance, unlike SetLowerCIDR
+func SetMinPrefix(prefix uint32) error {
+    if prefix < 8 || prefix > 32 {
+        return errors.New("prefix must be a valid CIDR value between 8 and 32")
+    }
+    _CIDR = prefix
+    return nil
+}
+
+// NewSupernet returns the smallest network covering the subnets and how
+// much of it was not observed
+func NewSupernet(subnets []net.IPNet) Supernet {
+    sn := CalculateSupernet(subnets)
+    if sn == nil {
+        return Supernet{ Subnets: subnets, Unobserved: big.NewInt(0), Size: big.NewInt(0) }
+    }
+    size := netSize(*sn)
+    return Supernet{
+        Network     : *sn,
+        Subnets     : subnets,
+        Size        : size,
+        Unobserved  : new(big.Int).Sub(size, coveredSize(subnets)),
+    }
+}
+
+// AggregateSubnets returns the minimal CIDR cover of the subnets: sibling
+// networks are merged bottom-up into their parent network as long as the
+// unobserved space of the parent does not exceed tolerance (0 to 1, 0
+// means only fully observed networks are merged). IPv4 supernets are not
+// shorter than the lower CIDR (see SetMinPrefix) and do not cross the
+// private ranges, IPv6 ones are not shorter than the site prefix (/48).
+func AggregateSubnets(subnets []string, tolerance float64) []Supernet {
+    nets4 := []net.IPNet{}
+    nets6 := []net.IPNet{}
+    for _, cidr := range subnets {
+        _, ipnet, err := net.ParseCIDR(cidr)
+        if err != nil {
+            continue
+        }
+        if ip4 := ipnet.IP.To4(); ip4 != nil {
+            ipnet.IP = ip4
+            nets4 = append(nets4, *ipnet)
+        } else {
+            nets6 = append(nets6, *ipnet)
+        }
+    }
+
+    result := aggregate(nets4, 32, int(_CIDR), tolerance)
+    result = append(result, aggregate(nets6, 128, _CIDR6, tolerance)...)
+    return result
+}
+
+type aggNode struct {
+    network         net.IPNet
+    members         []net.IPNet
+}
+
+func aggregate(nets []net.IPNet, bits int, minPrefix int, tolerance float64) []Supernet {
+    nodes := []*aggNode{}
+    for _, n := range nets {
+        nodes = append(nodes, &aggNode{ network: n, members: []net.IPNet{ n } })
+    }
+
+    // subnets inside another observed subnet are taken as its members
+    sort.Slice(nodes, func(i, j int) bool {
+        a, _ := nodes[i].network.Mask.Size()
+        b, _ := nodes[j].network.Mask.Size()
+        if a != b {
+            return a < b
+        }
+        return bytes.Compare(nodes[i].network.IP, nodes[j].network.IP) < 0
+    })
+    disjoint := []*aggNode{}
+    for _, n := range nodes {
+        merged := false
+        for _, d := range disjoint {
+            if d.network.Contains(n.network.IP) {
+                d.members = append(d.members, n.members...)
+                merged = true
+                break
+            }
+        }
+        if !merged {
+            disjoint = append(disjoint, n)
+        }
+    }
+    nodes = disjoint
+
+    // merge bottom-up, the smallest parent networks first
+    for prefix := bits - 1; prefix >= minPrefix; prefix-- {
+        mask := net.CIDRMask(prefix, bits)
+        blocks := map[string][]*aggNode{}
+        keep := []*aggNode{}
+        for _, n := range nodes {
+            if ones, _ := n.network.Mask.Size(); ones <= prefix {
+                keep = append(keep, n)
+                continue
+            }
+            key := n.network.IP.Mask(mask).String()
+            blocks[key] = append(blocks[key], n)
+        }
+
+        for _, children := range blocks {
+            parent := net.IPNet{ IP: children[0].network.IP.Mask(mask), Mask: mask }
+            if len(children) < 2 || (bits == 32 && !sameRange(children)) {
+                keep = append(keep, children...)
+                continue
+            }
+            // the observed subnets, not the merged networks, so the
+            // unobserved space inside the children is counted
+            list := []net.IPNet{}
+            for _, c := range children {
+                list = append(list, c.members...)
+            }
+            size := netSize(parent)
+            unobserved := new(big.Int).Sub(size, coveredSize(list))
+            ratio, _ := new(big.Rat).SetFrac(unobserved, size).Float64()
+            if ratio > tolerance {
+                keep = append(keep, children...)
+                continue
+            }
+            merged := &aggNode{ network: parent }
+            for _, c := range children {
+                merged.members = append(merged.members, c.members...)
+            }
+            keep = append(keep, merged)
+        }
+        nodes = keep
+    }
+
+    sort.Slice(nodes, func(i, j int) bool {
+        return bytes.Compare(nodes[i].network.IP.To16(), nodes[j].network.IP.To16()) < 0
+    })
+    result := []Supernet{}
+    for _, n := range nodes {
+        size := netSize(n.network)
+        result = append(result, Supernet{
+            Network     : n.network,
+            Subnets     : n.members,
+            Size        : size,
+            Unobserved  : new(big.Int).Sub(size, coveredSize(n.members)),
+        })
+    }
+    return result
+}
+
+// sameRange returns true if all IPv4 networks are at the same private
+// range (or none is private)
+func sameRange(nodes []*aggNode) bool {
+    r := getPrivateRange(nodes[0].network.IP.To4())
+    for _, n := range nodes[1:] {
+        if getPrivateRange(n.network.IP.To4()) != r {
+            return false
+        }
+    }
+    return true
+}
+
+// netSize returns the number of addresses of the network
+func netSize(n net.IPNet) *big.Int {
+    ones, bits := n.Mask.Size()
+    return new(big.Int).Lsh(big.NewInt(1), uint(bits - ones))
+}
+
+// coveredSize returns the number of addresses covered by the networks,
+// overlapping networks are counted once
+func coveredSize(nets []net.IPNet) *big.Int {
+    type interval struct {
+        start       *big.Int
+        end         *big.Int
+    }
+    list := []interval{}
+    for _, n := range nets {
+        ip := n.IP.To4()
+        if ip == nil {
+            ip = n.IP.To16()
+        }
+        start := new(big.Int).SetBytes(ip.Mask(n.Mask))
+        list = append(list, interval{ start, new(big.Int).Add(start, netSize(n)) })
+    }
+    sort.Slice(list, func(i, j int) bool {
+        return list[i].start.Cmp(list[j].start) < 0
+    })
+
+    total := big.NewInt(0)
+    var cur *interval
+    for i := range list {
+        it := list[i]
+        if cur != nil && it.start.Cmp(cur.end) <= 0 {
+            if it.end.Cmp(cur.end) > 0 {
+                cur.end = it.end
+            }
+            continue
+        }
+        if cur != nil {
+            total.Add(total, new(big.Int).Sub(cur.end, cur.start))
+        }
+        cur = &interval{ it.start, it.end }
+    }
+    if cur != nil {
+        total.Add(total, new(big.Int).Sub(cur.end, cur.start))
+    }
+    return total
+}
@@ -0,0 +1,101 @@
+package netcalc
+
+import (
+	"testing"
+)
+
+func supernetList(sn []Supernet) []string {
+	list := []string{}
+	for _, s := range sn {
+		list = append(list, s.Network.String())
+	}
+	return list
+}
+
+func TestAggregateSubnets(t *testing.T) {
+	tests := []struct {
+		name       string
+		subnets    []string
+		tolerance  float64
+		want       []string
+		unobserved []int64
+	}{
+		{
+			name:       "siblings",
+			subnets:    []string{"10.0.0.0/25", "10.0.0.128/25"},
+			tolerance:  0,
+			want:       []string{"10.0.0.0/24"},
+			unobserved: []int64{0},
+		},
+		{
+			name:       "nested subnet",
+			subnets:    []string{"10.0.0.0/24", "10.0.0.64/26"},
+			tolerance:  0,
+			want:       []string{"10.0.0.0/24"},
+			unobserved: []int64{0},
+		},
+		{
+			name:       "within tolerance",
+			subnets:    []string{"10.0.0.0/26", "10.0.0.128/26"},
+			tolerance:  0.5,
+			want:       []string{"10.0.0.0/24"},
+			unobserved: []int64{128},
+		},
+		{
+			// the /25 merged at 50% must not count as observed at /24
+			// (62.5% unobserved)
+			name:       "unobserved space of merged children",
+			subnets:    []string{"10.0.0.0/27", "10.0.0.64/27", "10.0.0.128/27"},
+			tolerance:  0.5,
+			want:       []string{"10.0.0.0/25", "10.0.0.128/27"},
+			unobserved: []int64{64, 0},
+		},
+		{
+			name:       "private ranges",
+			subnets:    []string{"172.31.255.0/24", "172.32.0.0/24"},
+			tolerance:  1,
+			want:       []string{"172.31.255.0/24", "172.32.0.0/24"},
+			unobserved: []int64{0, 0},
+		},
+		{
+			name:       "ipv6",
+			subnets:    []string{"2001:db8::/65", "2001:db8:0:0:8000::/65"},
+			tolerance:  0,
+			want:       []string{"2001:db8::/64"},
+			unobserved: []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AggregateSubnets(tt.subnets, tt.tolerance)
+			list := supernetList(got)
+			if len(list) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, list)
+			}
+			for i := range list {
+				if list[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, list)
+				}
+				if got[i].Unobserved.Int64() != tt.unobserved[i] {
+					t.Fatalf("%s: expected %d unobserved, got %s", list[i], tt.unobserved[i], got[i].Unobserved)
+				}
+			}
+		})
+	}
+}
+
+func TestSetMinPrefix(t *testing.T) {
+	cidr, distance := _CIDR, _DISTANCE
+	defer func() { _CIDR, _DISTANCE = cidr, distance }()
+
+	if err := SetMinPrefix(4); err == nil {
+		t.Fatal("expected an error for /4")
+	}
+	if err := SetMinPrefix(20); err != nil {
+		t.Fatal(err)
+	}
+	if _CIDR != 20 || _DISTANCE != distance {
+		t.Fatalf("expected prefix 20 and distance %d, got %d and %d", distance, _CIDR, _DISTANCE)
+	}
+}
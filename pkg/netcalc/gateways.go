@@ -0,0 +1,444 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "bytes"
+    "encoding/csv"
+    "fmt"
+    "io"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/google/gopacket"
+)
+
+// Maximum number of addresses tracked per MAC address
+const maxTracked = 4096
+
+// Gateway is a router found at the capture
+type Gateway struct {
+    MAC             string              `json:"mac"`
+    Vendor          string              `json:"vendor"`
+    IPs             []string            `json:"ips"`
+    VirtualIPs      []string            `json:"virtual_ips"`
+    // local subnets whose hosts send traffic through the gateway
+    Subnets         []string            `json:"subnets"`
+    // prefixes advertised by the gateway routing protocols
+    Routes          []string            `json:"routes"`
+    Evidence        []string            `json:"evidence"`
+    // off-subnet destinations reached through the gateway MAC address
+    Destinations    int                 `json:"destinations"`
+    // frames sent by the gateway MAC address with a decremented TTL
+    RoutedFrames    int64               `json:"routed_frames"`
+}
+
+type gwEntry struct {
+    ips             map[string]bool
+    vips            map[string]bool
+    routes          map[string]bool
+    evidence        evidenceSet
+    // destination addresses of frames sent to the MAC address
+    dst             map[string]bool
+    // source addresses of frames sent to the MAC address, with their MAC
+    src             map[string]string
+    // IPv4 source addresses of frames sent by the MAC address
+    forwarded       map[string]bool
+    routedSrc       map[string]bool
+    routedFrames    int64
+}
+
+func newGwEntry() *gwEntry {
+    return &gwEntry{
+        ips         : map[string]bool{},
+        vips        : map[string]bool{},
+        routes      : map[string]bool{},
+        dst         : map[string]bool{},
+        src         : map[string]string{},
+        forwarded   : map[string]bool{},
+        routedSrc   : map[string]bool{},
+    }
+}
+
+func (e *gwEntry) merge(o *gwEntry) {
+    for _, m := range [][2]map[string]bool{
+        { e.ips, o.ips }, { e.vips, o.vips }, { e.routes, o.routes }, { e.dst, o.dst },
+        { e.forwarded, o.forwarded }, { e.routedSrc, o.routedSrc },
+    } {
+        for k := range m[1] {
+            m[0][k] = true
+        }
+    }
+    for k, v := range o.src {
+        e.src[k] = v
+    }
+    e.evidence.merge(&o.evidence)
+    e.routedFrames += o.routedFrames
+}
+
+// GatewayCollector identifies the routers of the capture, the evidence
+// taken is:
+//
+//   - MAC addresses many off-subnet destinations are reached through
+//   - MAC addresses sending frames with a decremented TTL/hop limit
+//   - DHCP router option (3) and IPv6 router advertisements
+//   - HSRP/VRRP speakers and routing protocol (OSPF, RIP, EIGRP) speakers
+//
+// Feed it with Add and get the result with Gateways.
+type GatewayCollector struct {
+    macs            map[string]*gwEntry
+    // entries of routers whose MAC address is not known yet
+    byIP            map[string]*gwEntry
+    // IP to MAC address bindings from ARP and NDP
+    bindings        map[string]string
+}
+
+func NewGatewayCollector() *GatewayCollector {
+    return &GatewayCollector{
+        macs        : map[string]*gwEntry{},
+        byIP        : map[string]*gwEntry{},
+        bindings    : map[string]string{},
+    }
+}
+
+// entry returns the entry of the MAC address, or of the IP address if
+// the MAC address is unknown
+func (c *GatewayCollector) entry(mac net.HardwareAddr, ip net.IP) *gwEntry {
+    if len(mac) == 6 {
+        e, ok := c.macs[mac.String()]
+        if !ok {
+            e = newGwEntry()
+            c.macs[mac.String()] = e
+        }
+        if ip != nil {
+            e.ips[ip.String()] = true
+        }
+        return e
+    }
+    e, ok := c.byIP[ip.String()]
+    if !ok {
+        e = newGwEntry()
+        e.ips[ip.String()] = true
+        c.byIP[ip.String()] = e
+    }
+    return e
+}
+
+func (c *GatewayCollector) bind(mac net.HardwareAddr, ip net.IP) {
+    if len(mac) != 6 || ip == nil || ip.IsUnspecified() || isDeniedIP(ip) && !ip.IsLinkLocalUnicast() {
+        return
+    }
+    c.bindings[ip.String()] = mac.String()
+}
+
+// Add collects the gateway evidence of the packet
+func (c *GatewayCollector) Add(packet gopacket.Packet) {
+    c.AddEvidence(GetEvidenceFromPacket(packet))
+}
+
+// AddEvidence collects the gateway evidence decoded from a packet, the
+// same extraction the subnet inference uses
+func (c *GatewayCollector) AddEvidence(e *PacketEvidence) {
+    srcMAC, dstMAC := e.SrcMAC, e.DstMAC
+
+    if e.ARP != nil {
+        c.bind(e.ARP.SrcMAC, e.ARP.SrcIP)
+    }
+
+    srcIP, dstIP, ttl := e.SrcIP, e.DstIP, e.TTL
+    if srcIP == nil {
+        return
+    }
+
+    unicast := len(dstMAC) == 6 && dstMAC[0] & 0x01 == 0
+    if len(srcMAC) == 6 && unicast && !isDeniedIP(srcIP) && !isDeniedIP(dstIP) {
+        dst := c.entry(dstMAC, nil)
+        if len(dst.dst) < maxTracked {
+            dst.dst[dstIP.String()] = true
+        }
+        if len(dst.src) < maxTracked {
+            dst.src[srcIP.String()] = srcMAC.String()
+        }
+
+        src := c.entry(srcMAC, nil)
+        if len(src.forwarded) < maxTracked && srcIP.To4() != nil {
+            src.forwarded[srcIP.String()] = true
+        }
+        if ttl > 1 && ttl != initialTTL(ttl) {
+            src.routedFrames++
+            if len(src.routedSrc) < maxTracked {
+                src.routedSrc[srcIP.String()] = true
+            }
+        }
+    }
+
+    //DHCP router option
+    if e.DHCP != nil {
+        for _, router := range e.DHCP.Routers {
+            c.entry(nil, router).evidence.add(fmt.Sprintf("dhcp router option from %s", srcIP))
+        }
+    }
+
+    //IPv6 router advertisement
+    if e.RA != nil {
+        c.bind(e.RA.MAC, srcIP)
+        c.entry(e.RA.MAC, srcIP).evidence.add("router advertisement")
+    }
+
+    if e.NA != nil {
+        c.bind(e.NA.MAC, e.NA.IP)
+    }
+
+    //OSPF, RIP, EIGRP, HSRP and VRRP speakers
+    for _, route := range e.Routes {
+        router := net.ParseIP(route.Router)
+        if router == nil {
+            continue
+        }
+        entry := c.entry(srcMAC, router)
+        switch {
+        case route.VirtualIP:
+            entry.vips[route.Network.IP.String()] = true
+            entry.evidence.add(fmt.Sprintf("%s speaker", route.Protocol))
+        case route.Info == "hello":
+            entry.evidence.add("ospf hello")
+        default:
+            entry.routes[route.CIDR()] = true
+            entry.evidence.add(fmt.Sprintf("%s updates", route.Protocol))
+        }
+    }
+}
+
+// initialTTL returns the usual initial TTL (32, 64, 128 or 255) the
+// packet was sent with
+func initialTTL(ttl uint8) uint8 {
+    for _, t := range []uint8{ 32, 64, 128 } {
+        if ttl <= t {
+            return t
+        }
+    }
+    return 255
+}
+
+// subnetOf returns the most specific subnet holding the address
+func subnetOf(ip net.IP, subnets []InferredSubnet) SubnetData {
+    var best *SubnetData
+    for i, s := range subnets {
+        ipnet := s.IPNet()
+        if ipnet == nil || !ipnet.Contains(ip) {
+            continue
+        }
+        if best == nil || s.Mask > best.Mask {
+            best = &subnets[i].SubnetData
+        }
+    }
+    if best == nil {
+        return NewSubnetFromIP(ip)
+    }
+    return *best
+}
+
+// Gateways returns the routers found, the subnets are used to tell the
+// local subnets from the off-subnet destinations (see MaskInference)
+func (c *GatewayCollector) Gateways(subnets []InferredSubnet) []Gateway {
+    // copies of the entries, routers without a known MAC address are
+    // resolved by the ARP/NDP bindings
+    entries := map[string]*gwEntry{}
+    for mac, e := range c.macs {
+        entries[mac] = newGwEntry()
+        entries[mac].merge(e)
+    }
+    for ip, e := range c.byIP {
+        key, ok := c.bindings[ip]
+        if !ok {
+            key = "ip:" + ip
+        }
+        if _, ok := entries[key]; !ok {
+            entries[key] = newGwEntry()
+        }
+        entries[key].merge(e)
+    }
+    for ip, mac := range c.bindings {
+        if e, ok := entries[mac]; ok && (e.evidence.len() > 0 || len(e.dst) >= gatewayThreshold) {
+            e.ips[ip] = true
+        }
+    }
+
+    // MAC addresses sending frames of several addresses are routers
+    isRouter := func(mac string) bool {
+        e, ok := entries[mac]
+        return ok && (len(e.forwarded) >= gatewayThreshold || len(e.routedSrc) >= gatewayThreshold)
+    }
+
+    gateways := []Gateway{}
+    for key, e := range entries {
+        evidence := &evidenceSet{}
+        evidence.merge(&e.evidence)
+
+        local := []SubnetData{}
+        for a, srcMAC := range e.src {
+            if isRouter(srcMAC) {
+                continue
+            }
+            local = appendSubnet(local, subnetOf(net.ParseIP(a), subnets))
+        }
+        destinations := 0
+        for a := range e.dst {
+            ip := net.ParseIP(a)
+            inside := false
+            for _, s := range local {
+                if ipnet := s.IPNet(); ipnet != nil && ipnet.Contains(ip) {
+                    inside = true
+                    break
+                }
+            }
+            if !inside {
+                destinations++
+            }
+        }
+        if destinations >= gatewayThreshold {
+            evidence.add(fmt.Sprintf("%d off-subnet destinations", destinations))
+        }
+        if len(e.routedSrc) >= gatewayThreshold {
+            evidence.add(fmt.Sprintf("decremented TTL from %d sources", len(e.routedSrc)))
+        }
+        if evidence.len() == 0 {
+            continue
+        }
+
+        g := Gateway{
+            IPs             : sortedIPs(e.ips),
+            VirtualIPs      : sortedIPs(e.vips),
+            Subnets         : []string{},
+            Routes          : []string{},
+            Evidence        : evidence.list(),
+            Destinations    : destinations,
+            RoutedFrames    : e.routedFrames,
+        }
+        if !strings.HasPrefix(key, "ip:") {
+            g.MAC = key
+        }
+        for _, a := range g.IPs {
+            if ip := net.ParseIP(a); !isDeniedIP(ip) {
+                local = appendSubnet(local, subnetOf(ip, subnets))
+            }
+        }
+        sort.Slice(local, func(i, j int) bool {
+            if c := bytes.Compare(net.ParseIP(local[i].Net).To16(), net.ParseIP(local[j].Net).To16()); c != 0 {
+                return c < 0
+            }
+            return local[i].Mask < local[j].Mask
+        })
+        for _, s := range local {
+            g.Subnets = append(g.Subnets, s.String())
+        }
+        for r := range e.routes {
+            g.Routes = append(g.Routes, r)
+        }
+        sort.Strings(g.Routes)
+        gateways = append(gateways, g)
+    }
+
+    sort.Slice(gateways, func(i, j int) bool {
+        a, b := gateways[i], gateways[j]
+        if len(a.IPs) > 0 && len(b.IPs) > 0 {
+            ia, ib := net.ParseIP(a.IPs[0]), net.ParseIP(b.IPs[0])
+            if a4, b4 := ia.To4() != nil, ib.To4() != nil; a4 != b4 {
+                return a4
+            }
+            if c := bytes.Compare(ia.To16(), ib.To16()); c != 0 {
+                return c < 0
+            }
+        } else if len(a.IPs) != len(b.IPs) {
+            return len(a.IPs) > 0
+        }
+        return a.MAC < b.MAC
+    })
+    return gateways
+}
+
+func appendSubnet(list []SubnetData, s SubnetData) []SubnetData {
+    for _, l := range list {
+        if l.Net == s.Net && l.Mask == s.Mask {
+            return list
+        }
+    }
+    return append(list, s)
+}
+
+// sortedIPs returns the addresses sorted, IPv4 first
+func sortedIPs(set map[string]bool) []string {
+    ips := []net.IP{}
+    for a := range set {
+        if ip := net.ParseIP(a); ip != nil {
+            ips = append(ips, ip)
+        }
+    }
+    sort.Slice(ips, func(i, j int) bool {
+        if a4, b4 := ips[i].To4() != nil, ips[j].To4() != nil; a4 != b4 {
+            return a4
+        }
+        return bytes.Compare(ips[i].To16(), ips[j].To16()) < 0
+    })
+    list := []string{}
+    for _, ip := range ips {
+        list = append(list, ip.String())
+    }
+    return list
+}
+
+// GatewaysCSVHeader lists the columns written by WriteGatewaysCSV
+var GatewaysCSVHeader = []string{
+    "mac", "vendor", "ips", "virtual_ips", "subnets", "routes", "evidence",
+    "destinations", "routed_frames",
+}
+
+// Gateways is a gateway list, written as CSV by WriteGatewaysCSV
+type Gateways []Gateway
+
+// WriteCSV writes the gateways as CSV
+func (l Gateways) WriteCSV(w io.Writer) error {
+    return WriteGatewaysCSV(w, l)
+}
+
+// WriteGatewaysCSV writes the gateway list as CSV, multi-valued columns
+// are separated by '; '
+func WriteGatewaysCSV(w io.Writer, gateways []Gateway) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(GatewaysCSVHeader); err != nil {
+        return err
+    }
+
+    for _, g := range gateways {
+        err := cw.Write([]string{
+            g.MAC,
+            g.Vendor,
+            strings.Join(g.IPs, "; "),
+            strings.Join(g.VirtualIPs, "; "),
+            strings.Join(g.Subnets, "; "),
+            strings.Join(g.Routes, "; "),
+            strings.Join(g.Evidence, "; "),
+            strconv.Itoa(g.Destinations),
+            strconv.FormatInt(g.RoutedFrames, 10),
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    cw.Flush()
+    return cw.Error()
+}
@@ -0,0 +1,649 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "bytes"
+    "fmt"
+    "math/bits"
+    "net"
+    "sort"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+const (
+    ConfidenceHigh      = "high"
+    ConfidenceMedium    = "medium"
+    ConfidenceLow       = "low"
+)
+
+// Prefix length assumed for IPv4 addresses without any mask evidence
+const defaultIPv4Mask = 24
+
+// Number of distinct destination addresses reached through one MAC
+// address to take it as a router
+const gatewayThreshold = 3
+
+// Maximum number of evidence lines kept per subnet
+const maxEvidence = 5
+
+// InferredSubnet is a subnet with the prefix length inferred from the
+// capture, how much it can be trusted and why
+type InferredSubnet struct {
+    SubnetData
+    Confidence      string              `json:"confidence"`
+    Evidence        []string            `json:"evidence"`
+    // number of observed addresses inside the subnet
+    Hosts           int                 `json:"hosts"`
+    // 802.1Q VLAN IDs, see GetVLANFromPacket
+    VLAN            string              `json:"vlan"`
+    // ScopeIn, ScopePartial, ScopeOut or ScopeExcluded, see Ranges.Classify
+    Scope           string              `json:"scope"`
+}
+
+// knownNet is a network with an explicit mask (DHCP, ICMP, routing, RA)
+type knownNet struct {
+    network         net.IPNet
+    // link tells the mask belongs to the link the hosts are attached to,
+    // otherwise it is an advertised (possibly summarized) prefix
+    link            bool
+    evidence        evidenceSet
+}
+
+// gatewayKey is a unicast destination MAC address at a VLAN, a router on
+// a stick or a SVI uses the same MAC address for several subnets
+type gatewayKey struct {
+    mac             string
+    vlan            string
+}
+
+// maskEdge tells two addresses share the same subnet
+type maskEdge struct {
+    a               string
+    b               string
+    evidence        string
+}
+
+// MaskInference combines the mask evidence found along the capture:
+//
+//   - exact masks: DHCP subnet mask option, ICMP address mask replies,
+//     OSPF hello and network LSAs, IPv6 RA and DHCPv6 prefixes
+//   - advertised prefixes (OSPF, RIP, EIGRP): hints only, the subnet of
+//     an address is never larger than the most specific prefix holding it
+//   - same subnet relations: ARP requests, directed broadcasts, HSRP/VRRP
+//     virtual IPs and hosts sending through the same gateway MAC address
+//     at the same VLAN
+//   - directed broadcasts: the host part of the destination is all ones
+//
+// Feed it with Add and get the result with Subnets.
+type MaskInference struct {
+    hosts           map[string]net.IP
+    // addresses seen sending traffic
+    live            map[string]net.IP
+    known           map[string]*knownNet
+    edges           map[string]maskEdge
+    // lowest prefix length allowed for an address (directed broadcasts)
+    lower           map[string]int
+    broadcasts      map[string]net.IP
+    gwDst           map[gatewayKey]map[string]bool
+    // source addresses sent to each MAC address, with their source MAC
+    gwSrc           map[gatewayKey]map[string]string
+    // source addresses of each MAC address, routers forward many of them
+    macSrc          map[gatewayKey]map[string]bool
+    // number of addresses and evidences taken, to tell if a packet had any
+    taken           int
+}
+
+func NewMaskInference() *MaskInference {
+    return &MaskInference{
+        hosts       : map[string]net.IP{},
+        live        : map[string]net.IP{},
+        known       : map[string]*knownNet{},
+        edges       : map[string]maskEdge{},
+        lower       : map[string]int{},
+        broadcasts  : map[string]net.IP{},
+        gwDst       : map[gatewayKey]map[string]bool{},
+        gwSrc       : map[gatewayKey]map[string]string{},
+        macSrc      : map[gatewayKey]map[string]bool{},
+    }
+}
+
+func (m *MaskInference) addHost(ip net.IP) {
+    if ip == nil || ip.IsUnspecified() || isDeniedIP(ip) {
+        return
+    }
+    if ip4 := ip.To4(); ip4 != nil {
+        ip = ip4
+        if ip4.Equal(net.IPv4bcast) {
+            return
+        }
+    }
+    m.taken++
+    if _, ok := m.hosts[ip.String()]; !ok {
+        m.hosts[ip.String()] = append(net.IP{}, ip...)
+    }
+}
+
+// addLive records an address seen as source of a packet
+func (m *MaskInference) addLive(ip net.IP) {
+    if ip == nil || ip.IsUnspecified() || ip.IsMulticast() || isDeniedIP(ip) {
+        return
+    }
+    if ip4 := ip.To4(); ip4 != nil {
+        ip = ip4
+        if ip4.Equal(net.IPv4bcast) {
+            return
+        }
+    }
+    if _, ok := m.live[ip.String()]; !ok {
+        m.live[ip.String()] = append(net.IP{}, ip...)
+    }
+}
+
+// LiveHosts returns the addresses seen sending traffic, IPv4 first
+func (m *MaskInference) LiveHosts() []net.IP {
+    list := []net.IP{}
+    for _, ip := range m.live {
+        list = append(list, ip)
+    }
+    sort.Slice(list, func(i, j int) bool {
+        if a4, b4 := list[i].To4() != nil, list[j].To4() != nil; a4 != b4 {
+            return a4
+        }
+        return bytes.Compare(list[i].To16(), list[j].To16()) < 0
+    })
+    return list
+}
+
+func (m *MaskInference) addKnown(ip net.IP, ones int, link bool, evidence string) {
+    size := 128
+    if ip4 := ip.To4(); ip4 != nil {
+        ip, size = ip4, 32
+    }
+    if ones <= 0 || ones > size || isDeniedIP(ip) {
+        return
+    }
+    m.taken++
+    mask := net.CIDRMask(ones, size)
+    network := net.IPNet{ IP: append(net.IP{}, ip.Mask(mask)...), Mask: mask }
+    key := network.String()
+    k, ok := m.known[key]
+    if !ok {
+        k = &knownNet{ network: network }
+        m.known[key] = k
+    }
+    k.link = k.link || link
+    k.evidence.add(evidence)
+}
+
+func (m *MaskInference) addEdge(a, b net.IP, evidence string) {
+    a, b = a.To4(), b.To4()
+    if a == nil || b == nil || a.Equal(b) || isDeniedIP(a) || isDeniedIP(b) {
+        return
+    }
+    m.taken++
+    key := fmt.Sprintf("%s|%s|%s", a, b, evidence)
+    if _, ok := m.edges[key]; !ok {
+        m.edges[key] = maskEdge{ a: a.String(), b: b.String(), evidence: evidence }
+    }
+}
+
+// Add collects the addresses and mask evidence of the packet, returning
+// true if the packet had any
+func (m *MaskInference) Add(packet gopacket.Packet) bool {
+    return m.AddEvidence(GetEvidenceFromPacket(packet))
+}
+
+// AddEvidence collects the addresses and mask evidence decoded from a
+// packet, returning true if it had any
+func (m *MaskInference) AddEvidence(e *PacketEvidence) bool {
+    taken := m.taken
+
+    //ARP requests are only sent for on-link addresses
+    if arp := e.ARP; arp != nil {
+        m.addHost(arp.SrcIP)
+        m.addHost(arp.DstIP)
+        m.addLive(arp.SrcIP)
+        if arp.Request {
+            m.addEdge(arp.SrcIP, arp.DstIP, fmt.Sprintf("arp %s -> %s", arp.SrcIP, arp.DstIP))
+        }
+    }
+
+    src, dst := e.SrcIP, e.DstIP
+    if src != nil {
+        m.addLive(src)
+        if e.Established {
+            m.addHost(src)
+            m.addHost(dst)
+        }
+    }
+
+    if src.To4() != nil {
+        if len(e.DstMAC) == 6 {
+            if bytes.Equal(e.DstMAC, layers.EthernetBroadcast) {
+                m.addBroadcast(src, dst)
+            } else if e.DstMAC[0] & 0x01 == 0 && !isDeniedIP(dst) && !isDeniedIP(src) {
+                m.addGateway(gatewayKey{ mac: e.DstMAC.String(), vlan: e.VLAN }, e.SrcMAC.String(), src, dst)
+            }
+        }
+
+        //DHCP
+        if e.DHCP != nil {
+            m.addDHCP(e.DHCP)
+        }
+
+        //ICMP address mask reply
+        if e.MaskReply > 0 {
+            m.addHost(src)
+            m.addKnown(src, e.MaskReply, true, fmt.Sprintf("icmp mask reply /%d from %s", e.MaskReply, src))
+        }
+    }
+
+    //Router Advertisement (Prefix Information options)
+    if e.RA != nil {
+        for _, p := range e.RA.Prefixes {
+            m.addKnown(net.ParseIP(p.Net), p.Mask, true, fmt.Sprintf("router advertisement from %s", src))
+        }
+    }
+
+    //DHCPv6
+    for _, a := range e.DHCPv6Addrs {
+        m.addHost(net.ParseIP(a.Net))
+    }
+    for _, p := range e.DHCPv6Prefixes {
+        m.addKnown(net.ParseIP(p.Net), p.Mask, false, fmt.Sprintf("dhcpv6 delegated prefix from %s", src))
+    }
+
+    //OSPF, RIP, EIGRP, HSRP and VRRP
+    for _, route := range e.Routes {
+        ones, _ := route.Network.Mask.Size()
+        router := net.ParseIP(route.Router)
+        switch {
+        case route.VirtualIP:
+            m.addHost(route.Network.IP)
+            m.addHost(router)
+            m.addEdge(route.Network.IP, router, fmt.Sprintf("%s virtual ip %s from %s", route.Protocol, route.Network.IP, route.Router))
+        case route.Info == "hello":
+            m.addHost(router)
+            m.addKnown(router, ones, true, fmt.Sprintf("ospf hello /%d from %s", ones, route.Router))
+        case route.Info == "network-lsa":
+            m.addKnown(route.Network.IP, ones, true, fmt.Sprintf("ospf network-lsa from %s", route.Router))
+        default:
+            m.addKnown(route.Network.IP, ones, false, fmt.Sprintf("%s route from %s", route.Protocol, route.Router))
+        }
+    }
+
+    //LLDP, CDP, FDP and EDP management addresses
+    if n := e.Neighbor; n != nil {
+        for _, a := range n.MgmtIPs {
+            m.addHost(net.ParseIP(a))
+        }
+        for _, p := range n.Prefixes {
+            if _, ipnet, err := net.ParseCIDR(p); err == nil {
+                ones, _ := ipnet.Mask.Size()
+                m.addKnown(ipnet.IP, ones, false, fmt.Sprintf("%s prefix from %s", n.Protocol, n.DeviceID))
+            }
+        }
+    }
+
+    //NBNS, LLMNR and MDNS, the DNS PTR answers may be about remote
+    //addresses and are left to the host inventory
+    for _, name := range e.Names {
+        if name.Source != SourceDNS {
+            m.addHost(name.IP)
+        }
+    }
+
+    return m.taken != taken
+}
+
+// addBroadcast handles a frame sent to the Ethernet broadcast address,
+// if the IP destination is not the limited broadcast it is a directed one
+func (m *MaskInference) addBroadcast(src, dst net.IP) {
+    if dst == nil || src == nil || dst.Equal(net.IPv4bcast) || isDeniedIP(dst) || isDeniedIP(src) {
+        return
+    }
+    ones := bits.TrailingZeros32(^ipToUint32(dst))
+    if ones < 2 || ones == 32 {
+        return
+    }
+    m.addHost(src)
+    key := dst.String()
+    m.broadcasts[key] = append(net.IP{}, dst...)
+    m.lower[key] = 32 - ones
+    m.addEdge(src, dst, fmt.Sprintf("directed broadcast %s from %s", dst, src))
+}
+
+// addGateway records the unicast destination MAC addresses and who sends
+// traffic through them, to find the hosts sharing the same gateway
+func (m *MaskInference) addGateway(gw gatewayKey, srcMAC string, src, dst net.IP) {
+    if src == nil || dst == nil {
+        return
+    }
+    if _, ok := m.gwDst[gw]; !ok {
+        m.gwDst[gw] = map[string]bool{}
+        m.gwSrc[gw] = map[string]string{}
+    }
+    if len(m.gwDst[gw]) < gatewayThreshold {
+        m.gwDst[gw][dst.String()] = true
+    }
+    m.gwSrc[gw][src.String()] = srcMAC
+
+    sender := gatewayKey{ mac: srcMAC, vlan: gw.vlan }
+    if _, ok := m.macSrc[sender]; !ok {
+        m.macSrc[sender] = map[string]bool{}
+    }
+    if len(m.macSrc[sender]) < gatewayThreshold {
+        m.macSrc[sender][src.String()] = true
+    }
+}
+
+func (m *MaskInference) addDHCP(d *DHCPEvidence) {
+    if d.IP == nil {
+        return
+    }
+    m.addHost(d.IP)
+    for _, r := range d.Routers {
+        m.addHost(r)
+    }
+    if d.Mask == 0 {
+        return
+    }
+    evidence := fmt.Sprintf("dhcp mask /%d to %s", d.Mask, d.IP)
+    if d.Server != nil {
+        evidence += fmt.Sprintf(" from %s", d.Server)
+    }
+    m.addKnown(d.IP, d.Mask, true, evidence)
+}
+
+// Subnets returns the inferred subnets sorted by address
+func (m *MaskInference) Subnets() []InferredSubnet {
+    result := map[string]*InferredSubnet{}
+    subnetEvidence := map[string]*evidenceSet{}
+    add := func(ipnet net.IPNet, confidence string, e *evidenceSet, hosts int) {
+        ones, _ := ipnet.Mask.Size()
+        s := NewSubnetFromIPMask(ipnet.IP, ones)
+        key := s.String()
+        r, ok := result[key]
+        if !ok {
+            r = &InferredSubnet{ SubnetData: s, Confidence: confidence }
+            result[key] = r
+            subnetEvidence[key] = &evidenceSet{}
+        }
+        if confidenceRank(confidence) > confidenceRank(r.Confidence) {
+            r.Confidence = confidence
+        }
+        if e != nil {
+            subnetEvidence[key].merge(e)
+        }
+        r.Hosts += hosts
+    }
+
+    // known networks, the most specific link network holding an address
+    // is its subnet. Advertised prefixes may be summaries, they are only
+    // hints
+    known := []*knownNet{}
+    for _, k := range m.known {
+        known = append(known, k)
+        if k.link {
+            add(k.network, ConfidenceHigh, &k.evidence, 0)
+        } else {
+            add(k.network, ConfidenceLow, &k.evidence, 0)
+        }
+    }
+    sort.Slice(known, func(i, j int) bool {
+        a, _ := known[i].network.Mask.Size()
+        b, _ := known[j].network.Mask.Size()
+        return a > b
+    })
+    linkNet := func(ip net.IP) *knownNet {
+        for _, k := range known {
+            if k.link && k.network.Contains(ip) {
+                return k
+            }
+        }
+        return nil
+    }
+    upperNet := func(ip net.IP) int {
+        for _, k := range known {
+            if !k.link && k.network.Contains(ip) {
+                ones, _ := k.network.Mask.Size()
+                return ones
+            }
+        }
+        return 0
+    }
+
+    pending := map[string]net.IP{}
+    for key, ip := range m.hosts {
+        if k := linkNet(ip); k != nil {
+            add(k.network, ConfidenceHigh, nil, 1)
+        } else {
+            pending[key] = ip
+        }
+    }
+    for key, ip := range m.broadcasts {
+        if linkNet(ip) == nil {
+            pending[key] = ip
+        }
+    }
+
+    // hosts sending to the same gateway MAC address share the subnet
+    edges := []maskEdge{}
+    for _, e := range m.edges {
+        edges = append(edges, e)
+    }
+    for gw, dst := range m.gwDst {
+        if len(dst) < gatewayThreshold {
+            continue
+        }
+        // addresses forwarded by other routers are not local
+        srcs := []net.IP{}
+        for a, srcMAC := range m.gwSrc[gw] {
+            if len(m.macSrc[gatewayKey{ mac: srcMAC, vlan: gw.vlan }]) < gatewayThreshold {
+                srcs = append(srcs, net.ParseIP(a).To4())
+            }
+        }
+        if len(srcs) < 2 {
+            continue
+        }
+        sort.Slice(srcs, func(i, j int) bool { return bytes.Compare(srcs[i], srcs[j]) < 0 })
+        evidence := fmt.Sprintf("gateway mac %s shared by %d hosts", gw.mac, len(srcs))
+        if gw.vlan != "" {
+            evidence = fmt.Sprintf("gateway mac %s at vlan %s shared by %d hosts", gw.mac, gw.vlan, len(srcs))
+        }
+        for _, ip := range srcs[1:] {
+            edges = append(edges, maskEdge{ a: srcs[0].String(), b: ip.String(), evidence: evidence })
+        }
+    }
+
+    // group the IPv4 addresses without a known mask
+    parent := map[string]string{}
+    var find func(string) string
+    find = func(k string) string {
+        if p, ok := parent[k]; ok && p != k {
+            parent[k] = find(p)
+            return parent[k]
+        }
+        return k
+    }
+    groupEvidence := map[string]*evidenceSet{}
+    for _, e := range edges {
+        if _, ok := pending[e.a]; !ok {
+            continue
+        }
+        if _, ok := pending[e.b]; !ok {
+            continue
+        }
+        ra, rb := find(e.a), find(e.b)
+        if groupEvidence[rb] == nil {
+            groupEvidence[rb] = &evidenceSet{}
+        }
+        if ra != rb {
+            parent[ra] = rb
+            if g := groupEvidence[ra]; g != nil {
+                groupEvidence[rb].merge(g)
+                delete(groupEvidence, ra)
+            }
+        }
+        groupEvidence[rb].add(e.evidence)
+    }
+
+    groups := map[string][]net.IP{}
+    for key, ip := range pending {
+        if ip.To4() == nil {
+            add(net.IPNet{ IP: ip, Mask: net.CIDRMask(defaultIPv6Mask, 128) }, ConfidenceLow,
+                newEvidenceSet(fmt.Sprintf("no mask evidence, /%d assumed", defaultIPv6Mask)), 1)
+            continue
+        }
+        root := find(key)
+        groups[root] = append(groups[root], ip)
+    }
+
+    for root, members := range groups {
+        upper := 32
+        lower := 0
+        hosts := 0
+        first := ipToUint32(members[0])
+        for _, ip := range members {
+            if cp := commonPrefix(first, ipToUint32(ip)); cp < upper {
+                upper = cp
+            }
+            if l, ok := m.lower[ip.String()]; ok && l > lower {
+                lower = l
+            }
+            if _, ok := m.hosts[ip.String()]; ok {
+                hosts++
+            }
+        }
+
+        evidence := groupEvidence[root]
+        if evidence == nil {
+            evidence = &evidenceSet{}
+        }
+        confidence := ConfidenceLow
+        if lower > 0 || (len(members) > 1 && upper < defaultIPv4Mask) {
+            confidence = ConfidenceMedium
+        }
+
+        // the advertised prefix holding the addresses limits the size
+        if u := upperNet(members[0]); u > lower && u <= upper {
+            lower = u
+        }
+
+        ones := defaultIPv4Mask
+        if lower > ones {
+            ones = lower
+        }
+        if upper < ones {
+            ones = upper
+        }
+        if evidence.len() == 0 {
+            evidence.add(fmt.Sprintf("no mask evidence, /%d assumed", defaultIPv4Mask))
+        } else if ones == defaultIPv4Mask && lower < ones && upper > ones {
+            evidence.add(fmt.Sprintf("/%d assumed inside the bounds", defaultIPv4Mask))
+        }
+
+        mask := net.CIDRMask(ones, 32)
+        add(net.IPNet{ IP: members[0].Mask(mask), Mask: mask }, confidence, evidence, hosts)
+    }
+
+    subnets := []InferredSubnet{}
+    for key, s := range result {
+        s.Evidence = subnetEvidence[key].list()
+        subnets = append(subnets, *s)
+    }
+    sort.Slice(subnets, func(i, j int) bool {
+        a, b := net.ParseIP(subnets[i].Net), net.ParseIP(subnets[j].Net)
+        if a4, b4 := a.To4() != nil, b.To4() != nil; a4 != b4 {
+            return a4
+        }
+        if c := bytes.Compare(a.To16(), b.To16()); c != 0 {
+            return c < 0
+        }
+        return subnets[i].Mask < subnets[j].Mask
+    })
+    return subnets
+}
+
+func confidenceRank(c string) int {
+    switch c {
+    case ConfidenceHigh:
+        return 3
+    case ConfidenceMedium:
+        return 2
+    case ConfidenceLow:
+        return 1
+    }
+    return 0
+}
+
+// evidenceSet keeps the distinct evidence lines, the first maxEvidence
+// are listed and the others counted
+type evidenceSet struct {
+    lines           []string
+    seen            map[string]bool
+}
+
+func newEvidenceSet(lines ...string) *evidenceSet {
+    e := &evidenceSet{}
+    for _, l := range lines {
+        e.add(l)
+    }
+    return e
+}
+
+func (e *evidenceSet) add(line string) {
+    if line == "" || e.seen[line] {
+        return
+    }
+    if e.seen == nil {
+        e.seen = map[string]bool{}
+    }
+    e.seen[line] = true
+    if len(e.lines) < maxEvidence {
+        e.lines = append(e.lines, line)
+    }
+}
+
+// merge adds the lines of o, the listed ones first
+func (e *evidenceSet) merge(o *evidenceSet) {
+    for _, l := range o.lines {
+        e.add(l)
+    }
+    rest := []string{}
+    for l := range o.seen {
+        rest = append(rest, l)
+    }
+    sort.Strings(rest)
+    for _, l := range rest {
+        e.add(l)
+    }
+}
+
+// len returns the number of distinct lines
+func (e *evidenceSet) len() int {
+    return len(e.seen)
+}
+
+// list returns the listed lines and "+N more" for the others
+func (e *evidenceSet) list() []string {
+    list := append([]string{}, e.lines...)
+    if more := len(e.seen) - len(e.lines); more > 0 {
+        list = append(list, fmt.Sprintf("+%d more", more))
+    }
+    return list
+}
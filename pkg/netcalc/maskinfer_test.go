@@ -0,0 +1,156 @@
+package netcalc
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func mac(last byte) net.HardwareAddr {
+	return net.HardwareAddr{0, 0x11, 0x22, 0x33, 0x44, last}
+}
+
+// unicast returns an established TCP segment from src to dst through the
+// gateway MAC address
+func unicast(vlan string, srcMAC net.HardwareAddr, src, dst string) *PacketEvidence {
+	return &PacketEvidence{
+		VLAN:        vlan,
+		SrcMAC:      srcMAC,
+		DstMAC:      mac(0xfe),
+		SrcIP:       net.ParseIP(src).To4(),
+		DstIP:       net.ParseIP(dst).To4(),
+		Established: true,
+	}
+}
+
+func vip(protocol, router, ip string) Route {
+	r := newRoute(protocol, net.ParseIP(router), net.ParseIP(ip), 32, 32)
+	r.VirtualIP = true
+	return r
+}
+
+func findSubnet(subnets []InferredSubnet, cidr string) *InferredSubnet {
+	for i, s := range subnets {
+		if s.String() == cidr {
+			return &subnets[i]
+		}
+	}
+	return nil
+}
+
+func TestMaskInference(t *testing.T) {
+	tests := []struct {
+		name       string
+		evidence   []*PacketEvidence
+		subnet     string
+		confidence string
+		absent     []string
+	}{
+		{
+			name: "dhcp mask",
+			evidence: []*PacketEvidence{{
+				SrcIP: net.IP{10, 0, 4, 1},
+				DHCP:  &DHCPEvidence{IP: net.IP{10, 0, 4, 20}, Mask: 22, Server: net.IP{10, 0, 4, 1}},
+			}},
+			subnet:     "10.0.4.0/22",
+			confidence: ConfidenceHigh,
+		},
+		{
+			name: "arp requests",
+			evidence: []*PacketEvidence{
+				{ARP: &ARPEvidence{Request: true, SrcMAC: mac(1), SrcIP: net.IP{10, 0, 0, 5}, DstIP: net.IP{10, 0, 1, 9}}},
+			},
+			subnet:     "10.0.0.0/23",
+			confidence: ConfidenceMedium,
+		},
+		{
+			name: "routing prefix is a hint",
+			evidence: []*PacketEvidence{{
+				Routes: []Route{newRoute(ProtoOSPF, net.IP{10, 60, 1, 1}, net.IP{10, 61, 0, 0}, 16, 32)},
+			}},
+			subnet:     "10.61.0.0/16",
+			confidence: ConfidenceLow,
+		},
+		{
+			name: "virtual ip without a mask",
+			evidence: []*PacketEvidence{{
+				Routes: []Route{vip(ProtoHSRP, "10.0.0.2", "10.0.0.1")},
+			}},
+			subnet:     "10.0.0.0/24",
+			confidence: ConfidenceLow,
+		},
+		{
+			name: "dns ptr names are not evidence",
+			evidence: []*PacketEvidence{{
+				Names: []HostName{
+					{Name: "ws01", IP: net.IP{10, 0, 9, 5}, Source: SourceNBNS, Type: NameHost},
+					{Name: "www.example.com", IP: net.IP{10, 200, 0, 5}, Source: SourceDNS, Type: NameHost},
+				},
+			}},
+			subnet:     "10.0.9.0/24",
+			confidence: ConfidenceLow,
+			absent:     []string{"10.200.0.0/24"},
+		},
+		{
+			name: "gateway union at one vlan",
+			evidence: []*PacketEvidence{
+				unicast("10", mac(1), "10.1.0.5", "8.8.8.8"),
+				unicast("10", mac(2), "10.1.1.6", "1.1.1.1"),
+				unicast("10", mac(1), "10.1.0.5", "9.9.9.9"),
+			},
+			subnet:     "10.1.0.0/23",
+			confidence: ConfidenceMedium,
+		},
+		{
+			name: "router on a stick",
+			evidence: []*PacketEvidence{
+				unicast("10", mac(1), "10.1.0.5", "8.8.8.8"),
+				unicast("10", mac(1), "10.1.0.5", "1.1.1.1"),
+				unicast("10", mac(1), "10.1.0.5", "9.9.9.9"),
+				unicast("20", mac(2), "10.2.0.5", "8.8.8.8"),
+				unicast("20", mac(2), "10.2.0.5", "1.1.1.1"),
+				unicast("20", mac(2), "10.2.0.5", "9.9.9.9"),
+			},
+			subnet:     "10.2.0.0/24",
+			confidence: ConfidenceLow,
+			absent:     []string{"10.0.0.0/14"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMaskInference()
+			for _, e := range tt.evidence {
+				m.AddEvidence(e)
+			}
+			subnets := m.Subnets()
+			s := findSubnet(subnets, tt.subnet)
+			if s == nil {
+				t.Fatalf("expected %s, got %v", tt.subnet, subnets)
+			}
+			if s.Confidence != tt.confidence {
+				t.Errorf("expected %s confidence, got %s", tt.confidence, s.Confidence)
+			}
+			for _, a := range tt.absent {
+				if findSubnet(subnets, a) != nil {
+					t.Errorf("unexpected %s", a)
+				}
+			}
+		})
+	}
+}
+
+func TestEvidenceSet(t *testing.T) {
+	e := &evidenceSet{}
+	for i := 0; i < 8; i++ {
+		e.add(fmt.Sprintf("line %d", i))
+		e.add(fmt.Sprintf("line %d", i))
+	}
+	o := newEvidenceSet("line 0", "line 8")
+	e.merge(o)
+
+	want := []string{"line 0", "line 1", "line 2", "line 3", "line 4", "+4 more"}
+	if got := e.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
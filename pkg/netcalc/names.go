@@ -0,0 +1,411 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "encoding/binary"
+    "fmt"
+    "net"
+    "strconv"
+    "strings"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// NameSource is the protocol a host name was learned from
+type NameSource string
+
+const (
+    SourceNBNS      NameSource = "nbns"
+    SourceLLMNR     NameSource = "llmnr"
+    SourceMDNS      NameSource = "mdns"
+    SourceDNS       NameSource = "dns"
+)
+
+// NameType is the kind of name found
+type NameType string
+
+const (
+    // host name (NetBIOS computer name, mDNS/LLMNR host)
+    NameHost        NameType = "host"
+    // NetBIOS workgroup or domain
+    NameDomain      NameType = "domain"
+    // mDNS/DNS-SD service instance
+    NameService     NameType = "service"
+)
+
+// HostName is a name to address mapping found in the traffic
+type HostName struct {
+    Name            string
+    IP              net.IP
+    Source          NameSource
+    Type            NameType
+    // NetBIOS suffix or DNS-SD service type and port
+    Info            string
+}
+
+// Key returns a string identifying the mapping, used to remove duplicates
+func (n HostName) Key() string {
+    return fmt.Sprintf("%s|%s|%s|%s|%s", n.Source, n.Type, strings.ToLower(n.Name), n.IP, n.Info)
+}
+
+const (
+    dnsPort         = 53
+    nbnsPort        = 137
+    mdnsPort        = 5353
+    llmnrPort       = 5355
+)
+
+// NetBIOS name suffixes (16th byte of the name)
+var nbnsSuffixes = map[byte]string{
+    0x00 : "workstation",
+    0x03 : "messenger",
+    0x1b : "domain master browser",
+    0x1c : "domain controllers",
+    0x1d : "master browser",
+    0x1e : "browser election",
+    0x20 : "file server",
+}
+
+// GetNamesFromPacket decodes NBNS, LLMNR and mDNS messages (and DNS
+// reverse lookup responses) returning the host name to address mappings
+// found
+func GetNamesFromPacket(packet gopacket.Packet) []HostName {
+    udpLayer := packet.Layer(layers.LayerTypeUDP)
+    if udpLayer == nil {
+        return []HostName{}
+    }
+    udp := udpLayer.(*layers.UDP)
+
+    var srcIP net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        srcIP = ipLayer.(*layers.IPv4).SrcIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        srcIP = ipLayer.(*layers.IPv6).SrcIP
+    }
+
+    switch {
+    case udp.SrcPort == nbnsPort || udp.DstPort == nbnsPort:
+        return parseNBNS(udp.Payload, srcIP)
+    case udp.SrcPort == dnsPort:
+        return parseDNSNames(udp.Payload, srcIP, SourceDNS)
+    case udp.SrcPort == llmnrPort:
+        return parseDNSNames(udp.Payload, srcIP, SourceLLMNR)
+    case udp.SrcPort == mdnsPort || udp.DstPort == mdnsPort:
+        return parseDNSNames(udp.Payload, srcIP, SourceMDNS)
+    }
+
+    return []HostName{}
+}
+
+// parseNBNS decodes NetBIOS name registrations, positive query responses
+// and node status (nbtstat) responses
+func parseNBNS(data []byte, srcIP net.IP) []HostName {
+    names := []HostName{}
+    if len(data) < 12 {
+        return names
+    }
+
+    flags := binary.BigEndian.Uint16(data[2:4])
+    response := flags & 0x8000 != 0
+    opcode := (flags >> 11) & 0x0f
+    rcode := flags & 0x000f
+
+    if rcode != 0 {
+        return names
+    }
+
+    switch {
+    case response && opcode == 0:
+        // positive name query / node status response
+    case !response && (opcode == 5 || opcode == 8 || opcode == 9 || opcode == 15):
+        // name registration, refresh and multi-homed registration
+    default:
+        return names
+    }
+
+    qdCount := int(binary.BigEndian.Uint16(data[4:6]))
+    rrCount := int(binary.BigEndian.Uint16(data[6:8])) +
+        int(binary.BigEndian.Uint16(data[8:10])) +
+        int(binary.BigEndian.Uint16(data[10:12]))
+
+    off := 12
+    for i := 0; i < qdCount; i++ {
+        _, next, ok := readNBName(data, off)
+        if !ok || next + 4 > len(data) {
+            return names
+        }
+        off = next + 4
+    }
+
+    for i := 0; i < rrCount; i++ {
+        name, next, ok := readNBName(data, off)
+        if !ok || next + 10 > len(data) {
+            break
+        }
+        rrType := binary.BigEndian.Uint16(data[next:next+2])
+        rdLen := int(binary.BigEndian.Uint16(data[next+8:next+10]))
+        rdata := next + 10
+        if rdata + rdLen > len(data) {
+            break
+        }
+        off = rdata + rdLen
+
+        switch rrType {
+        case 0x0020: // NB
+            for e := rdata; e + 6 <= rdata + rdLen; e += 6 {
+                group := data[e] & 0x80 != 0
+                ip := net.IP{ data[e+2], data[e+3], data[e+4], data[e+5] }
+                if n, ok := newNBName(name, group, ip); ok {
+                    names = append(names, n)
+                }
+            }
+
+        case 0x0021: // NBSTAT
+            if rdLen < 1 || srcIP == nil {
+                continue
+            }
+            count := int(data[rdata])
+            for e := rdata + 1; count > 0 && e + 18 <= rdata + rdLen; e += 18 {
+                group := data[e+16] & 0x80 != 0
+                if n, ok := newNBName(data[e:e+16], group, srcIP); ok {
+                    names = append(names, n)
+                }
+                count--
+            }
+        }
+    }
+
+    return names
+}
+
+// newNBName builds a HostName from a raw 16 byte NetBIOS name
+func newNBName(raw []byte, group bool, ip net.IP) (HostName, bool) {
+    if len(raw) != 16 || raw[0] == 0x01 || raw[0] == '*' {
+        // __MSBROWSE__ and wildcard names
+        return HostName{}, false
+    }
+    if ip == nil || ip.IsUnspecified() {
+        return HostName{}, false
+    }
+
+    suffix := raw[15]
+    name := strings.TrimRight(string(raw[:15]), " \x00")
+    if name == "" {
+        return HostName{}, false
+    }
+
+    n := HostName{
+        Name    : name,
+        IP      : ip,
+        Source  : SourceNBNS,
+        Type    : NameHost,
+        Info    : fmt.Sprintf("<%02X>", suffix),
+    }
+    if group && suffix == 0x00 {
+        n.Info += " workgroup/domain"
+    } else if desc, ok := nbnsSuffixes[suffix]; ok {
+        n.Info += " " + desc
+    }
+
+    switch {
+    case group && suffix == 0x00, suffix == 0x1b, suffix == 0x1c, suffix == 0x1d, suffix == 0x1e:
+        n.Type = NameDomain
+    case group:
+        return HostName{}, false
+    }
+
+    return n, true
+}
+
+// readNBName reads a first-level encoded NetBIOS name (RFC 1002 4.1),
+// following compression pointers, returning the 16 decoded bytes
+func readNBName(data []byte, off int) ([]byte, int, bool) {
+    next := -1
+    for jumps := 0; jumps < 8; jumps++ {
+        if off >= len(data) {
+            return nil, 0, false
+        }
+        l := int(data[off])
+        if l & 0xc0 == 0xc0 {
+            if off + 1 >= len(data) {
+                return nil, 0, false
+            }
+            if next < 0 {
+                next = off + 2
+            }
+            off = int(binary.BigEndian.Uint16(data[off:off+2]) & 0x3fff)
+            continue
+        }
+        if l != 32 || off + 1 + l > len(data) {
+            return nil, 0, false
+        }
+
+        raw := make([]byte, 16)
+        for i := 0; i < 16; i++ {
+            hi := data[off + 1 + (i * 2)] - 'A'
+            lo := data[off + 2 + (i * 2)] - 'A'
+            if hi > 0x0f || lo > 0x0f {
+                return nil, 0, false
+            }
+            raw[i] = hi << 4 | lo
+        }
+
+        // skip the scope labels
+        p := off + 1 + l
+        for p < len(data) && data[p] != 0 {
+            if data[p] & 0xc0 == 0xc0 {
+                p++
+                break
+            }
+            p += int(data[p]) + 1
+        }
+        p++
+        if next < 0 {
+            next = p
+        }
+        return raw, next, true
+    }
+    return nil, 0, false
+}
+
+// parseDNSNames decodes LLMNR and mDNS responses (A, AAAA, PTR and SRV).
+// Only the reverse lookups (PTR) of DNS responses are used.
+func parseDNSNames(data []byte, srcIP net.IP, source NameSource) []HostName {
+    names := []HostName{}
+
+    dns := &layers.DNS{}
+    if err := dns.DecodeFromBytes(data, gopacket.NilDecodeFeedback); err != nil {
+        return names
+    }
+    if !dns.QR {
+        return names
+    }
+
+    records := append([]layers.DNSResourceRecord{}, dns.Answers...)
+    records = append(records, dns.Authorities...)
+    records = append(records, dns.Additionals...)
+
+    hosts := map[string][]net.IP{}
+    for _, rr := range records {
+        if source == SourceDNS {
+            continue
+        }
+        if (rr.Type == layers.DNSTypeA || rr.Type == layers.DNSTypeAAAA) && rr.IP != nil {
+            name := strings.ToLower(string(rr.Name))
+            hosts[name] = append(hosts[name], rr.IP)
+            names = append(names, HostName{
+                Name    : string(rr.Name),
+                IP      : rr.IP,
+                Source  : source,
+                Type    : NameHost,
+            })
+        }
+    }
+
+    for _, rr := range records {
+        switch rr.Type {
+        case layers.DNSTypePTR:
+            if ip := ReverseNameToIP(string(rr.Name)); ip != nil {
+                names = append(names, HostName{
+                    Name    : string(rr.PTR),
+                    IP      : ip,
+                    Source  : source,
+                    Type    : NameHost,
+                })
+            }
+
+        case layers.DNSTypeSRV:
+            if source == SourceDNS {
+                continue
+            }
+            instance := string(rr.Name)
+            ips := hosts[strings.ToLower(string(rr.SRV.Name))]
+            if len(ips) == 0 && srcIP != nil {
+                // responders announce their own services
+                ips = []net.IP{ srcIP }
+            }
+            info := fmt.Sprintf("%s port %d", serviceType(instance), rr.SRV.Port)
+            for _, ip := range ips {
+                names = append(names, HostName{
+                    Name    : instance,
+                    IP      : ip,
+                    Source  : source,
+                    Type    : NameService,
+                    Info    : info,
+                })
+            }
+        }
+    }
+
+    return names
+}
+
+// serviceType returns the DNS-SD service type of an instance name
+// ("My Printer._ipp._tcp.local" -> "_ipp._tcp")
+func serviceType(instance string) string {
+    labels := strings.Split(instance, ".")
+    for i := 0; i + 1 < len(labels); i++ {
+        if strings.HasPrefix(labels[i], "_") && (labels[i+1] == "_tcp" || labels[i+1] == "_udp") {
+            return labels[i] + "." + labels[i+1]
+        }
+    }
+    return ""
+}
+
+// ReverseNameToIP converts in-addr.arpa and ip6.arpa names to addresses,
+// returning nil for other names
+func ReverseNameToIP(name string) net.IP {
+    name = strings.TrimSuffix(strings.ToLower(name), ".")
+
+    if s, ok := strings.CutSuffix(name, ".in-addr.arpa"); ok {
+        parts := strings.Split(s, ".")
+        if len(parts) != 4 {
+            return nil
+        }
+        ip := make(net.IP, 4)
+        for i, p := range parts {
+            v, err := strconv.Atoi(p)
+            if err != nil || v < 0 || v > 255 {
+                return nil
+            }
+            ip[3-i] = byte(v)
+        }
+        return ip
+    }
+
+    if s, ok := strings.CutSuffix(name, ".ip6.arpa"); ok {
+        parts := strings.Split(s, ".")
+        if len(parts) != 32 {
+            return nil
+        }
+        ip := make(net.IP, 16)
+        for i, p := range parts {
+            v, err := strconv.ParseUint(p, 16, 8)
+            if err != nil || len(p) != 1 {
+                return nil
+            }
+            pos := 31 - i
+            if pos % 2 == 0 {
+                ip[pos/2] |= byte(v) << 4
+            } else {
+                ip[pos/2] |= byte(v)
+            }
+        }
+        return ip
+    }
+
+    return nil
+}
@@ -0,0 +1,408 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/csv"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "strconv"
+    "net"
+    "sort"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// Neighbor discovery protocols
+const (
+    ProtoLLDP       = "lldp"
+    ProtoCDP        = "cdp"
+    ProtoFDP        = "fdp"
+    ProtoEDP        = "edp"
+)
+
+// SNAP OUIs of the vendor discovery protocols
+var (
+    ouiFoundry      = []byte{ 0x00, 0xe0, 0x52 }
+    ouiExtreme      = []byte{ 0x00, 0xe0, 0x2b }
+)
+
+const edpSNAPType = 0x00bb
+
+// Neighbor is a device announced by a link layer discovery protocol
+type Neighbor struct {
+    Protocol        string          `json:"protocol"`
+    DeviceID        string          `json:"device_id"`
+    SystemName      string          `json:"system_name"`
+    PortID          string          `json:"port_id"`
+    PortDescription string          `json:"port_description"`
+    Platform        string          `json:"platform"`
+    Description     string          `json:"description"`
+    MgmtIPs         []string        `json:"management_ips"`
+    Prefixes        []string        `json:"prefixes"`
+    NativeVLAN      int             `json:"native_vlan"`
+    VLANs           []string        `json:"vlans"`
+    VTPDomain       string          `json:"vtp_domain"`
+    Capabilities    []string        `json:"capabilities"`
+    SourceMAC       string          `json:"source_mac"`
+    FirstSeen       time.Time       `json:"first_seen"`
+    LastSeen        time.Time       `json:"last_seen"`
+    Frames          int64           `json:"frames"`
+}
+
+// Key returns a string identifying the neighbor port, used to remove
+// duplicates
+func (n Neighbor) Key() string {
+    return fmt.Sprintf("%s|%s|%s|%s", n.Protocol, n.DeviceID, n.PortID, n.SourceMAC)
+}
+
+// Merge adds the information of another announcement of the same neighbor
+func (n *Neighbor) Merge(o Neighbor) {
+    n.Frames += o.Frames
+    if !o.FirstSeen.IsZero() && (n.FirstSeen.IsZero() || o.FirstSeen.Before(n.FirstSeen)) {
+        n.FirstSeen = o.FirstSeen
+    }
+    if o.LastSeen.After(n.LastSeen) {
+        n.LastSeen = o.LastSeen
+    }
+    n.MgmtIPs = mergeStrings(n.MgmtIPs, o.MgmtIPs)
+    n.Prefixes = mergeStrings(n.Prefixes, o.Prefixes)
+    n.VLANs = mergeStrings(n.VLANs, o.VLANs)
+    n.Capabilities = mergeStrings(n.Capabilities, o.Capabilities)
+
+    for _, f := range []struct{ dst *string; src string }{
+        { &n.SystemName, o.SystemName },
+        { &n.PortDescription, o.PortDescription },
+        { &n.Platform, o.Platform },
+        { &n.Description, o.Description },
+        { &n.VTPDomain, o.VTPDomain },
+    } {
+        if f.src != "" {
+            *f.dst = f.src
+        }
+    }
+    if o.NativeVLAN != 0 {
+        n.NativeVLAN = o.NativeVLAN
+    }
+}
+
+func mergeStrings(list []string, add []string) []string {
+    for _, s := range add {
+        found := false
+        for _, i := range list {
+            if i == s {
+                found = true
+                break
+            }
+        }
+        if !found {
+            list = append(list, s)
+        }
+    }
+    sort.Strings(list)
+    return list
+}
+
+func newNeighbor(protocol string, packet gopacket.Packet) *Neighbor {
+    n := &Neighbor{
+        Protocol        : protocol,
+        MgmtIPs         : []string{},
+        Prefixes        : []string{},
+        VLANs           : []string{},
+        Capabilities    : []string{},
+        Frames          : 1,
+    }
+    if ethLayer := packet.Layer(layers.LayerTypeEthernet); ethLayer != nil {
+        n.SourceMAC = ethLayer.(*layers.Ethernet).SrcMAC.String()
+    }
+    return n
+}
+
+func (n *Neighbor) addIP(ip net.IP) {
+    if ip == nil || ip.IsUnspecified() {
+        return
+    }
+    n.MgmtIPs = mergeStrings(n.MgmtIPs, []string{ ip.String() })
+}
+
+// GetNeighborFromPacket decodes LLDP, CDP, FDP and EDP frames, returning
+// nil for any other packet
+func GetNeighborFromPacket(packet gopacket.Packet) *Neighbor {
+    if l := packet.Layer(layers.LayerTypeLinkLayerDiscovery); l != nil {
+        return parseLLDP(packet, l.(*layers.LinkLayerDiscovery))
+    }
+
+    snapLayer := packet.Layer(layers.LayerTypeSNAP)
+    if snapLayer == nil {
+        return nil
+    }
+    snap := snapLayer.(*layers.SNAP)
+
+    if l := packet.Layer(layers.LayerTypeCiscoDiscoveryInfo); l != nil {
+        protocol := ProtoCDP
+        if bytes.Equal(snap.OrganizationalCode, ouiFoundry) {
+            protocol = ProtoFDP
+        }
+        return parseCDP(packet, protocol, l.(*layers.CiscoDiscoveryInfo))
+    }
+
+    if bytes.Equal(snap.OrganizationalCode, ouiExtreme) && snap.Type == edpSNAPType {
+        return parseEDP(packet, snap.LayerPayload())
+    }
+
+    return nil
+}
+
+func parseLLDP(packet gopacket.Packet, lldp *layers.LinkLayerDiscovery) *Neighbor {
+    n := newNeighbor(ProtoLLDP, packet)
+
+    switch lldp.ChassisID.Subtype {
+    case layers.LLDPChassisIDSubTypeMACAddr:
+        n.DeviceID = net.HardwareAddr(lldp.ChassisID.ID).String()
+    case layers.LLDPChassisIDSubTypeNetworkAddr:
+        if ip := networkAddr(lldp.ChassisID.ID); ip != nil {
+            n.DeviceID = ip.String()
+            n.addIP(ip)
+            break
+        }
+        n.DeviceID = printable(lldp.ChassisID.ID)
+    default:
+        n.DeviceID = printable(lldp.ChassisID.ID)
+    }
+
+    if lldp.PortID.Subtype == layers.LLDPPortIDSubtypeMACAddr {
+        n.PortID = net.HardwareAddr(lldp.PortID.ID).String()
+    } else {
+        n.PortID = printable(lldp.PortID.ID)
+    }
+
+    l := packet.Layer(layers.LayerTypeLinkLayerDiscoveryInfo)
+    if l == nil {
+        return n
+    }
+    info := l.(*layers.LinkLayerDiscoveryInfo)
+
+    n.SystemName = info.SysName
+    n.PortDescription = info.PortDescription
+    n.Description = strings.TrimSpace(info.SysDescription)
+
+    switch info.MgmtAddress.Subtype {
+    case layers.IANAAddressFamilyIPV4, layers.IANAAddressFamilyIPV6:
+        n.addIP(net.IP(info.MgmtAddress.Address))
+    }
+
+    caps := info.SysCapabilities.EnabledCap
+    if caps == (layers.LLDPCapabilities{}) {
+        caps = info.SysCapabilities.SystemCap
+    }
+    for _, c := range []struct{ set bool; name string }{
+        { caps.Other, "other" },
+        { caps.Repeater, "repeater" },
+        { caps.Bridge, "bridge" },
+        { caps.WLANAP, "wlan-ap" },
+        { caps.Router, "router" },
+        { caps.Phone, "phone" },
+        { caps.DocSis, "docsis" },
+        { caps.StationOnly, "station" },
+        { caps.CVLAN, "c-vlan" },
+        { caps.SVLAN, "s-vlan" },
+        { caps.TMPR, "tpmr" },
+    } {
+        if c.set {
+            n.Capabilities = append(n.Capabilities, c.name)
+        }
+    }
+
+    if i8021, err := info.Decode8021(); err == nil {
+        n.NativeVLAN = int(i8021.PVID)
+        for _, v := range i8021.VLANNames {
+            n.VLANs = append(n.VLANs, fmt.Sprintf("%d (%s)", v.ID, v.Name))
+        }
+    }
+
+    return n
+}
+
+func parseCDP(packet gopacket.Packet, protocol string, cdp *layers.CiscoDiscoveryInfo) *Neighbor {
+    n := newNeighbor(protocol, packet)
+
+    n.DeviceID = cdp.DeviceID
+    n.SystemName = cdp.SysName
+    n.PortID = cdp.PortID
+    n.Platform = cdp.Platform
+    n.Description = strings.TrimSpace(cdp.Version)
+    n.VTPDomain = cdp.VTPDomain
+    n.NativeVLAN = int(cdp.NativeVLAN)
+
+    for _, ip := range cdp.Addresses {
+        n.addIP(ip)
+    }
+    for _, ip := range cdp.MgmtAddresses {
+        n.addIP(ip)
+    }
+    for _, p := range cdp.IPPrefixes {
+        n.Prefixes = append(n.Prefixes, p.String())
+    }
+
+    caps := cdp.Capabilities
+    for _, c := range []struct{ set bool; name string }{
+        { caps.L3Router, "router" },
+        { caps.TBBridge, "trans-bridge" },
+        { caps.SPBridge, "source-route-bridge" },
+        { caps.L2Switch, "switch" },
+        { caps.IsHost, "host" },
+        { caps.IGMPFilter, "igmp" },
+        { caps.L1Repeater, "repeater" },
+        { caps.IsPhone, "phone" },
+        { caps.RemotelyManaged, "remotely-managed" },
+    } {
+        if c.set {
+            n.Capabilities = append(n.Capabilities, c.name)
+        }
+    }
+
+    return n
+}
+
+// parseEDP decodes the Extreme Discovery Protocol display, info and vlan
+// TLVs
+func parseEDP(packet gopacket.Packet, data []byte) *Neighbor {
+    // version, reserved, length, checksum, sequence, machine id type
+    // and machine MAC
+    if len(data) < 16 {
+        return nil
+    }
+    n := newNeighbor(ProtoEDP, packet)
+    n.DeviceID = net.HardwareAddr(data[10:16]).String()
+
+    off := 16
+    for off + 4 <= len(data) {
+        if data[off] != 0x99 {
+            break
+        }
+        tlvType := data[off+1]
+        tlvLen := int(binary.BigEndian.Uint16(data[off+2:off+4]))
+        if tlvLen < 4 || off + tlvLen > len(data) {
+            break
+        }
+        v := data[off+4:off+tlvLen]
+        off += tlvLen
+
+        switch tlvType {
+        case 0x00: // null
+            return n
+        case 0x01: // display
+            n.SystemName = printable(v)
+        case 0x02: // info: slot, port, ..., version
+            if len(v) >= 4 {
+                n.PortID = fmt.Sprintf("%d:%d", binary.BigEndian.Uint16(v[0:2]) + 1, binary.BigEndian.Uint16(v[2:4]) + 1)
+            }
+            if len(v) >= 16 {
+                n.Description = fmt.Sprintf("%d.%d.%d.%d", v[12], v[13], v[14], v[15])
+            }
+        case 0x05: // vlan: flags, reserved, vlan id, reserved, ip, name
+            if len(v) >= 12 {
+                vid := binary.BigEndian.Uint16(v[2:4]) & 0x0fff
+                name := printable(v[12:])
+                n.VLANs = append(n.VLANs, fmt.Sprintf("%d (%s)", vid, name))
+                n.addIP(net.IP(append([]byte{}, v[8:12]...)))
+            }
+        }
+    }
+
+    sort.Strings(n.VLANs)
+    return n
+}
+
+// networkAddr decodes an IANA address family prefixed address
+func networkAddr(b []byte) net.IP {
+    if len(b) == 5 && b[0] == byte(layers.IANAAddressFamilyIPV4) {
+        return net.IP(b[1:5])
+    }
+    if len(b) == 17 && b[0] == byte(layers.IANAAddressFamilyIPV6) {
+        return net.IP(b[1:17])
+    }
+    return nil
+}
+
+// printable returns b as a string, or hex encoded if it is binary data
+func printable(b []byte) string {
+    s := strings.TrimRight(string(b), "\x00")
+    for _, r := range s {
+        if !unicode.IsPrint(r) {
+            return hex.EncodeToString(b)
+        }
+    }
+    return s
+}
+
+// NeighborsCSVHeader lists the columns written by WriteNeighborsCSV
+var NeighborsCSVHeader = []string{
+    "protocol", "device_id", "system_name", "port_id", "port_description",
+    "platform", "description", "management_ips", "prefixes", "native_vlan",
+    "vlans", "vtp_domain", "capabilities", "source_mac", "first_seen",
+    "last_seen", "frames",
+}
+
+// Neighbors is a neighbor list, written as CSV by WriteNeighborsCSV
+type Neighbors []Neighbor
+
+// WriteCSV writes the neighbors as CSV
+func (l Neighbors) WriteCSV(w io.Writer) error {
+    return WriteNeighborsCSV(w, l)
+}
+
+// WriteNeighborsCSV writes the neighbor table as CSV, multi-valued
+// columns are separated by '; '
+func WriteNeighborsCSV(w io.Writer, neighbors []Neighbor) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(NeighborsCSVHeader); err != nil {
+        return err
+    }
+
+    for _, n := range neighbors {
+        err := cw.Write([]string{
+            n.Protocol,
+            n.DeviceID,
+            n.SystemName,
+            n.PortID,
+            n.PortDescription,
+            n.Platform,
+            n.Description,
+            strings.Join(n.MgmtIPs, "; "),
+            strings.Join(n.Prefixes, "; "),
+            strconv.Itoa(n.NativeVLAN),
+            strings.Join(n.VLANs, "; "),
+            n.VTPDomain,
+            strings.Join(n.Capabilities, "; "),
+            n.SourceMAC,
+            n.FirstSeen.UTC().Format(time.RFC3339Nano),
+            n.LastSeen.UTC().Format(time.RFC3339Nano),
+            strconv.FormatInt(n.Frames, 10),
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    cw.Flush()
+    return cw.Error()
+}
@@ -0,0 +1,88 @@
+package netcalc
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// edpTLV returns an EDP TLV: marker, type, length (header included) and
+// value
+func edpTLV(kind byte, value []byte) []byte {
+	tlv := []byte{0x99, kind, 0, 0}
+	binary.BigEndian.PutUint16(tlv[2:], uint16(len(value)+4))
+	return append(tlv, value...)
+}
+
+// edpFrame returns an 802.3 LLC/SNAP frame carrying an EDP advertisement
+// of an Extreme switch
+func edpFrame() []byte {
+	machine := []byte{0x00, 0x04, 0x96, 0x1f, 0xa8, 0x50}
+
+	// version, reserved, length, checksum, sequence, machine id type
+	// and machine MAC
+	edp := []byte{1, 0, 0, 0, 0, 0, 0, 7, 0, 0}
+	edp = append(edp, machine...)
+	edp = append(edp, edpTLV(0x01, []byte("sw-core-01\x00"))...)
+	// slot 0, port 23, virtual chassis, reserved and version 12.6.3.4
+	edp = append(edp, edpTLV(0x02, []byte{0, 0, 0, 23, 0, 0, 0, 0, 0, 0, 0, 0, 12, 6, 3, 4})...)
+	// flags, reserved, vlan 120, reserved, ip and name
+	vlan := []byte{0x80, 0, 0, 120, 0, 0, 0, 0, 10, 120, 0, 1}
+	edp = append(edp, edpTLV(0x05, append(vlan, []byte("Servers\x00")...))...)
+	edp = append(edp, edpTLV(0x00, nil)...)
+	binary.BigEndian.PutUint16(edp[2:], uint16(len(edp)))
+
+	frame := []byte{0x00, 0xe0, 0x2b, 0x00, 0x00, 0x00}
+	frame = append(frame, machine...)
+	frame = append(frame, 0, 0)
+	llc := append([]byte{0xaa, 0xaa, 0x03, 0x00, 0xe0, 0x2b, 0x00, 0xbb}, edp...)
+	binary.BigEndian.PutUint16(frame[12:], uint16(len(llc)))
+	return append(frame, llc...)
+}
+
+func TestEDPNeighbor(t *testing.T) {
+	packet := gopacket.NewPacket(edpFrame(), layers.LinkTypeEthernet, gopacket.Default)
+	n := GetNeighborFromPacket(packet)
+	if n == nil {
+		t.Fatal("expected an EDP neighbor")
+	}
+
+	want := map[string]string{
+		"protocol":    ProtoEDP,
+		"device id":   "00:04:96:1f:a8:50",
+		"system name": "sw-core-01",
+		"port":        "1:24",
+		"version":     "12.6.3.4",
+		"source mac":  "00:04:96:1f:a8:50",
+	}
+	got := map[string]string{
+		"protocol":    n.Protocol,
+		"device id":   n.DeviceID,
+		"system name": n.SystemName,
+		"port":        n.PortID,
+		"version":     n.Description,
+		"source mac":  n.SourceMAC,
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("%s: expected %s, got %s", k, w, got[k])
+		}
+	}
+	if !reflect.DeepEqual(n.VLANs, []string{"120 (Servers)"}) {
+		t.Errorf("unexpected vlans %v", n.VLANs)
+	}
+	if !reflect.DeepEqual(n.MgmtIPs, []string{"10.120.0.1"}) {
+		t.Errorf("unexpected management addresses %v", n.MgmtIPs)
+	}
+}
+
+func TestEDPSubnet(t *testing.T) {
+	m := NewMaskInference()
+	m.Add(gopacket.NewPacket(edpFrame(), layers.LinkTypeEthernet, gopacket.Default))
+	if findSubnet(m.Subnets(), "10.120.0.0/24") == nil {
+		t.Errorf("expected the EDP management address subnet, got %v", m.Subnets())
+	}
+}
@@ -0,0 +1,221 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "net"
+    "os"
+    "strings"
+)
+
+// Ranges file format, one range per line ('#' starts a comment):
+//
+//   internal <cidr>    treated as private (e.g. customer public blocks)
+//   deny     <cidr>    never reported, as loopback and multicast
+//   exclude  <cidr>    reported but classified as excluded
+//   scope    <cidr>    in-scope range, without any the internal ranges
+//                      are the scope
+//
+// e.g.
+//
+//   internal 203.0.113.0/24
+//   exclude  10.10.0.0/16
+//   scope    10.0.0.0/8
+
+const (
+    ScopeIn         = "in-scope"
+    ScopeOut        = "out-of-scope"
+    ScopeExcluded   = "excluded"
+    // partly in scope, or in scope and partly excluded
+    ScopePartial    = "partial"
+)
+
+// Ranges is the set of custom ranges used by subnet discovery
+type Ranges struct {
+    Internal        []*net.IPNet
+    Deny            []*net.IPNet
+    Exclude         []*net.IPNet
+    Scope           []*net.IPNet
+}
+
+// Count returns the number of ranges
+func (r *Ranges) Count() int {
+    return len(r.Internal) + len(r.Deny) + len(r.Exclude) + len(r.Scope)
+}
+
+// LoadRanges reads a ranges file
+func LoadRanges(fileName string) (*Ranges, error) {
+    f, err := os.Open(fileName)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    ranges := &Ranges{}
+    scanner := bufio.NewScanner(f)
+    lineNum := 0
+    for scanner.Scan() {
+        lineNum++
+        if err := ranges.AddRange(scanner.Text()); err != nil {
+            return nil, errors.New(fmt.Sprintf("line %d: %s", lineNum, err))
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+
+    return ranges, nil
+}
+
+// AddRange parses one range line, more than one CIDR may be given
+// (e.g. "scope 10.0.0.0/8 172.16.0.0/12")
+func (r *Ranges) AddRange(line string) error {
+    if idx := strings.Index(line, "#"); idx >= 0 {
+        line = line[:idx]
+    }
+    fields := strings.Fields(line)
+    if len(fields) == 0 {
+        return nil
+    }
+    if len(fields) < 2 {
+        return errors.New("range must be: <internal|deny|exclude|scope> <cidr>")
+    }
+
+    var list *[]*net.IPNet
+    switch strings.ToLower(fields[0]) {
+    case "internal", "private":
+        list = &r.Internal
+    case "deny", "denied":
+        list = &r.Deny
+    case "exclude", "excluded":
+        list = &r.Exclude
+    case "scope", "include":
+        list = &r.Scope
+    default:
+        return errors.New(fmt.Sprintf("unknown range type (%s)", fields[0]))
+    }
+
+    for _, f := range fields[1:] {
+        n, err := parseRange(f)
+        if err != nil {
+            return err
+        }
+        *list = append(*list, n)
+    }
+
+    return nil
+}
+
+func parseRange(s string) (*net.IPNet, error) {
+    if !strings.Contains(s, "/") {
+        if ip := net.ParseIP(s); ip != nil {
+            if ip.To4() != nil {
+                s += "/32"
+            } else {
+                s += "/128"
+            }
+        }
+    }
+    _, n, err := net.ParseCIDR(s)
+    if err != nil {
+        return nil, err
+    }
+    if ip4 := n.IP.To4(); ip4 != nil {
+        n.IP = ip4
+    }
+    return n, nil
+}
+
+// Apply adds the internal and deny ranges to the private and denied
+// subnet lists, it must be called before reading the packets
+func (r *Ranges) Apply() {
+    for _, n := range r.Internal {
+        privateSubnets = append(privateSubnets, *n)
+    }
+    for _, n := range r.Deny {
+        deniedSubnets = append(deniedSubnets, *n)
+    }
+}
+
+// Classify returns ScopeExcluded if the network is inside an exclude
+// range, ScopeIn if it is inside a scope range (the private ranges when
+// no scope is set) and does not overlap any exclude range, ScopePartial
+// if only part of it is in scope and not excluded and ScopeOut otherwise
+func (r *Ranges) Classify(network *net.IPNet) string {
+    if network == nil {
+        return ScopeOut
+    }
+    if insideAny(network, r.Exclude) {
+        return ScopeExcluded
+    }
+    scope := r.scopeRanges()
+    if !overlapsAny(network, scope) {
+        return ScopeOut
+    }
+    if insideAny(network, scope) && !overlapsAny(network, r.Exclude) {
+        return ScopeIn
+    }
+    return ScopePartial
+}
+
+// scopeRanges returns the scope ranges, the private ranges when no
+// scope is set
+func (r *Ranges) scopeRanges() []*net.IPNet {
+    if len(r.Scope) > 0 {
+        return r.Scope
+    }
+    list := []*net.IPNet{}
+    for i := range privateSubnets {
+        list = append(list, &privateSubnets[i])
+    }
+    return list
+}
+
+// InScope returns true if the address is in scope and not excluded
+func (r *Ranges) InScope(ip net.IP) bool {
+    size := 128
+    if ip4 := ip.To4(); ip4 != nil {
+        ip, size = ip4, 32
+    }
+    return r.Classify(&net.IPNet{ IP: ip, Mask: net.CIDRMask(size, size) }) == ScopeIn
+}
+
+func overlapsAny(network *net.IPNet, list []*net.IPNet) bool {
+    for _, n := range list {
+        if n.Contains(network.IP) || network.Contains(n.IP) {
+            return true
+        }
+    }
+    return false
+}
+
+// insideAny returns true if the network is inside one of the ranges
+func insideAny(network *net.IPNet, list []*net.IPNet) bool {
+    for _, n := range list {
+        if containsNet(n, network) {
+            return true
+        }
+    }
+    return false
+}
+
+// containsNet returns true if the network n is inside the network outer
+func containsNet(outer, n *net.IPNet) bool {
+    outerOnes, outerBits := outer.Mask.Size()
+    ones, bits := n.Mask.Size()
+    return outerBits == bits && outerOnes <= ones && outer.Contains(n.IP)
+}
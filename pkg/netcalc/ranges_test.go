@@ -0,0 +1,53 @@
+package netcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func mustRanges(t *testing.T, lines ...string) *Ranges {
+	r := &Ranges{}
+	for _, l := range lines {
+		if err := r.AddRange(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestClassify(t *testing.T) {
+	scoped := mustRanges(t, "scope 10.1.0.0/16", "exclude 10.1.5.0/24 10.1.9.9")
+	private := mustRanges(t, "exclude 192.168.1.0/24")
+
+	tests := []struct {
+		ranges *Ranges
+		cidr   string
+		want   string
+	}{
+		{scoped, "10.1.2.0/24", ScopeIn},
+		{scoped, "10.1.5.0/24", ScopeExcluded},
+		{scoped, "10.1.5.128/25", ScopeExcluded},
+		{scoped, "10.1.9.0/24", ScopePartial},
+		{scoped, "10.0.0.0/8", ScopePartial},
+		{scoped, "10.2.0.0/16", ScopeOut},
+		{private, "10.0.0.0/24", ScopeIn},
+		{private, "192.168.0.0/16", ScopePartial},
+		{private, "192.168.1.0/24", ScopeExcluded},
+		{private, "8.0.0.0/7", ScopeOut},
+		{private, "fd00:1::/64", ScopeIn},
+	}
+
+	for _, tt := range tests {
+		_, n, err := net.ParseCIDR(tt.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tt.ranges.Classify(n); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.cidr, tt.want, got)
+		}
+	}
+
+	if !scoped.InScope(net.ParseIP("10.1.9.8")) || scoped.InScope(net.ParseIP("10.1.9.9")) {
+		t.Error("InScope does not honor the /32 exclude")
+	}
+}
@@ -0,0 +1,448 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "encoding/binary"
+    "fmt"
+    "net"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// Routing protocols
+const (
+    ProtoOSPF       = "ospf"
+    ProtoRIP        = "rip"
+    ProtoRIPng      = "ripng"
+    ProtoEIGRP      = "eigrp"
+    ProtoHSRP       = "hsrp"
+    ProtoVRRP       = "vrrp"
+)
+
+const (
+    ripPort         = 520
+    ripngPort       = 521
+    hsrpPort        = 1985
+    hsrp6Port       = 2029
+
+    ipProtoEIGRP    = 88
+    ipProtoOSPF     = 89
+)
+
+// Route is a prefix advertised by a routing protocol, or a first hop
+// redundancy protocol virtual address (VirtualIP set, /32 or /128)
+type Route struct {
+    Network         net.IPNet
+    Protocol        string
+    // address of the router that sent the packet
+    Router          string
+    Metric          int
+    // LSA type, route type, redundancy group, etc.
+    Info            string
+    VirtualIP       bool
+}
+
+// CIDR returns the prefix in CIDR notation
+func (r Route) CIDR() string {
+    return r.Network.String()
+}
+
+// Key returns a string identifying the route, used to remove duplicates
+func (r Route) Key() string {
+    return fmt.Sprintf("%s|%s|%s|%s", r.Protocol, r.CIDR(), r.Router, r.Info)
+}
+
+// Subnet returns the route as SubnetData with its advertised mask and
+// marked with the protocol and router. The mask of a virtual IP is not
+// advertised, it is returned as a host address (/32 or /128), see
+// MaskInference for its inferred subnet
+func (r Route) Subnet() SubnetData {
+    ones, _ := r.Network.Mask.Size()
+    s := NewSubnetFromIPMask(r.Network.IP, ones)
+    s.Source = fmt.Sprintf("%s %s", r.Protocol, r.Router)
+    return s
+}
+
+func newRoute(protocol string, router net.IP, ip net.IP, ones int, bits int) Route {
+    if bits == 32 {
+        ip = ip.To4()
+    }
+    mask := net.CIDRMask(ones, bits)
+    return Route{
+        Network     : net.IPNet{ IP: ip.Mask(mask), Mask: mask },
+        Protocol    : protocol,
+        Router      : router.String(),
+    }
+}
+
+func ip4(v uint32) net.IP {
+    ip := make(net.IP, 4)
+    binary.BigEndian.PutUint32(ip, v)
+    return ip
+}
+
+// GetRoutesFromPacket decodes OSPFv2 (hello and LS update), RIPv1/v2,
+// RIPng and EIGRP (IPv4 internal/external routes) prefixes and the
+// HSRP/VRRP virtual addresses
+func GetRoutesFromPacket(packet gopacket.Packet) []Route {
+    routes := []Route{}
+
+    var srcIP net.IP
+    var proto layers.IPProtocol
+    var payload []byte
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        srcIP, proto, payload = ipv4.SrcIP, ipv4.Protocol, ipv4.Payload
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        srcIP, proto, payload = ipv6.SrcIP, ipv6.NextHeader, ipv6.Payload
+    } else {
+        return routes
+    }
+
+    switch proto {
+    case ipProtoOSPF:
+        if srcIP.To4() != nil {
+            return parseOSPFv2(payload, srcIP)
+        }
+        return routes
+    case ipProtoEIGRP:
+        return parseEIGRP(payload, srcIP)
+    case layers.IPProtocolVRRP:
+        return parseVRRP(payload, srcIP)
+    }
+
+    udpLayer := packet.Layer(layers.LayerTypeUDP)
+    if udpLayer == nil {
+        return routes
+    }
+    udp := udpLayer.(*layers.UDP)
+
+    switch {
+    case udp.SrcPort == ripPort && udp.DstPort == ripPort:
+        return parseRIP(udp.Payload, srcIP)
+    case udp.SrcPort == ripngPort && udp.DstPort == ripngPort:
+        return parseRIPng(udp.Payload, srcIP)
+    case udp.DstPort == hsrpPort || udp.DstPort == hsrp6Port:
+        return parseHSRP(udp.Payload, srcIP)
+    }
+
+    return routes
+}
+
+// parseOSPFv2 decodes the hello network mask and the router, network,
+// summary and external LSAs of a link state update (RFC 2328 A.3, A.4)
+func parseOSPFv2(data []byte, srcIP net.IP) []Route {
+    routes := []Route{}
+    if len(data) < 24 || data[0] != 2 {
+        return routes
+    }
+    length := int(binary.BigEndian.Uint16(data[2:4]))
+    if length < 24 || length > len(data) {
+        return routes
+    }
+    data = data[:length]
+
+    switch data[1] {
+    case 1: // hello
+        if len(data) >= 28 {
+            mask := net.IPMask(data[24:28])
+            ones, bits := mask.Size()
+            if bits == 32 && ones > 0 {
+                r := newRoute(ProtoOSPF, srcIP, srcIP, ones, 32)
+                r.Info = "hello"
+                routes = append(routes, r)
+            }
+        }
+
+    case 4: // link state update
+        if len(data) < 28 {
+            return routes
+        }
+        count := int(binary.BigEndian.Uint32(data[24:28]))
+        off := 28
+        for i := 0; i < count && off + 20 <= len(data); i++ {
+            lsType := data[off+3]
+            lsID := binary.BigEndian.Uint32(data[off+4:off+8])
+            advRouter := ip4(binary.BigEndian.Uint32(data[off+8:off+12]))
+            lsLen := int(binary.BigEndian.Uint16(data[off+18:off+20]))
+            if lsLen < 20 || off + lsLen > len(data) {
+                break
+            }
+            body := data[off+20:off+lsLen]
+            off += lsLen
+
+            add := func(network uint32, mask uint32, metric int, info string) {
+                ones, bits := net.IPMask(ip4(mask)).Size()
+                if bits != 32 {
+                    return
+                }
+                r := newRoute(ProtoOSPF, srcIP, ip4(network), ones, 32)
+                r.Metric = metric
+                r.Info = fmt.Sprintf("%s adv %s", info, advRouter)
+                routes = append(routes, r)
+            }
+
+            switch lsType {
+            case 1: // router LSA, stub links carry network and mask
+                if len(body) < 4 {
+                    continue
+                }
+                links := int(binary.BigEndian.Uint16(body[2:4]))
+                p := 4
+                for l := 0; l < links && p + 12 <= len(body); l++ {
+                    linkID := binary.BigEndian.Uint32(body[p:p+4])
+                    linkData := binary.BigEndian.Uint32(body[p+4:p+8])
+                    linkType := body[p+8]
+                    tos := int(body[p+9])
+                    metric := int(binary.BigEndian.Uint16(body[p+10:p+12]))
+                    if linkType == 3 {
+                        add(linkID, linkData, metric, "router-lsa stub")
+                    }
+                    p += 12 + (tos * 4)
+                }
+            case 2: // network LSA, LSID is the DR interface address
+                if len(body) >= 4 {
+                    add(lsID, binary.BigEndian.Uint32(body[0:4]), 0, "network-lsa")
+                }
+            case 3: // summary LSA
+                if len(body) >= 8 {
+                    add(lsID, binary.BigEndian.Uint32(body[0:4]), int(binary.BigEndian.Uint32(body[4:8]) & 0x00ffffff), "summary-lsa")
+                }
+            case 5, 7: // AS external and NSSA LSAs
+                if len(body) >= 8 {
+                    info := "external-lsa"
+                    if lsType == 7 {
+                        info = "nssa-lsa"
+                    }
+                    add(lsID, binary.BigEndian.Uint32(body[0:4]), int(binary.BigEndian.Uint32(body[4:8]) & 0x00ffffff), info)
+                }
+            }
+        }
+    }
+
+    return routes
+}
+
+// parseRIP decodes RIPv1/v2 responses (RFC 1058, RFC 2453)
+func parseRIP(data []byte, srcIP net.IP) []Route {
+    routes := []Route{}
+    if len(data) < 4 || data[0] != 2 {
+        return routes
+    }
+    version := data[1]
+
+    for off := 4; off + 20 <= len(data); off += 20 {
+        afi := binary.BigEndian.Uint16(data[off:off+2])
+        if afi != 2 {
+            // 0xffff is the authentication entry
+            continue
+        }
+        ip := net.IP(append([]byte{}, data[off+4:off+8]...))
+        mask := net.IPMask(data[off+8:off+12])
+        metric := int(binary.BigEndian.Uint32(data[off+16:off+20]))
+        if metric >= 16 {
+            continue
+        }
+
+        ones, bits := mask.Size()
+        if version == 1 || bits != 32 || (ones == 0 && !ip.Equal(net.IPv4zero)) {
+            ones = classfulMask(ip)
+        }
+
+        r := newRoute(ProtoRIP, srcIP, ip, ones, 32)
+        r.Metric = metric
+        r.Info = fmt.Sprintf("ripv%d", version)
+        routes = append(routes, r)
+    }
+
+    return routes
+}
+
+// classfulMask returns the mask length of the address class (RIPv1)
+func classfulMask(ip net.IP) int {
+    switch {
+    case ip[0] < 128:
+        return 8
+    case ip[0] < 192:
+        return 16
+    }
+    return 24
+}
+
+// parseRIPng decodes RIPng responses (RFC 2080)
+func parseRIPng(data []byte, srcIP net.IP) []Route {
+    routes := []Route{}
+    if len(data) < 4 || data[0] != 2 {
+        return routes
+    }
+
+    for off := 4; off + 20 <= len(data); off += 20 {
+        plen := int(data[off+18])
+        metric := int(data[off+19])
+        // 0xff is the next hop entry
+        if metric == 0xff || metric >= 16 || plen > 128 {
+            continue
+        }
+        r := newRoute(ProtoRIPng, srcIP, net.IP(append([]byte{}, data[off:off+16]...)), plen, 128)
+        r.Metric = metric
+        routes = append(routes, r)
+    }
+
+    return routes
+}
+
+// parseEIGRP decodes the IPv4 internal and external route TLVs of EIGRP
+// updates, queries and replies
+func parseEIGRP(data []byte, srcIP net.IP) []Route {
+    routes := []Route{}
+    if len(data) < 20 || data[0] != 2 {
+        return routes
+    }
+    opcode := data[1]
+    if opcode != 1 && opcode != 3 && opcode != 4 {
+        return routes
+    }
+
+    off := 20
+    for off + 4 <= len(data) {
+        tlvType := binary.BigEndian.Uint16(data[off:off+2])
+        tlvLen := int(binary.BigEndian.Uint16(data[off+2:off+4]))
+        if tlvLen < 4 || off + tlvLen > len(data) {
+            break
+        }
+        v := data[off+4:off+tlvLen]
+        off += tlvLen
+
+        // offsets of the delay and of the first destination
+        var info string
+        var delayOff, dest int
+        switch tlvType {
+        case 0x0102:
+            info, delayOff, dest = "internal", 4, 20
+        case 0x0103:
+            info, delayOff, dest = "external", 24, 40
+        default:
+            continue
+        }
+        if len(v) <= dest {
+            continue
+        }
+
+        delay := binary.BigEndian.Uint32(v[delayOff:delayOff+4])
+        if delay == 0xffffffff {
+            // unreachable
+            continue
+        }
+
+        // one or more (prefix length, destination) pairs
+        p := dest
+        for p < len(v) {
+            plen := int(v[p])
+            size := (plen + 7) / 8
+            if plen > 32 || p + 1 + size > len(v) {
+                break
+            }
+            ip := make(net.IP, 4)
+            copy(ip, v[p+1:p+1+size])
+            r := newRoute(ProtoEIGRP, srcIP, ip, plen, 32)
+            r.Metric = int(delay)
+            r.Info = info
+            routes = append(routes, r)
+            p += 1 + size
+        }
+    }
+
+    return routes
+}
+
+// parseHSRP decodes HSRPv1 and HSRPv2 (group state TLV) virtual addresses
+func parseHSRP(data []byte, srcIP net.IP) []Route {
+    routes := []Route{}
+
+    if len(data) >= 20 && data[0] == 0 {
+        vip := net.IP(append([]byte{}, data[16:20]...))
+        if !vip.IsUnspecified() {
+            r := newRoute(ProtoHSRP, srcIP, vip, 32, 32)
+            r.VirtualIP = true
+            r.Info = fmt.Sprintf("group %d", data[6])
+            routes = append(routes, r)
+        }
+        return routes
+    }
+
+    for off := 0; off + 2 <= len(data); {
+        tlvType := data[off]
+        tlvLen := int(data[off+1])
+        if off + 2 + tlvLen > len(data) {
+            break
+        }
+        v := data[off+2:off+2+tlvLen]
+        off += 2 + tlvLen
+
+        if tlvType != 1 || len(v) < 40 {
+            continue
+        }
+        group := binary.BigEndian.Uint16(v[4:6])
+        var r Route
+        if v[3] == 6 {
+            vip := net.IP(append([]byte{}, v[24:40]...))
+            if vip.IsUnspecified() {
+                continue
+            }
+            r = newRoute(ProtoHSRP, srcIP, vip, 128, 128)
+        } else {
+            vip := net.IP(append([]byte{}, v[24:28]...))
+            if vip.IsUnspecified() {
+                continue
+            }
+            r = newRoute(ProtoHSRP, srcIP, vip, 32, 32)
+        }
+        r.VirtualIP = true
+        r.Info = fmt.Sprintf("group %d", group)
+        routes = append(routes, r)
+    }
+
+    return routes
+}
+
+// parseVRRP decodes VRRPv2 and VRRPv3 virtual addresses
+func parseVRRP(data []byte, srcIP net.IP) []Route {
+    routes := []Route{}
+    if len(data) < 8 {
+        return routes
+    }
+    version := data[0] >> 4
+    if version != 2 && version != 3 {
+        return routes
+    }
+    vrid := data[1]
+    count := int(data[3])
+
+    size := 4
+    if srcIP.To4() == nil {
+        size = 16
+    }
+    for i, off := 0, 8; i < count && off + size <= len(data); i, off = i + 1, off + size {
+        vip := net.IP(append([]byte{}, data[off:off+size]...))
+        r := newRoute(ProtoVRRP, srcIP, vip, size * 8, size * 8)
+        r.VirtualIP = true
+        r.Info = fmt.Sprintf("vrid %d", vrid)
+        routes = append(routes, r)
+    }
+
+    return routes
+}
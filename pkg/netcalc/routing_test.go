@@ -0,0 +1,29 @@
+package netcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRouteSubnet(t *testing.T) {
+	tests := []struct {
+		name  string
+		route Route
+		want  string
+	}{
+		{"ospf route", newRoute(ProtoOSPF, net.IP{10, 60, 1, 1}, net.IP{10, 61, 0, 0}, 16, 32), "10.61.0.0/16"},
+		{"hsrp virtual ip", vip(ProtoHSRP, "10.0.0.2", "10.0.0.1"), "10.0.0.1/32"},
+		{"vrrp virtual ip", vip(ProtoVRRP, "192.168.1.2", "192.168.1.254"), "192.168.1.254/32"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.route.Subnet()
+			if s.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, s.String())
+			}
+			if s.Source == "" {
+				t.Errorf("missing source")
+			}
+		})
+	}
+}
@@ -16,11 +16,10 @@ package netcalc
 import (
     "net"
     "fmt"
+    "encoding/binary"
 
     "github.com/google/gopacket"
     "github.com/google/gopacket/layers"
-    "github.com/helviojunior/pcapraptor/pkg/log"
-
 )
 
 
@@ -37,6 +36,16 @@ var privateSubnets = []net.IPNet{
             IP:   net.IPv4(172, 16, 0, 0),
             Mask: net.CIDRMask(12, 32), 
         },
+        {
+            // RFC 6598 shared address space (carrier-grade NAT)
+            IP:   net.IPv4(100, 64, 0, 0),
+            Mask: net.CIDRMask(10, 32), 
+        },
+        {
+            // IPv6 Unique Local Addresses
+            IP:   net.ParseIP("fc00::"),
+            Mask: net.CIDRMask(7, 128), 
+        },
     }
 
 var deniedSubnets = []net.IPNet{
@@ -56,12 +65,30 @@ var deniedSubnets = []net.IPNet{
             IP:   net.IPv4(224, 0, 0, 0),
             Mask: net.CIDRMask(3, 32), // 224.0.0.0
         },
+        {
+            IP:   net.ParseIP("::"),
+            Mask: net.CIDRMask(127, 128), // unspecified and loopback
+        },
+        {
+            IP:   net.ParseIP("fe80::"),
+            Mask: net.CIDRMask(10, 128), // link-local
+        },
+        {
+            IP:   net.ParseIP("ff00::"),
+            Mask: net.CIDRMask(8, 128), // multicast
+        },
     }
 
+// Default prefix length of IPv6 subnets inferred from a single address
+const defaultIPv6Mask = 64
+
 type SubnetData struct {
-    Net             string
-    Mask            int
-    IsPrivate       bool
+    Net             string              `json:"net"`
+    Mask            int                 `json:"mask"`
+    IsPrivate       bool                `json:"is_private"`
+    IsIPv6          bool                `json:"is_ipv6"`
+    // protocol and router that advertised the subnet (routing protocols)
+    Source          string              `json:"source,omitempty"`
 }
 
 
@@ -73,6 +100,20 @@ func (subnet SubnetData) To4() net.IP {
     return ipnet.IP.To4()
 }
 
+// IPNet returns the subnet as net.IPNet (IPv4 or IPv6)
+func (subnet SubnetData) IPNet() *net.IPNet {
+    _, ipnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", subnet.Net, subnet.Mask))
+    if err != nil {
+        return nil
+    }
+    return ipnet
+}
+
+// String returns the subnet in CIDR notation
+func (subnet SubnetData) String() string {
+    return fmt.Sprintf("%s/%d", subnet.Net, subnet.Mask)
+}
+
 func IsPrivateIP(ip net.IP) bool {
     for _, subnet := range privateSubnets {
         if subnet.Contains(ip) {
@@ -83,6 +124,9 @@ func IsPrivateIP(ip net.IP) bool {
 }
 
 func NewSubnetFromIP(ip net.IP) SubnetData {
+    if ip.To4() == nil {
+        return NewSubnetFromIPMask(ip, defaultIPv6Mask)
+    }
     return SubnetData{
         Net: ip.Mask(net.CIDRMask(24, 32)).String(),
         Mask: 24,
@@ -92,6 +136,14 @@ func NewSubnetFromIP(ip net.IP) SubnetData {
 }
 
 func NewSubnetFromIPMask(ip net.IP, cidr int) SubnetData {
+    if ip4 := ip.To4(); ip4 == nil && len(ip) == net.IPv6len {
+        return SubnetData{
+            Net: ip.Mask(net.CIDRMask(cidr, 128)).String(),
+            Mask: cidr,
+            IsPrivate: IsPrivateIP(ip),
+            IsIPv6: true,
+        }
+    }
     return SubnetData{
         Net: ip.Mask(net.CIDRMask(cidr, 32)).String(),
         Mask: cidr,
@@ -100,7 +152,7 @@ func NewSubnetFromIPMask(ip net.IP, cidr int) SubnetData {
 }
 
 func AddSlice(subnetList *[]SubnetData, data SubnetData) {
-    if data.Net == "" || data.Net == "0.0.0.0" {
+    if data.Net == "" || data.Net == "0.0.0.0" || data.Net == "::" {
         return
     }
 
@@ -113,8 +165,11 @@ func AddSlice(subnetList *[]SubnetData, data SubnetData) {
         return
     }
 
-    for _, subnet := range *subnetList {
+    for i, subnet := range *subnetList {
         if subnet.Net == data.Net && subnet.Mask == data.Mask {
+            if subnet.Source == "" {
+                (*subnetList)[i].Source = data.Source
+            }
             return
         }
     }
@@ -122,79 +177,232 @@ func AddSlice(subnetList *[]SubnetData, data SubnetData) {
     *subnetList = append(*subnetList, data)
 }
 
-func GetSubnetsFromPacket(packet gopacket.Packet) []SubnetData {
+// ARPEvidence is an IPv4 ARP message
+type ARPEvidence struct {
+    Request         bool
+    SrcMAC          net.HardwareAddr
+    SrcIP           net.IP
+    DstIP           net.IP
+}
+
+// DHCPEvidence is a DHCPv4 reply
+type DHCPEvidence struct {
+    // address given to the client, nil for replies without one (NAK)
+    IP              net.IP
+    // prefix length of the subnet mask option, zero when missing
+    Mask            int
+    Routers         []net.IP
+    Server          net.IP
+}
+
+// RAEvidence is an IPv6 router advertisement
+type RAEvidence struct {
+    // source link-layer address option, or the frame source
+    MAC             net.HardwareAddr
+    Prefixes        []SubnetData
+}
+
+// NAEvidence is the target link-layer address of an IPv6 neighbor
+// advertisement
+type NAEvidence struct {
+    MAC             net.HardwareAddr
+    IP              net.IP
+}
 
-    subnetList := []SubnetData{}
+// PacketEvidence is the subnet evidence of a packet, decoded once by
+// GetEvidenceFromPacket and shared by the subnet inference and the
+// gateway collector
+type PacketEvidence struct {
+    // 802.1Q VLAN IDs, see GetVLANFromPacket
+    VLAN            string
+    SrcMAC          net.HardwareAddr
+    DstMAC          net.HardwareAddr
+    SrcIP           net.IP
+    DstIP           net.IP
+    // TTL or hop limit
+    TTL             uint8
+    // TCP segment of an established connection
+    Established     bool
+    ARP             *ARPEvidence
+    DHCP            *DHCPEvidence
+    // prefix length of an ICMP address mask reply
+    MaskReply       int
+    RA              *RAEvidence
+    NA              *NAEvidence
+    // DHCPv6 addresses (IA_NA) and delegated prefixes (IA_PD)
+    DHCPv6Addrs     []SubnetData
+    DHCPv6Prefixes  []SubnetData
+    // OSPF, RIP, EIGRP, HSRP and VRRP
+    Routes          []Route
+    // LLDP, CDP, FDP and EDP
+    Neighbor        *Neighbor
+    // NBNS, LLMNR, mDNS and DNS PTR
+    Names           []HostName
+}
 
-    defaultMask := net.CIDRMask(24, 32)
+// GetEvidenceFromPacket decodes the subnet evidence of the packet
+func GetEvidenceFromPacket(packet gopacket.Packet) *PacketEvidence {
+    e := &PacketEvidence{ VLAN: GetVLANFromPacket(packet) }
+
+    if ethLayer := packet.Layer(layers.LayerTypeEthernet); ethLayer != nil {
+        eth := ethLayer.(*layers.Ethernet)
+        e.SrcMAC, e.DstMAC = eth.SrcMAC, eth.DstMAC
+    }
 
-    //Try to parse if is an ARP packet
     if arpLayer := packet.Layer(layers.LayerTypeARP); arpLayer != nil {
         arp := arpLayer.(*layers.ARP)
-        if arp.Protocol == 0x0800 { // IPv4    && arp.Operation == layers.ARPReply {
-            AddSlice(&subnetList, NewSubnetFromIP(net.IP{ arp.SourceProtAddress[0], arp.SourceProtAddress[1], arp.SourceProtAddress[2], arp.SourceProtAddress[3] }))
-            AddSlice(&subnetList, NewSubnetFromIP(net.IP{ arp.DstProtAddress[0], arp.DstProtAddress[1], arp.DstProtAddress[2], arp.DstProtAddress[3] }))
+        if arp.Protocol == layers.EthernetTypeIPv4 && len(arp.SourceProtAddress) == 4 && len(arp.DstProtAddress) == 4 {
+            e.ARP = &ARPEvidence{
+                Request     : arp.Operation == layers.ARPRequest,
+                SrcMAC      : net.HardwareAddr(arp.SourceHwAddress),
+                SrcIP       : net.IP(arp.SourceProtAddress),
+                DstIP       : net.IP(arp.DstProtAddress),
+            }
         }
     }
 
+    if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+        tcp := tcpLayer.(*layers.TCP)
+        e.Established = tcp.ACK && !(tcp.FIN || tcp.RST)
+    }
+
     if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
-        ipv4 := ipLayer.(*layers.IPv4)  
-        
-        //TCP
-        if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
-            tcp := tcpLayer.(*layers.TCP)
-            if tcp.ACK && !(tcp.FIN || tcp.RST) {
-                AddSlice(&subnetList, NewSubnetFromIP(net.IP{ ipv4.SrcIP[0], ipv4.SrcIP[1], ipv4.SrcIP[2], ipv4.SrcIP[3] }))
-                AddSlice(&subnetList, NewSubnetFromIP(net.IP{ ipv4.DstIP[0], ipv4.DstIP[1], ipv4.DstIP[2], ipv4.DstIP[3] }))
-            }
-        }
+        ipv4 := ipLayer.(*layers.IPv4)
+        e.SrcIP, e.DstIP, e.TTL = ipv4.SrcIP.To4(), ipv4.DstIP.To4(), ipv4.TTL
 
         //DHCP
         if dhcpLayer := packet.Layer(layers.LayerTypeDHCPv4); dhcpLayer != nil {
-            dhcp := dhcpLayer.(*layers.DHCPv4)
-            if dhcp.Operation == layers.DHCPOpReply {
-                isOffer := false
-                for _, o := range dhcp.Options {
-                    if o.Type == layers.DHCPOptMessageType && (layers.DHCPMsgType(o.Data[0]) == layers.DHCPMsgTypeOffer || layers.DHCPMsgType(o.Data[0]) == layers.DHCPMsgTypeAck) {
-                        isOffer = true
-                    }
+            e.DHCP = getDHCPEvidence(dhcpLayer.(*layers.DHCPv4))
+        }
+
+        //ICMP address mask reply
+        if icmpLayer := packet.Layer(layers.LayerTypeICMPv4); icmpLayer != nil {
+            icmp := icmpLayer.(*layers.ICMPv4)
+            if icmp.TypeCode.Type() == layers.ICMPv4TypeAddressMaskReply && len(icmp.Payload) >= 4 {
+                if ones, size := net.IPMask(icmp.Payload[0:4]).Size(); size == 32 {
+                    e.MaskReply = ones
                 }
-                
-                if isOffer {
-                    router := dhcp.YourClientIP
-                    for _, o := range dhcp.Options {
-                        if o.Type == layers.DHCPOptSubnetMask {
-                            defaultMask = net.IPv4Mask(o.Data[0], o.Data[1], o.Data[2], o.Data[3])
-                        }
-                    }
-                    ones, _ := defaultMask.Size()
-
-                    AddSlice(&subnetList, NewSubnetFromIPMask(dhcp.YourClientIP, ones))
-
-                    for _, o := range dhcp.Options {
-                        if o.Type == layers.DHCPOptRouter {
-                            router = net.IP{ o.Data[0], o.Data[1], o.Data[2], o.Data[3] }
-                            AddSlice(&subnetList, NewSubnetFromIPMask(router, ones))
-                        }else if o.Type  == layers.DHCPOptNameServer || o.Type == layers.DHCPOptDNS {
-                            offset := 0
-                            size := len(o.Data)
-                            for offset <= size - 4 {
-                                AddSlice(&subnetList, NewSubnetFromIP(net.IP{ o.Data[offset], o.Data[offset + 1], o.Data[offset + 2], o.Data[offset + 3] }))
-                                offset += 4
-                            }
-                            
-                        }
+            }
+        }
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        e.SrcIP, e.DstIP, e.TTL = ipv6.SrcIP, ipv6.DstIP, ipv6.HopLimit
+
+        //Router Advertisement (Prefix Information options)
+        if raLayer := packet.Layer(layers.LayerTypeICMPv6RouterAdvertisement); raLayer != nil {
+            ra := raLayer.(*layers.ICMPv6RouterAdvertisement)
+            e.RA = &RAEvidence{ MAC: e.SrcMAC }
+            for _, o := range ra.Options {
+                switch {
+                case o.Type == layers.ICMPv6OptSourceAddress && len(o.Data) == 6:
+                    e.RA.MAC = net.HardwareAddr(o.Data)
+                case o.Type == layers.ICMPv6OptPrefixInfo && len(o.Data) >= 30:
+                    prefixLen := int(o.Data[0])
+                    if prefixLen > 0 && prefixLen <= 128 {
+                        e.RA.Prefixes = append(e.RA.Prefixes, NewSubnetFromIPMask(net.IP(o.Data[14:30]), prefixLen))
                     }
+                }
+            }
+        }
+
+        if naLayer := packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement); naLayer != nil {
+            na := naLayer.(*layers.ICMPv6NeighborAdvertisement)
+            for _, o := range na.Options {
+                if o.Type == layers.ICMPv6OptTargetAddress && len(o.Data) == 6 {
+                    e.NA = &NAEvidence{ MAC: net.HardwareAddr(o.Data), IP: na.TargetAddress }
+                }
+            }
+        }
 
-                    log.Debug("DHCP Offer/ACK", "IP", dhcp.YourClientIP, "Mask", net.IP(defaultMask).String(), "Router", router)
+        //DHCPv6
+        if dhcpLayer := packet.Layer(layers.LayerTypeDHCPv6); dhcpLayer != nil {
+            dhcp := dhcpLayer.(*layers.DHCPv6)
+            if dhcp.MsgType == layers.DHCPv6MsgTypeAdverstise || dhcp.MsgType == layers.DHCPv6MsgTypeReply {
+                for _, o := range dhcp.Options {
+                    switch o.Code {
+                    case layers.DHCPv6OptIANA:
+                        e.DHCPv6Addrs = append(e.DHCPv6Addrs, parseDHCPv6IA(o.Data, 12, layers.DHCPv6OptIAAddr)...)
+                    case layers.DHCPv6OptIAPD:
+                        e.DHCPv6Prefixes = append(e.DHCPv6Prefixes, parseDHCPv6IA(o.Data, 12, layers.DHCPv6OptIAPrefix)...)
+                    }
                 }
             }
         }
+    }
+
+    e.Routes = GetRoutesFromPacket(packet)
+    e.Neighbor = GetNeighborFromPacket(packet)
+    e.Names = GetNamesFromPacket(packet)
+
+    return e
+}
 
-        //TODO: Implement NBNS, LLMNR and MDNS
+// getDHCPEvidence returns the address, mask, routers and server of a
+// DHCPv4 reply, nil for requests
+func getDHCPEvidence(dhcp *layers.DHCPv4) *DHCPEvidence {
+    if dhcp.Operation != layers.DHCPOpReply {
+        return nil
+    }
+
+    d := &DHCPEvidence{ IP: dhcp.YourClientIP, Server: dhcp.NextServerIP }
+    if d.IP == nil || d.IP.IsUnspecified() {
+        d.IP = dhcp.ClientIP
+    }
+    if d.IP != nil && d.IP.IsUnspecified() {
+        d.IP = nil
+    }
+    for _, o := range dhcp.Options {
+        switch o.Type {
+        case layers.DHCPOptSubnetMask:
+            if len(o.Data) == 4 {
+                d.Mask, _ = net.IPMask(o.Data).Size()
+            }
+        case layers.DHCPOptRouter:
+            for off := 0; off + 4 <= len(o.Data); off += 4 {
+                d.Routers = append(d.Routers, net.IP(append([]byte{}, o.Data[off:off+4]...)))
+            }
+        case layers.DHCPOptServerID:
+            if len(o.Data) == 4 {
+                d.Server = net.IP(o.Data)
+            }
+        }
     }
+    if d.Server != nil && d.Server.IsUnspecified() {
+        d.Server = nil
+    }
+    return d
+}
+
+// parseDHCPv6IA parses the IA_NA/IA_PD sub options (IAADDR or IAPREFIX)
+func parseDHCPv6IA(data []byte, offset int, code layers.DHCPv6Opt) []SubnetData {
+    subnets := []SubnetData{}
+    for offset + 4 <= len(data) {
+        optCode := layers.DHCPv6Opt(binary.BigEndian.Uint16(data[offset:offset+2]))
+        optLen := int(binary.BigEndian.Uint16(data[offset+2:offset+4]))
+        start := offset + 4
+        if start + optLen > len(data) {
+            break
+        }
+        opt := data[start:start+optLen]
+
+        if optCode == code {
+            switch code {
+            case layers.DHCPv6OptIAAddr:
+                // address(16), preferred lifetime(4), valid lifetime(4)
+                if len(opt) >= 16 {
+                    subnets = append(subnets, NewSubnetFromIPMask(net.IP(opt[0:16]), defaultIPv6Mask))
+                }
+            case layers.DHCPv6OptIAPrefix:
+                // preferred lifetime(4), valid lifetime(4), prefix length(1), prefix(16)
+                if len(opt) >= 25 && opt[8] > 0 && opt[8] <= 128 {
+                    subnets = append(subnets, NewSubnetFromIPMask(net.IP(opt[9:25]), int(opt[8])))
+                }
+            }
+        }
 
-    return subnetList;
+        offset = start + optLen
+    }
+    return subnets
 }
 
 func isDeniedIP(ip net.IP) bool {
@@ -15,6 +15,7 @@ package netcalc
 
 
 import (
+    "bytes"
     "encoding/binary"
     "fmt"
     "net"
@@ -26,6 +27,9 @@ import (
 var _DISTANCE = uint32(512)
 var _CIDR = uint32(8)
 
+// IPv6 subnets are grouped by site prefix (/48)
+var _CIDR6 = 48
+
 type ipNetGroup []net.IPNet
 
 func ipToUint32(ip net.IP) uint32 {
@@ -79,11 +83,16 @@ func CalculateSupernet(ips []net.IPNet) *net.IPNet {
     if len(ips) == 1 {
         return &net.IPNet{IP: ips[0].IP, Mask: ips[0].Mask}
     }
+
+    if ips[0].IP.To4() == nil {
+        return calculateSupernet6(ips)
+    }
     
     min := ipToUint32(ips[0].IP)
     max := ipToUint32(ips[0].IP)
+    prefix := 32
 
-    for _, ipnet := range ips[1:] {
+    for _, ipnet := range ips {
         ip := ipToUint32(ipnet.IP)
         if ip < min {
             min = ip
@@ -91,15 +100,65 @@ func CalculateSupernet(ips []net.IPNet) *net.IPNet {
         if ip > max {
             max = ip
         }
+        if ones, bits := ipnet.Mask.Size(); bits == 32 && ones < prefix {
+            prefix = ones
+        }
     }
 
-    prefix := commonPrefix(min, max)
+    if cp := commonPrefix(min, max); cp < prefix {
+        prefix = cp
+    }
     mask := net.CIDRMask(prefix, 32)
     network := uint32ToIP(min & binary.BigEndian.Uint32(mask))
 
     return &net.IPNet{IP: network, Mask: mask}
 }
 
+// calculateSupernet6 returns the smallest IPv6 network covering all ips
+func calculateSupernet6(ips []net.IPNet) *net.IPNet {
+    min := ips[0].IP.To16()
+    max := ips[0].IP.To16()
+    prefix := 128
+
+    for _, ipnet := range ips {
+        ip := ipnet.IP.To16()
+        if bytes.Compare(ip, min) < 0 {
+            min = ip
+        }
+        if bytes.Compare(ip, max) > 0 {
+            max = ip
+        }
+        if ones, _ := ipnet.Mask.Size(); ones < prefix {
+            prefix = ones
+        }
+    }
+
+    if cp := commonPrefix6(min, max); cp < prefix {
+        prefix = cp
+    }
+    mask := net.CIDRMask(prefix, 128)
+
+    return &net.IPNet{IP: min.Mask(mask), Mask: mask}
+}
+
+func commonPrefix6(a, b net.IP) int {
+    prefix := 0
+    for i := 0; i < net.IPv6len; i++ {
+        diff := a[i] ^ b[i]
+        if diff == 0 {
+            prefix += 8
+            continue
+        }
+        for bit := 7; bit >= 0; bit-- {
+            if diff&(1<<bit) != 0 {
+                return prefix
+            }
+            prefix++
+        }
+    }
+    return prefix
+}
+
 // agrupa IPs por faixa privada
 func getPrivateRange(ip net.IP) string {
     if ip[0] == 10 {
@@ -128,6 +187,7 @@ func SetLowerCIDR(cidr uint32) error {
 
 func GroupSubnets(subnets []string) [][]net.IPNet {
     grouped := map[string][]net.IPNet{}
+    grouped6 := map[string][]net.IPNet{}
 
     // Parse e agrupa por faixa privada
     for _, cidr := range subnets {
@@ -136,6 +196,16 @@ func GroupSubnets(subnets []string) [][]net.IPNet {
             fmt.Println("Erro CIDR:", cidr)
             continue
         }
+        if ipnet.IP.To4() == nil {
+            // IPv6: group by site prefix
+            site := ipnet.IP.Mask(net.CIDRMask(_CIDR6, 128))
+            if ones, _ := ipnet.Mask.Size(); ones < _CIDR6 {
+                site = ipnet.IP
+            }
+            r := "v6:" + site.String()
+            grouped6[r] = append(grouped6[r], *ipnet)
+            continue
+        }
         r := getPrivateRange(ipnet.IP)
         grouped[r] = append(grouped[r], *ipnet)
     }
@@ -177,5 +247,12 @@ func GroupSubnets(subnets []string) [][]net.IPNet {
         }
     }
 
+    for _, group := range grouped6 {
+        sort.Slice(group, func(i, j int) bool {
+            return bytes.Compare(group[i].IP.To16(), group[j].IP.To16()) < 0
+        })
+        result = append(result, group)
+    }
+
     return result
 }
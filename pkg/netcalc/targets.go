@@ -0,0 +1,226 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "bytes"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "net"
+    "sort"
+    "time"
+)
+
+// Targets returns the IPv4 networks to scan: the parts of the subnets
+// inside the scope ranges (the private ranges when no scope is set),
+// without the exclude ranges (a subnet partially in scope or excluded is
+// split into the CIDRs left) and without networks already inside
+// another one
+func (r *Ranges) Targets(subnets []InferredSubnet) []net.IPNet {
+    scope := r.scopeRanges()
+    list := []net.IPNet{}
+    for _, s := range subnets {
+        network := s.IPNet()
+        if network == nil || network.IP.To4() == nil {
+            continue
+        }
+        network.IP = network.IP.To4()
+        for _, piece := range intersectCIDR(*network, scope) {
+            list = append(list, subtractCIDR(piece, r.Exclude)...)
+        }
+    }
+
+    sort.Slice(list, func(i, j int) bool {
+        a, _ := list[i].Mask.Size()
+        b, _ := list[j].Mask.Size()
+        if a != b {
+            return a < b
+        }
+        return bytes.Compare(list[i].IP, list[j].IP) < 0
+    })
+    targets := []net.IPNet{}
+    for _, n := range list {
+        inside := false
+        for _, t := range targets {
+            if t.Contains(n.IP) {
+                inside = true
+                break
+            }
+        }
+        if !inside {
+            targets = append(targets, n)
+        }
+    }
+    sort.Slice(targets, func(i, j int) bool {
+        return bytes.Compare(targets[i].IP, targets[j].IP) < 0
+    })
+    return targets
+}
+
+// intersectCIDR returns the parts of the IPv4 network inside the ranges,
+// CIDRs are either nested or disjoint so each part is the network itself
+// or a range inside it
+func intersectCIDR(network net.IPNet, ranges []*net.IPNet) []net.IPNet {
+    list := []net.IPNet{}
+    for _, x := range ranges {
+        ip := x.IP.To4()
+        if ip == nil {
+            continue
+        }
+        ones, _ := x.Mask.Size()
+        r := net.IPNet{ IP: ip, Mask: net.CIDRMask(ones, 32) }
+        if containsNet(&r, &network) {
+            return []net.IPNet{ network }
+        }
+        if containsNet(&network, &r) {
+            list = append(list, r)
+        }
+    }
+    return list
+}
+
+// subtractCIDR returns the parts of the network outside all the ranges,
+// as the minimal list of CIDRs
+func subtractCIDR(network net.IPNet, ranges []*net.IPNet) []net.IPNet {
+    overlaps := false
+    for _, x := range ranges {
+        if x.Contains(network.IP) {
+            xOnes, _ := x.Mask.Size()
+            nOnes, _ := network.Mask.Size()
+            if xOnes <= nOnes {
+                return []net.IPNet{}
+            }
+        }
+        if x.Contains(network.IP) || network.Contains(x.IP) {
+            overlaps = true
+        }
+    }
+    ones, bits := network.Mask.Size()
+    if !overlaps || ones == bits {
+        return []net.IPNet{ network }
+    }
+
+    // split in two halves
+    mask := net.CIDRMask(ones + 1, bits)
+    low := net.IPNet{ IP: append(net.IP{}, network.IP...), Mask: mask }
+    high := net.IPNet{ IP: append(net.IP{}, network.IP...), Mask: mask }
+    high.IP[ones / 8] |= 0x80 >> uint(ones % 8)
+
+    return append(subtractCIDR(low, ranges), subtractCIDR(high, ranges)...)
+}
+
+// WriteNmapTargets writes one network per line, the format read by
+// nmap -iL
+func WriteNmapTargets(w io.Writer, targets []net.IPNet) error {
+    for _, t := range targets {
+        if _, err := fmt.Fprintln(w, t.String()); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// WriteMasscanRanges writes a masscan configuration file (masscan -c)
+// with one range per network
+func WriteMasscanRanges(w io.Writer, targets []net.IPNet) error {
+    for _, t := range targets {
+        if _, err := fmt.Fprintf(w, "range = %s\n", t.String()); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// WriteHostsGrepable writes the live hosts in the nmap grepable format
+// (-oG), as a ping scan result
+func WriteHostsGrepable(w io.Writer, hosts []net.IP) error {
+    if _, err := fmt.Fprintf(w, "# pcapraptor live hosts %s\n", time.Now().UTC().Format(time.RFC1123)); err != nil {
+        return err
+    }
+    for _, ip := range hosts {
+        if _, err := fmt.Fprintf(w, "Host: %s ()\tStatus: Up\n", ip); err != nil {
+            return err
+        }
+    }
+    _, err := fmt.Fprintf(w, "# pcapraptor done -- %d IP addresses (%d hosts up)\n", len(hosts), len(hosts))
+    return err
+}
+
+type nmapRun struct {
+    XMLName         xml.Name            `xml:"nmaprun"`
+    Scanner         string              `xml:"scanner,attr"`
+    Start           int64               `xml:"start,attr"`
+    Version         string              `xml:"xmloutputversion,attr"`
+    Hosts           []nmapHost          `xml:"host"`
+    RunStats        nmapRunStats        `xml:"runstats"`
+}
+
+type nmapHost struct {
+    Status          nmapStatus          `xml:"status"`
+    Address         nmapAddress         `xml:"address"`
+}
+
+type nmapStatus struct {
+    State           string              `xml:"state,attr"`
+    Reason          string              `xml:"reason,attr"`
+}
+
+type nmapAddress struct {
+    Addr            string              `xml:"addr,attr"`
+    AddrType        string              `xml:"addrtype,attr"`
+}
+
+type nmapRunStats struct {
+    Hosts           nmapHostStats       `xml:"hosts"`
+}
+
+type nmapHostStats struct {
+    Up              int                 `xml:"up,attr"`
+    Down            int                 `xml:"down,attr"`
+    Total           int                 `xml:"total,attr"`
+}
+
+// WriteHostsXML writes the live hosts in the nmap XML format (-oX), as a
+// ping scan result, so it can be imported by the tools reading nmap scans
+func WriteHostsXML(w io.Writer, hosts []net.IP) error {
+    run := nmapRun{
+        Scanner     : "pcapraptor",
+        Start       : time.Now().Unix(),
+        Version     : "1.05",
+        Hosts       : []nmapHost{},
+        RunStats    : nmapRunStats{ Hosts: nmapHostStats{ Up: len(hosts), Total: len(hosts) } },
+    }
+    for _, ip := range hosts {
+        addrType := "ipv4"
+        if ip.To4() == nil {
+            addrType = "ipv6"
+        }
+        run.Hosts = append(run.Hosts, nmapHost{
+            Status      : nmapStatus{ State: "up", Reason: "passive" },
+            Address     : nmapAddress{ Addr: ip.String(), AddrType: addrType },
+        })
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+    enc := xml.NewEncoder(w)
+    enc.Indent("", "  ")
+    if err := enc.Encode(run); err != nil {
+        return err
+    }
+    _, err := io.WriteString(w, "\n")
+    return err
+}
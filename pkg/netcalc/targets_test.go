@@ -0,0 +1,90 @@
+package netcalc
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func inferred(cidrs ...string) []InferredSubnet {
+	list := []InferredSubnet{}
+	for _, c := range cidrs {
+		ip, n, _ := net.ParseCIDR(c)
+		ones, _ := n.Mask.Size()
+		list = append(list, InferredSubnet{SubnetData: SubnetData{Net: ip.Mask(n.Mask).String(), Mask: ones}})
+	}
+	return list
+}
+
+func TestTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []string
+		subnets []string
+		want    string
+	}{
+		{
+			name:    "subnet inside the scope",
+			ranges:  []string{"scope 10.0.0.0/8"},
+			subnets: []string{"10.1.2.0/24", "10.1.2.128/25"},
+			want:    "10.1.2.0/24",
+		},
+		{
+			name:    "subnet wider than the scope",
+			ranges:  []string{"scope 10.1.0.0/16 10.3.4.0/24"},
+			subnets: []string{"10.0.0.0/8"},
+			want:    "10.1.0.0/16 10.3.4.0/24",
+		},
+		{
+			name:    "out of scope",
+			ranges:  []string{"scope 10.1.0.0/16"},
+			subnets: []string{"10.2.0.0/16", "8.8.8.0/24"},
+			want:    "",
+		},
+		{
+			name:    "excludes cut out",
+			ranges:  []string{"scope 10.1.0.0/16", "exclude 10.1.0.0/17 10.1.192.0/18"},
+			subnets: []string{"10.0.0.0/8"},
+			want:    "10.1.128.0/18",
+		},
+		{
+			name:    "private ranges by default",
+			ranges:  []string{"exclude 192.168.1.0/25"},
+			subnets: []string{"192.168.1.0/24", "203.0.113.0/24", "fd00::/64"},
+			want:    "192.168.1.128/25",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mustRanges(t, tt.ranges...)
+			list := []string{}
+			for _, n := range r.Targets(inferred(tt.subnets...)) {
+				list = append(list, n.String())
+			}
+			if got := strings.Join(list, " "); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteNmapTargets(t *testing.T) {
+	_, a, _ := net.ParseCIDR("10.0.0.0/24")
+	_, b, _ := net.ParseCIDR("10.0.2.0/23")
+	var buf bytes.Buffer
+	if err := WriteNmapTargets(&buf, []net.IPNet{*a, *b}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "10.0.0.0/24\n10.0.2.0/23\n" {
+		t.Fatalf("unexpected targets %q", buf.String())
+	}
+	buf.Reset()
+	if err := WriteMasscanRanges(&buf, []net.IPNet{*a}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "range = 10.0.0.0/24\n" {
+		t.Fatalf("unexpected ranges %q", buf.String())
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package netcalc
+
+import (
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// GetVLANFromPacket returns the 802.1Q VLAN IDs of the packet, outer
+// first and separated by '.' for QinQ (e.g. "100.20"), or an empty
+// string for untagged frames
+func GetVLANFromPacket(packet gopacket.Packet) string {
+    ids := []string{}
+    for _, l := range packet.Layers() {
+        if q, ok := l.(*layers.Dot1Q); ok {
+            ids = append(ids, strconv.Itoa(int(q.VLANIdentifier)))
+        }
+    }
+    return strings.Join(ids, ".")
+}
+
+// VLANSummary summarizes the subnets found at one VLAN
+type VLANSummary struct {
+    VLAN            string              `json:"vlan"`
+    Subnets         []string            `json:"subnets"`
+    Hosts           int                 `json:"hosts"`
+    Gateways        []string            `json:"gateways"`
+}
+
+// VLANMapper keeps the subnet and gateway evidence of every VLAN apart,
+// so trunk captures with overlapping address plans are not merged
+type VLANMapper struct {
+    engines         map[string]*MaskInference
+    gateways        map[string]*GatewayCollector
+}
+
+func NewVLANMapper() *VLANMapper {
+    return &VLANMapper{
+        engines     : map[string]*MaskInference{},
+        gateways    : map[string]*GatewayCollector{},
+    }
+}
+
+// Add feeds the packet to the VLAN it belongs to, returning true if the
+// packet had any subnet evidence
+func (v *VLANMapper) Add(packet gopacket.Packet) bool {
+    return v.AddEvidence(GetEvidenceFromPacket(packet))
+}
+
+// AddEvidence feeds the evidence decoded from a packet to the VLAN it
+// belongs to, returning true if it had any subnet evidence
+func (v *VLANMapper) AddEvidence(e *PacketEvidence) bool {
+    if _, ok := v.engines[e.VLAN]; !ok {
+        v.engines[e.VLAN] = NewMaskInference()
+        v.gateways[e.VLAN] = NewGatewayCollector()
+    }
+    v.gateways[e.VLAN].AddEvidence(e)
+    return v.engines[e.VLAN].AddEvidence(e)
+}
+
+// VLANs returns the VLANs found, untagged ("") first
+func (v *VLANMapper) VLANs() []string {
+    vlans := []string{}
+    for vlan := range v.engines {
+        vlans = append(vlans, vlan)
+    }
+    sort.Slice(vlans, func(i, j int) bool {
+        return compareVLAN(vlans[i], vlans[j]) < 0
+    })
+    return vlans
+}
+
+// Tagged returns true if any tagged frame was found
+func (v *VLANMapper) Tagged() bool {
+    for vlan := range v.engines {
+        if vlan != "" {
+            return true
+        }
+    }
+    return false
+}
+
+// Subnets returns the inferred subnets of the VLAN
+func (v *VLANMapper) Subnets(vlan string) []InferredSubnet {
+    e, ok := v.engines[vlan]
+    if !ok {
+        return []InferredSubnet{}
+    }
+    subnets := e.Subnets()
+    for i := range subnets {
+        subnets[i].VLAN = vlan
+    }
+    return subnets
+}
+
+// LiveHosts returns the addresses seen sending traffic at the VLAN
+func (v *VLANMapper) LiveHosts(vlan string) []net.IP {
+    e, ok := v.engines[vlan]
+    if !ok {
+        return []net.IP{}
+    }
+    return e.LiveHosts()
+}
+
+// Gateways returns the routers found at the VLAN
+func (v *VLANMapper) Gateways(vlan string) []Gateway {
+    g, ok := v.gateways[vlan]
+    if !ok {
+        return []Gateway{}
+    }
+    return g.Gateways(v.Subnets(vlan))
+}
+
+// Summary returns one line per VLAN with its subnets, number of hosts and
+// gateways, filter (if set) selects the subnets taken
+func (v *VLANMapper) Summary(filter func(InferredSubnet) bool) []VLANSummary {
+    summary := []VLANSummary{}
+    for _, vlan := range v.VLANs() {
+        s := VLANSummary{ VLAN: vlan, Subnets: []string{}, Gateways: []string{} }
+        for _, subnet := range v.Subnets(vlan) {
+            if filter != nil && !filter(subnet) {
+                continue
+            }
+            s.Subnets = append(s.Subnets, subnet.String())
+            s.Hosts += subnet.Hosts
+        }
+        for _, g := range v.Gateways(vlan) {
+            if len(g.IPs) > 0 {
+                s.Gateways = append(s.Gateways, g.IPs[0])
+            } else {
+                s.Gateways = append(s.Gateways, g.MAC)
+            }
+        }
+        summary = append(summary, s)
+    }
+    return summary
+}
+
+// compareVLAN sorts VLAN keys numerically, outer tag first
+func compareVLAN(a, b string) int {
+    if a == b {
+        return 0
+    }
+    if a == "" || b == "" {
+        if a == "" {
+            return -1
+        }
+        return 1
+    }
+    pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+    for i := 0; i < len(pa) && i < len(pb); i++ {
+        na, _ := strconv.Atoi(pa[i])
+        nb, _ := strconv.Atoi(pb[i])
+        if na != nb {
+            if na < nb {
+                return -1
+            }
+            return 1
+        }
+    }
+    if len(pa) < len(pb) {
+        return -1
+    }
+    return 1
+}
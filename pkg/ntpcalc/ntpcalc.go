@@ -18,11 +18,12 @@ import (
     "io"
     "errors"
     "os"
+    "sort"
 
     //"github.com/helviojunior/pcapraptor/pkg/log"
 
     "github.com/helviojunior/pcapraptor/pkg/gopcap"
-    "github.com/google/gopacket"
+    "github.com/helviojunior/pcapraptor/pkg/decap"
     "github.com/google/gopacket/layers"
 )
 
@@ -32,15 +33,14 @@ type Writer struct {
 
 type NTPData struct {
 	RequestTransTime   uint64
-    RequestTsSec       int64
-    RequestTsUsec      int64
+    RequestTime        time.Time
     RequestNtpTs       time.Time
 }
 
-func NewNTPData(packetTsSec int32, packetTsUsec int32, ntpTs uint64) *NTPData {
+// NewNTPData holds a request seen at packetTime (the capture time)
+func NewNTPData(packetTime time.Time, ntpTs uint64) *NTPData {
 	return &NTPData{
-		RequestTsSec           	: int64(packetTsSec),
-		RequestTsUsec 			: int64(packetTsUsec),
+		RequestTime             : packetTime,
 		RequestTransTime 		: ntpTs,
 		RequestNtpTs 			: ntpToUnix(ntpTs),
 	}
@@ -62,10 +62,10 @@ and roundtrip delay
 delay = (T4 - T1) - (T3 - T2)
 */
 
-func (ntp NTPData) CalcDelta(packetTsSec int32, packetTsUsec int32, ntpTs uint64) int64 {
-	t1 := time.Unix(ntp.RequestTsSec, ntp.RequestTsUsec)
-	t2 := time.Unix(int64(packetTsSec), int64(packetTsUsec))
-	
+func (ntp NTPData) CalcDelta(packetTime time.Time, ntpTs uint64) int64 {
+	t1 := ntp.RequestTime
+	t2 := packetTime
+
     d1 := int64(t2.Sub(t1).Nanoseconds() / 2)
     d2 := ntpToUnix(ntpTs).Sub(t2)
     //offset := int64((d1.Nanoseconds() + d2.Nanoseconds()) / 2)
@@ -74,8 +74,58 @@ func (ntp NTPData) CalcDelta(packetTsSec int32, packetTsUsec int32, ntpTs uint64
 	return offset
 }
 
+// GetFileDelta returns the time shift of the capture, the median offset
+// of the first DefaultSamples NTP request/response pairs (as the ntp
+// command does)
 func GetFileDelta(pcapFile string) (*time.Duration, error) {
-    // create reader
+    res, err := GetFileSamples(pcapFile, DefaultSamples)
+    if err != nil {
+        return nil, err
+    }
+
+    diff := res.Duration()
+    return &diff, nil
+}
+
+// DefaultSamples is the number of NTP request/response pairs used to
+// calculate the time shift
+const DefaultSamples = 5
+
+const (
+    ConfidenceHigh      = "high"
+    ConfidenceMedium    = "medium"
+    ConfidenceLow       = "low"
+)
+
+// Sample is one NTP request/response pair found at the capture, offsets
+// and delays are in seconds
+type Sample struct {
+    Client          string          `json:"client"`
+    Server          string          `json:"server"`
+    Time            time.Time       `json:"time"`
+    Offset          float64         `json:"offset"`
+    Delay           float64         `json:"delay"`
+}
+
+// Result is the time shift calculated from the NTP samples: the median
+// offset of the samples and how much they agree with each other
+type Result struct {
+    Offset          float64         `json:"offset"`
+    Spread          float64         `json:"spread"`
+    Confidence      string          `json:"confidence"`
+    Samples         []Sample        `json:"samples"`
+}
+
+// Duration returns the offset as a time.Duration
+func (r Result) Duration() time.Duration {
+    return time.Duration(r.Offset * float64(time.Second))
+}
+
+// GetFileSamples looks for up to max NTP request/response pairs (0 means
+// all) and returns the median offset with a confidence level: high with
+// 3 or more samples within 1 second, medium with 2 or more within 5
+// seconds and low otherwise
+func GetFileSamples(pcapFile string, max int) (*Result, error) {
     r, err := gopcap.Open(pcapFile)
     if err != nil {
         return nil, err
@@ -83,11 +133,10 @@ func GetFileDelta(pcapFile string) (*time.Duration, error) {
     defer r.Close()
 
     ntpList := []*NTPData{}
-    var diff time.Duration
-    // loop over packets
+    clients := map[uint64]string{}
+    res := &Result{ Samples: []Sample{} }
 
-    not_found := true
-    for not_found {
+    for max <= 0 || len(res.Samples) < max {
         h, data, err := r.ReadNextPacket()
         if err != nil {
             if err == io.EOF {
@@ -96,34 +145,74 @@ func GetFileDelta(pcapFile string) (*time.Duration, error) {
             return nil, err
         }
 
-        packet := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.NoCopy)
-        if ntpLayer := packet.Layer(layers.LayerTypeNTP); ntpLayer != nil {
-            ntp := ntpLayer.(*layers.NTP)
-            if ntp.Mode == 3 || ntp.Mode == 1 { //Request, Symetric Active
-                ntpList = append(ntpList, NewNTPData(h.TsSec, h.TsUsec, uint64(ntp.TransmitTimestamp)))
-            }else if ntp.Mode == 4 { // Response from server
-                for _, nd := range ntpList {
-                    if nd.RequestTransTime == uint64(ntp.OriginTimestamp) {
-                        t := nd.CalcDelta(h.TsSec, h.TsUsec, uint64(ntp.TransmitTimestamp))
-                        diff = time.Duration(t)
-                        not_found = false
-
-                        //newData := time.Unix(int64(h.TsSec), int64(h.TsUsec)).Add(dur)
-
-                        //log.Info("NTP response found", "TsSec", h.TsSec, "pkt", h.TsUsec, "tf", newData, "t", t)
-                    }
+        packet := decap.NewPacket(data, layers.LinkType(r.Header.Network))
+        ntpLayer := packet.Layer(layers.LayerTypeNTP)
+        if ntpLayer == nil {
+            continue
+        }
+        ntp := ntpLayer.(*layers.NTP)
+        src, dst := "", ""
+        if nl := packet.NetworkLayer(); nl != nil {
+            src, dst = nl.NetworkFlow().Src().String(), nl.NetworkFlow().Dst().String()
+        }
+
+        if ntp.Mode == 3 || ntp.Mode == 1 { //Request, Symetric Active
+            ntpList = append(ntpList, NewNTPData(r.Header.PacketTime(h), uint64(ntp.TransmitTimestamp)))
+            clients[uint64(ntp.TransmitTimestamp)] = src
+        }else if ntp.Mode == 4 { // Response from server
+            for i, nd := range ntpList {
+                if nd.RequestTransTime != uint64(ntp.OriginTimestamp) {
+                    continue
+                }
+                t4 := r.Header.PacketTime(h)
+                t1 := nd.RequestTime
+                server := ntpToUnix(uint64(ntp.TransmitTimestamp)).Sub(ntpToUnix(uint64(ntp.ReceiveTimestamp)))
+                client := dst
+                if c, ok := clients[nd.RequestTransTime]; ok && c != "" {
+                    client = c
                 }
-                
+                res.Samples = append(res.Samples, Sample{
+                    Client      : client,
+                    Server      : src,
+                    Time        : t4.UTC(),
+                    Offset      : time.Duration(nd.CalcDelta(t4, uint64(ntp.TransmitTimestamp))).Seconds(),
+                    Delay       : (t4.Sub(t1) - server).Seconds(),
+                })
+
+                // a request is answered once
+                ntpList = append(ntpList[:i], ntpList[i+1:]...)
+                break
             }
         }
+    }
 
-    } 
-
-    if not_found {
+    if len(res.Samples) == 0 {
         return nil, errors.New("Cannot find any NTP package")
     }
 
-    return &diff, nil
+    offsets := []float64{}
+    for _, s := range res.Samples {
+        offsets = append(offsets, s.Offset)
+    }
+    sort.Float64s(offsets)
+    n := len(offsets)
+    if n % 2 == 1 {
+        res.Offset = offsets[n / 2]
+    } else {
+        res.Offset = (offsets[n / 2 - 1] + offsets[n / 2]) / 2
+    }
+    res.Spread = offsets[n - 1] - offsets[0]
+
+    switch {
+    case n >= 3 && res.Spread <= 1:
+        res.Confidence = ConfidenceHigh
+    case n >= 2 && res.Spread <= 5:
+        res.Confidence = ConfidenceMedium
+    default:
+        res.Confidence = ConfidenceLow
+    }
+
+    return res, nil
 }
 
 func ntpToUnix(ntp uint64) time.Time {
@@ -0,0 +1,305 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package osfp guesses the operating system of the hosts passively, from
+// the TCP SYN parameters (p0f v3 signatures), the DHCP parameter request
+// lists and the HTTP user agents.
+package osfp
+
+import (
+    "bytes"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// Evidence sources
+const (
+    sourceTCP       = "tcp"
+    sourceDHCP      = "dhcp"
+    sourceUserAgent = "user-agent"
+)
+
+// Evidence weights, a specific signature outweighs a generic or fuzzy one
+const (
+    weightSpecific  = 3
+    weightGeneric   = 2
+    weightFuzzy     = 1
+)
+
+// derived are the systems built on the kernel of another family, the
+// TCP stack can not tell them apart
+var derived = map[string]string{
+    "Android"       : "Linux",
+    "ChromeOS"      : "Linux",
+}
+
+var (
+    tcpRequestDB    = parseTCPSignatures(tcpRequestSignatures)
+    tcpResponseDB   = parseTCPSignatures(tcpResponseSignatures)
+    dhcpDB          = parseSignatures(dhcpSignatures)
+    dhcpVendorDB    = parseSignatures(dhcpVendorSignatures)
+    userAgentDB     = parseSignatures(userAgentSignatures)
+)
+
+// label is a parsed p0f label
+type label struct {
+    generic         bool
+    class           string
+    name            string
+    flavor          string
+}
+
+func parseLabel(s string) label {
+    f := strings.SplitN(s, ":", 4)
+    for len(f) < 4 {
+        f = append(f, "")
+    }
+    return label{ generic: f[0] == "g", class: f[1], name: f[2], flavor: f[3] }
+}
+
+// String returns the OS name and flavor
+func (l label) String() string {
+    return strings.TrimSpace(l.name + " " + l.flavor)
+}
+
+// textSignature is a label and one of its string fingerprints
+type textSignature struct {
+    label           label
+    sig             string
+}
+
+func parseSignatures(list []signature) []textSignature {
+    sigs := []textSignature{}
+    for _, s := range list {
+        l := parseLabel(s.label)
+        for _, sig := range s.sigs {
+            sigs = append(sigs, textSignature{ label: l, sig: sig })
+        }
+    }
+    return sigs
+}
+
+// evidence is an OS guess from one packet
+type evidence struct {
+    os              string
+    source          string
+    weight          int
+    name            string
+    family          string
+}
+
+// Collector gathers the OS evidences packet by packet. TCP and HTTP
+// evidences are indexed by IP address and DHCP ones by MAC address
+type Collector struct {
+    evidences       map[string]map[string]evidence
+}
+
+// NewCollector returns an empty collector
+func NewCollector() *Collector {
+    return &Collector{ evidences: map[string]map[string]evidence{} }
+}
+
+func (c *Collector) add(key string, l label, weight int, source string) {
+    e, ok := c.evidences[key]
+    if !ok {
+        e = map[string]evidence{}
+        c.evidences[key] = e
+    }
+    k := source + "|" + l.String()
+    if old, ok := e[k]; ok && old.weight >= weight {
+        return
+    }
+    family := l.name
+    if f, ok := derived[l.name]; ok {
+        family = f
+    }
+    e[k] = evidence{ os: l.String(), source: source, weight: weight, name: l.name, family: family }
+}
+
+// Add collects the OS evidences of the packet
+func (c *Collector) Add(packet gopacket.Packet) {
+    if dhcpLayer := packet.Layer(layers.LayerTypeDHCPv4); dhcpLayer != nil {
+        c.addDHCP(dhcpLayer.(*layers.DHCPv4))
+        return
+    }
+
+    var src net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        src = ipLayer.(*layers.IPv4).SrcIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        src = ipLayer.(*layers.IPv6).SrcIP
+    }
+    tcpLayer := packet.Layer(layers.LayerTypeTCP)
+    if src == nil || tcpLayer == nil {
+        return
+    }
+    tcp := tcpLayer.(*layers.TCP)
+
+    if tcp.SYN {
+        c.addTCP(src.String(), packet, tcp)
+    } else if len(tcp.Payload) > 0 {
+        c.addUserAgent(src.String(), tcp.Payload)
+    }
+}
+
+func (c *Collector) addTCP(key string, packet gopacket.Packet, tcp *layers.TCP) {
+    fp := tcpFingerprintOf(packet, tcp)
+    if fp == nil {
+        return
+    }
+    db := tcpRequestDB
+    if tcp.ACK {
+        db = tcpResponseDB
+    }
+    for _, fuzzy := range []bool{ false, true } {
+        for _, s := range db {
+            if !s.match(fp, fuzzy) {
+                continue
+            }
+            weight := weightSpecific
+            if fuzzy {
+                weight = weightFuzzy
+            } else if s.label.generic {
+                weight = weightGeneric
+            }
+            c.add(key, s.label, weight, sourceTCP)
+            return
+        }
+    }
+}
+
+func (c *Collector) addDHCP(dhcp *layers.DHCPv4) {
+    if dhcp.Operation != layers.DHCPOpRequest || len(dhcp.ClientHWAddr) != 6 {
+        return
+    }
+    key := dhcp.ClientHWAddr.String()
+    for _, o := range dhcp.Options {
+        switch o.Type {
+        case layers.DHCPOptParamsRequest:
+            params := []string{}
+            for _, p := range o.Data {
+                params = append(params, strconv.Itoa(int(p)))
+            }
+            list := strings.Join(params, ",")
+            for _, s := range dhcpDB {
+                if s.sig == list {
+                    c.add(key, s.label, weightSpecific, sourceDHCP)
+                    break
+                }
+            }
+        case layers.DHCPOptClassID:
+            vendor := string(o.Data)
+            for _, s := range dhcpVendorDB {
+                if strings.HasPrefix(vendor, s.sig) {
+                    c.add(key, s.label, weightGeneric, sourceDHCP)
+                    break
+                }
+            }
+        }
+    }
+}
+
+// addUserAgent looks for the User-Agent header of a HTTP request
+func (c *Collector) addUserAgent(key string, payload []byte) {
+    end := bytes.Index(payload, []byte("\r\n\r\n"))
+    if end < 0 {
+        end = len(payload)
+    }
+    lines := strings.Split(string(payload[:end]), "\r\n")
+    if len(lines) < 2 || !strings.Contains(lines[0], " HTTP/1.") {
+        return
+    }
+    for _, line := range lines[1:] {
+        name, value, ok := strings.Cut(line, ":")
+        if !ok || !strings.EqualFold(strings.TrimSpace(name), "User-Agent") {
+            continue
+        }
+        value = strings.TrimSpace(value)
+        for _, s := range userAgentDB {
+            if strings.Contains(value, s.sig) {
+                weight := weightGeneric
+                if s.label.generic {
+                    weight = weightFuzzy
+                }
+                c.add(key, s.label, weight, sourceUserAgent)
+                return
+            }
+        }
+        return
+    }
+}
+
+// evidencesOf returns the evidences of the given IP and MAC addresses,
+// the strongest first
+func (c *Collector) evidencesOf(keys []string) []evidence {
+    list := []evidence{}
+    for _, k := range keys {
+        for _, e := range c.evidences[k] {
+            list = append(list, e)
+        }
+    }
+    sort.Slice(list, func(i, j int) bool {
+        if list[i].weight != list[j].weight {
+            return list[i].weight > list[j].weight
+        }
+        if list[i].os != list[j].os {
+            return list[i].os < list[j].os
+        }
+        return list[i].source < list[j].source
+    })
+    return list
+}
+
+// Guess returns the most likely OS of a host given its IP and MAC
+// addresses, or an empty string. The OS family (Windows, Linux, ...)
+// with the highest weight wins, then the best evidence of that family
+// gives the name and version, a derived system (e.g. Android) being
+// preferred to its kernel
+func (c *Collector) Guess(keys ...string) string {
+    evidences := c.evidencesOf(keys)
+    if len(evidences) == 0 {
+        return ""
+    }
+
+    families := map[string]int{}
+    isDerived := map[string]bool{}
+    for _, e := range evidences {
+        families[e.family] += e.weight
+        if e.name != e.family {
+            isDerived[e.family] = true
+        }
+    }
+    best := ""
+    for f, w := range families {
+        if best == "" || w > families[best] || (w == families[best] && f < best) {
+            best = f
+        }
+    }
+
+    // evidences are sorted by weight, prefer the ones with a version
+    var guess *evidence
+    for i, e := range evidences {
+        if e.family != best || (isDerived[best] && e.name == e.family) {
+            continue
+        }
+        if guess == nil || (e.weight == guess.weight && guess.os == guess.name && e.os != e.name) {
+            guess = &evidences[i]
+        }
+    }
+    return guess.os
+}
@@ -0,0 +1,160 @@
+package osfp
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestParseTCPSignature(t *testing.T) {
+	tests := []struct {
+		sig  string
+		want tcpSignature
+	}{
+		{"*:64:0:*:mss*44,7:mss,sok,ts,nop,ws:df,id+:0",
+			tcpSignature{ittl: 64, mss: -1, wsizeKind: wsizeMSS, wsize: 44, scale: 7,
+				olayout: "mss,sok,ts,nop,ws", quirks: []string{"df", "id+"}, pclass: 0}},
+		{"4:128+10:0:1460:8192,8:mss,nop,ws,nop,nop,sok::*",
+			tcpSignature{ver: 4, ittl: 128, mss: 1460, wsizeKind: wsizeExact, wsize: 8192, scale: 8,
+				olayout: "mss,nop,ws,nop,nop,sok", quirks: []string{}, pclass: -1}},
+		{"6:64-:0:*:%512,*:mss:ecn,df:+",
+			tcpSignature{ver: 6, ittl: 64, mss: -1, wsizeKind: wsizeMod, wsize: 512, scale: -1,
+				olayout: "mss", quirks: []string{"df", "ecn"}, pclass: 1}},
+		{"*:255:0:*:*,*:mss::0",
+			tcpSignature{ittl: 255, mss: -1, wsizeKind: wsizeAny, scale: -1,
+				olayout: "mss", quirks: []string{}, pclass: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sig, func(t *testing.T) {
+			s, err := parseTCPSignature(tt.sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*s, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, *s)
+			}
+		})
+	}
+
+	for _, sig := range []string{"*:64:0:*:mss*44:mss:df:0", "*:64:0:*:8192,8:mss:df:x", "*:xx:0:*:8192,8:mss:df:0"} {
+		if _, err := parseTCPSignature(sig); err == nil {
+			t.Errorf("%s: expected an error", sig)
+		}
+	}
+}
+
+// linuxSYN returns a Linux 4.x SYN packet
+func linuxSYN(t *testing.T, ns, ece bool) gopacket.Packet {
+	ts := make([]byte, 8)
+	binary.BigEndian.PutUint32(ts, 12345)
+	ip := &layers.IPv4{Version: 4, TTL: 61, Id: 4242, Flags: layers.IPv4DontFragment,
+		Protocol: layers.IPProtocolTCP, SrcIP: net.IP{10, 0, 0, 5}, DstIP: net.IP{10, 0, 0, 1}}
+	tcp := &layers.TCP{SrcPort: 40000, DstPort: 443, Seq: 1000, SYN: true, NS: ns, ECE: ece, Window: 1460 * 44,
+		Options: []layers.TCPOption{
+			{OptionType: layers.TCPOptionKindMSS, OptionData: []byte{0x05, 0xb4}},
+			{OptionType: layers.TCPOptionKindSACKPermitted},
+			{OptionType: layers.TCPOptionKindTimestamps, OptionData: ts},
+			{OptionType: layers.TCPOptionKindNop},
+			{OptionType: layers.TCPOptionKindWindowScale, OptionData: []byte{7}},
+		}}
+	tcp.SetNetworkLayerForChecksum(ip)
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ip, tcp); err != nil {
+		t.Fatal(err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+}
+
+func TestTCPFingerprint(t *testing.T) {
+	tests := []struct {
+		name   string
+		ns     bool
+		ece    bool
+		quirks []string
+		want   string
+	}{
+		{"syn", false, false, []string{"df", "id+"}, "Linux 4.x or newer"},
+		// the NS bit is the ECN nonce, not an ECN setup
+		{"nonce sum", true, false, []string{"df", "id+"}, "Linux 4.x or newer"},
+		{"ecn setup", false, true, []string{"df", "ecn", "id+"}, "Linux 4.x or newer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := linuxSYN(t, tt.ns, tt.ece)
+			fp := tcpFingerprintOf(packet, packet.Layer(layers.LayerTypeTCP).(*layers.TCP))
+			if fp == nil {
+				t.Fatal("no fingerprint")
+			}
+			if fp.olayout != "mss,sok,ts,nop,ws" || fp.mss != 1460 || fp.scale != 7 {
+				t.Errorf("unexpected fingerprint %+v", *fp)
+			}
+			if !reflect.DeepEqual(fp.quirks, tt.quirks) {
+				t.Errorf("expected quirks %v, got %v", tt.quirks, fp.quirks)
+			}
+
+			c := NewCollector()
+			c.Add(packet)
+			if got := c.Guess("10.0.0.5"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// guessEvidence is an OS evidence of a host address
+type guessEvidence struct {
+	key    string
+	label  string
+	weight int
+	source string
+}
+
+func TestGuess(t *testing.T) {
+	tests := []struct {
+		name      string
+		evidences []guessEvidence
+		want      string
+	}{
+		{"specific wins", []guessEvidence{
+			{"10.0.0.5", "g:unix:Linux:2.6.x or newer", weightGeneric, sourceTCP},
+			{"10.0.0.5", "s:win:Windows:10 or 11", weightSpecific, sourceTCP},
+		}, "Windows 10 or 11"},
+		{"family weights add up", []guessEvidence{
+			{"10.0.0.5", "s:win:Windows:10 or 11", weightSpecific, sourceTCP},
+			{"10.0.0.5", "g:unix:Linux:", weightGeneric, sourceTCP},
+			{"00:11:22:33:44:55", "s:unix:Linux:4.x or newer", weightGeneric, sourceDHCP},
+		}, "Linux 4.x or newer"},
+		{"tie broken by name", []guessEvidence{
+			{"10.0.0.5", "s:win:Windows:10 or 11", weightSpecific, sourceTCP},
+			{"10.0.0.5", "s:unix:Linux:4.x or newer", weightSpecific, sourceTCP},
+		}, "Linux 4.x or newer"},
+		{"derived system preferred", []guessEvidence{
+			{"10.0.0.5", "s:unix:Linux:4.x or newer", weightSpecific, sourceTCP},
+			{"10.0.0.5", "s:unix:Android:", weightGeneric, sourceUserAgent},
+		}, "Android"},
+		{"version preferred", []guessEvidence{
+			{"10.0.0.5", "g:unix:Linux:", weightGeneric, sourceUserAgent},
+			{"10.0.0.5", "g:unix:Linux:2.6.x or newer", weightGeneric, sourceTCP},
+		}, "Linux 2.6.x or newer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector()
+			for _, e := range tt.evidences {
+				c.add(e.key, parseLabel(e.label), e.weight, e.source)
+			}
+			if got := c.Guess("10.0.0.5", "00:11:22:33:44:55"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if got := NewCollector().Guess("10.0.0.5"); got != "" {
+		t.Errorf("expected no guess, got %s", got)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package osfp
+
+// signature is a label and the fingerprints identifying it. Labels use
+// the p0f format: type (s for specific or g for generic), class, name and
+// flavor, separated by ':'
+type signature struct {
+    label           string
+    sigs            []string
+}
+
+// tcpRequestSignatures are the p0f v3 signatures of the SYN packets
+// (ver:ittl:olen:mss:wsize,scale:olayout:quirks:pclass)
+var tcpRequestSignatures = []signature{
+    { "s:unix:Linux:4.x or newer", []string{
+        "*:64:0:*:mss*44,7:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*44,8:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*44,9:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*44,10:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*45,7:mss,sok,ts,nop,ws:df,id+:0",
+    }},
+    { "s:unix:Linux:3.11 and newer", []string{
+        "*:64:0:*:mss*20,10:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*20,7:mss,sok,ts,nop,ws:df,id+:0",
+    }},
+    { "s:unix:Linux:3.1-3.10", []string{
+        "*:64:0:*:mss*10,4:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*10,5:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*10,6:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*10,7:mss,sok,ts,nop,ws:df,id+:0",
+    }},
+    { "s:unix:Linux:2.6.x", []string{
+        "*:64:0:*:mss*4,6:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*4,7:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*4,8:mss,sok,ts,nop,ws:df,id+:0",
+    }},
+    { "s:unix:Linux:2.4.x", []string{
+        "*:64:0:*:mss*4,0:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*4,1:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:mss*4,2:mss,sok,ts,nop,ws:df,id+:0",
+    }},
+    { "g:unix:Linux:2.6.x or newer", []string{
+        "*:64:0:*:*,*:mss,sok,ts,nop,ws:df,id+:0",
+        "*:64:0:*:*,*:mss,nop,nop,sok,nop,ws:df,id+:0",
+        "*:64:0:*:*,*:mss,sok,ts:df,id+:0",
+        "*:64:0:*:*,*:mss,nop,nop,ts:df,id+:0",
+    }},
+    { "s:win:Windows:10 or 11", []string{
+        "*:128:0:*:64240,8:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,8:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:64240,8:mss,nop,ws,sok,ts:df,id+:0",
+        "*:128:0:*:65535,8:mss,nop,ws,sok,ts:df,id+:0",
+    }},
+    { "s:win:Windows:7 or 8", []string{
+        "*:128:0:*:8192,0:mss,nop,nop,sok:df,id+:0",
+        "*:128:0:*:8192,2:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:8192,8:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:8192,2:mss,nop,ws,sok,ts:df,id+:0",
+    }},
+    { "s:win:Windows:XP", []string{
+        "*:128:0:*:16384,0:mss,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,0:mss,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,0:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,1:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,2:mss,nop,ws,nop,nop,sok:df,id+:0",
+    }},
+    { "g:win:Windows:", []string{
+        "*:128:0:*:*,*:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:*,*:mss,nop,nop,sok:df,id+:0",
+        "*:128:0:*:*,*:mss,nop,ws,sok,ts:df,id+:0",
+    }},
+    { "s:unix:macOS:10.x", []string{
+        "*:64:0:*:65535,1:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+        "*:64:0:*:65535,3:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+    }},
+    { "s:unix:macOS:10.9 or newer", []string{
+        "*:64:0:*:65535,4:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+        "*:64:0:*:65535,5:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+        "*:64:0:*:65535,6:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+    }},
+    { "s:unix:iOS:", []string{
+        "*:64:0:*:65535,2:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+    }},
+    { "s:unix:FreeBSD:9.x or newer", []string{
+        "*:64:0:*:65535,6:mss,nop,ws,sok,ts:df,id+:0",
+    }},
+    { "s:unix:FreeBSD:8.x", []string{
+        "*:64:0:*:65535,3:mss,nop,ws,sok,ts:df,id+:0",
+    }},
+    { "s:unix:OpenBSD:3.x", []string{
+        "*:64:0:*:16384,0:mss,nop,nop,sok,nop,ws,nop,nop,ts:df,id+:0",
+    }},
+    { "s:unix:OpenBSD:4.x-5.x", []string{
+        "*:64:0:*:16384,3:mss,nop,nop,sok,nop,ws,nop,nop,ts:df,id+:0",
+    }},
+    { "g:!:Network device:", []string{
+        "*:255:0:*:*,*:mss:df,id+:0",
+        "*:255:0:*:*,*:mss:id+:0",
+    }},
+}
+
+// tcpResponseSignatures are the p0f v3 signatures of the SYN+ACK packets
+var tcpResponseSignatures = []signature{
+    { "s:unix:Linux:3.x or newer", []string{
+        "*:64:0:*:mss*10,0:mss:df:0",
+        "*:64:0:*:mss*10,0:mss,sok,ts:df:0",
+        "*:64:0:*:mss*10,0:mss,nop,nop,ts:df:0",
+        "*:64:0:*:mss*10,0:mss,nop,nop,sok:df:0",
+        "*:64:0:*:mss*10,*:mss,nop,ws:df:0",
+        "*:64:0:*:mss*10,*:mss,sok,ts,nop,ws:df:0",
+        "*:64:0:*:mss*10,*:mss,nop,nop,ts,nop,ws:df:0",
+        "*:64:0:*:mss*10,*:mss,nop,nop,sok,nop,ws:df:0",
+    }},
+    { "g:unix:Linux:3.x or newer", []string{
+        "*:64:0:*:*,7:mss,sok,ts,nop,ws:df:0",
+        "*:64:0:*:*,7:mss,nop,nop,sok,nop,ws:df:0",
+        "*:64:0:*:*,*:mss,sok,ts,nop,ws:df:0",
+    }},
+    { "s:win:Windows:10 or newer", []string{
+        "*:128:0:*:65535,8:mss,nop,ws,sok,ts:df,id+:0",
+        "*:128:0:*:65535,8:mss,nop,ws,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,8:mss,nop,ws:df,id+:0",
+    }},
+    { "s:win:Windows:7 or 8", []string{
+        "*:128:0:*:8192,0:mss:df,id+:0",
+        "*:128:0:*:8192,0:mss,sok,ts:df,id+:0",
+        "*:128:0:*:8192,8:mss,nop,ws:df,id+:0",
+        "*:128:0:*:8192,0:mss,nop,nop,ts:df,id+:0",
+        "*:128:0:*:8192,0:mss,nop,nop,sok:df,id+:0",
+        "*:128:0:*:8192,8:mss,nop,ws,sok,ts:df,id+:0",
+        "*:128:0:*:8192,8:mss,nop,ws,nop,nop,ts:df,id+:0",
+        "*:128:0:*:8192,8:mss,nop,ws,nop,nop,sok:df,id+:0",
+    }},
+    { "s:win:Windows:XP", []string{
+        "*:128:0:*:65535,0:mss:df,id+:0",
+        "*:128:0:*:65535,0:mss,nop,nop,sok:df,id+:0",
+        "*:128:0:*:65535,0:mss,nop,ws,nop,nop,sok:df,id+:0",
+    }},
+    { "g:win:Windows:", []string{
+        "*:128:0:*:*,*:mss,nop,ws,sok,ts:df,id+:0",
+        "*:128:0:*:*,*:mss,nop,ws,nop,nop,sok:df,id+:0",
+    }},
+    { "s:unix:FreeBSD:9.x or newer", []string{
+        "*:64:0:*:65535,6:mss,nop,ws,sok,ts:df,id+:0",
+    }},
+    { "s:unix:macOS:", []string{
+        "*:64:0:*:65535,*:mss,nop,ws,nop,nop,ts,sok,eol+1:df,id+:0",
+        "*:64:0:*:65535,*:mss,nop,ws,sok,eol+1:df,id+:0",
+    }},
+    { "g:!:Network device:", []string{
+        "*:255:0:*:*,*:mss:df,id+:0",
+        "*:255:0:*:*,*:mss:id+:0",
+        "*:255:0:*:*,0:mss:0+:0",
+    }},
+}
+
+// dhcpSignatures are the parameter request lists (option 55) sent by
+// the DHCP clients
+var dhcpSignatures = []signature{
+    { "s:win:Windows:10 or 11", []string{
+        "1,3,6,15,31,33,43,44,46,47,119,121,249,252",
+    }},
+    { "s:win:Windows:Vista, 7 or 8", []string{
+        "1,15,3,6,44,46,47,31,33,121,249,43",
+        "1,15,3,6,44,46,47,31,33,121,249,43,252",
+        "1,15,3,6,44,46,47,31,33,121,249,252,43",
+    }},
+    { "s:win:Windows:XP", []string{
+        "1,15,3,6,44,46,47,31,33,249,43",
+        "1,15,3,6,44,46,47,31,33,249,43,252",
+        "1,15,3,6,44,46,47,43,77",
+    }},
+    { "s:unix:macOS:", []string{
+        "1,121,3,6,15,119,252,95,44,46",
+        "1,121,3,6,15,108,114,119,252,95,44,46",
+        "1,3,6,15,119,95,252,44,46,101",
+        "1,3,6,15,119,95,252,44,46,47",
+    }},
+    { "s:unix:iOS:", []string{
+        "1,121,3,6,15,119,252",
+        "1,121,3,6,15,108,114,119,252",
+        "1,3,6,15,119,252",
+    }},
+    { "s:unix:Android:", []string{
+        "1,3,6,15,26,28,51,58,59,43",
+        "1,3,6,15,26,28,51,58,59,43,114",
+        "1,3,6,15,26,28,51,58,59,43,114,108",
+        "1,33,3,6,15,28,51,58,59",
+        "1,121,33,3,6,15,28,51,58,59,119",
+    }},
+    { "s:unix:Linux:", []string{
+        "1,28,2,3,15,6,119,12,44,47,26,121,42",
+        "1,28,2,3,15,6,119,12,44,47,26,121,42,249,33,252",
+        "1,28,2,121,15,6,12,40,41,42,26,119,3,121,249,33,252,42",
+        "1,3,6,12,15,28,42,51,54,58,59,119,121",
+        "1,2,6,12,15,26,28,121,3,33,40,41,42,119,249,252,17",
+    }},
+    { "g:unix:Linux:embedded", []string{
+        "1,3,6,12,15,28,42",
+        "1,3,6,12,15,28,40,41,42",
+    }},
+}
+
+// dhcpVendorSignatures are the vendor class (option 60) prefixes sent by
+// the DHCP clients
+var dhcpVendorSignatures = []signature{
+    { "g:win:Windows:", []string{ "MSFT 5.0", "MSFT 98" } },
+    { "g:unix:Android:", []string{ "android-dhcp-" } },
+    { "g:unix:Linux:embedded", []string{ "udhcp " } },
+    { "g:unix:Linux:", []string{ "dhcpcd-" } },
+}
+
+// userAgentSignatures are the substrings identifying the OS at the
+// HTTP User-Agent header, tested in order
+var userAgentSignatures = []signature{
+    { "s:win:Windows Phone:", []string{ "Windows Phone" } },
+    { "s:win:Windows:10 or 11", []string{ "Windows NT 10.0", "Microsoft-CryptoAPI/10.0" } },
+    { "s:win:Windows:8.1", []string{ "Windows NT 6.3", "Microsoft-CryptoAPI/6.3" } },
+    { "s:win:Windows:8", []string{ "Windows NT 6.2", "Microsoft-CryptoAPI/6.2" } },
+    { "s:win:Windows:7", []string{ "Windows NT 6.1", "Microsoft-CryptoAPI/6.1" } },
+    { "s:win:Windows:Vista", []string{ "Windows NT 6.0", "Microsoft-CryptoAPI/6.0" } },
+    { "s:win:Windows:XP", []string{ "Windows NT 5.1", "Windows NT 5.2" } },
+    { "g:win:Windows:", []string{ "Windows-Update-Agent", "Microsoft NCSI", "Microsoft-Delivery-Optimization", "WinHttp" } },
+    { "s:unix:Android:", []string{ "Android", "Dalvik/" } },
+    { "s:unix:ChromeOS:", []string{ "CrOS" } },
+    { "s:unix:iOS:", []string{ "iPhone", "iPad", "iPod" } },
+    { "s:unix:macOS:", []string{ "Macintosh", "Mac OS X" } },
+    { "s:unix:FreeBSD:", []string{ "FreeBSD" } },
+    { "s:unix:OpenBSD:", []string{ "OpenBSD" } },
+    { "s:unix:Linux:", []string{ "Linux", "Ubuntu", "Debian", "Fedora" } },
+}
@@ -0,0 +1,339 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package osfp
+
+import (
+    "encoding/binary"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// window size kinds of a TCP signature
+const (
+    wsizeAny        = iota
+    wsizeExact
+    wsizeMSS
+    wsizeMTU
+    wsizeMod
+)
+
+// quirks relaxed by the fuzzy matching
+var fuzzyQuirks = map[string]bool{ "df": true, "id+": true, "id-": true, "ecn": true }
+
+// tcpSignature is a parsed p0f v3 TCP signature
+type tcpSignature struct {
+    label           label
+    // 0 for any
+    ver             int
+    ittl            int
+    olen            int
+    // -1 for any
+    mss             int
+    wsizeKind       int
+    wsize           int
+    // -1 for any
+    scale           int
+    olayout         string
+    quirks          []string
+    // -1 for any, 0 for no payload and 1 for payload
+    pclass          int
+}
+
+// tcpFingerprint is the TCP/IP fingerprint of a SYN or SYN+ACK packet
+type tcpFingerprint struct {
+    ver             int
+    ttl             int
+    olen            int
+    mss             int
+    wsize           int
+    scale           int
+    olayout         string
+    quirks          []string
+    pclass          int
+}
+
+// parseTCPSignatures parses the signatures, panicking on errors as the
+// signatures are part of the source
+func parseTCPSignatures(list []signature) []*tcpSignature {
+    sigs := []*tcpSignature{}
+    for _, s := range list {
+        l := parseLabel(s.label)
+        for _, sig := range s.sigs {
+            ts, err := parseTCPSignature(sig)
+            if err != nil {
+                panic(fmt.Sprintf("osfp: %s: %s", sig, err))
+            }
+            ts.label = l
+            sigs = append(sigs, ts)
+        }
+    }
+    return sigs
+}
+
+func parseTCPSignature(sig string) (*tcpSignature, error) {
+    f := strings.Split(sig, ":")
+    if len(f) != 8 {
+        return nil, fmt.Errorf("expected 8 fields, got %d", len(f))
+    }
+    s := &tcpSignature{ mss: -1, scale: -1, pclass: -1 }
+    var err error
+
+    if f[0] != "*" {
+        if s.ver, err = strconv.Atoi(f[0]); err != nil {
+            return nil, err
+        }
+    }
+    // the ttl may be followed by '-' (bad ttl) or '+distance'
+    ittl := strings.TrimRight(strings.SplitN(f[1], "+", 2)[0], "-")
+    if s.ittl, err = strconv.Atoi(ittl); err != nil {
+        return nil, err
+    }
+    if s.olen, err = strconv.Atoi(f[2]); err != nil {
+        return nil, err
+    }
+    if f[3] != "*" {
+        if s.mss, err = strconv.Atoi(f[3]); err != nil {
+            return nil, err
+        }
+    }
+
+    win := strings.SplitN(f[4], ",", 2)
+    if len(win) != 2 {
+        return nil, fmt.Errorf("invalid window %q", f[4])
+    }
+    switch {
+    case win[0] == "*":
+        s.wsizeKind = wsizeAny
+    case strings.HasPrefix(win[0], "mss*"):
+        s.wsizeKind = wsizeMSS
+        s.wsize, err = strconv.Atoi(win[0][4:])
+    case strings.HasPrefix(win[0], "mtu*"):
+        s.wsizeKind = wsizeMTU
+        s.wsize, err = strconv.Atoi(win[0][4:])
+    case strings.HasPrefix(win[0], "%"):
+        s.wsizeKind = wsizeMod
+        s.wsize, err = strconv.Atoi(win[0][1:])
+    default:
+        s.wsizeKind = wsizeExact
+        s.wsize, err = strconv.Atoi(win[0])
+    }
+    if err != nil {
+        return nil, err
+    }
+    if win[1] != "*" {
+        if s.scale, err = strconv.Atoi(win[1]); err != nil {
+            return nil, err
+        }
+    }
+
+    s.olayout = f[5]
+    s.quirks = splitList(f[6])
+    switch f[7] {
+    case "0":
+        s.pclass = 0
+    case "+":
+        s.pclass = 1
+    case "*":
+    default:
+        return nil, fmt.Errorf("invalid payload class %q", f[7])
+    }
+    return s, nil
+}
+
+func splitList(s string) []string {
+    list := []string{}
+    for _, i := range strings.Split(s, ",") {
+        if i != "" {
+            list = append(list, i)
+        }
+    }
+    sort.Strings(list)
+    return list
+}
+
+// initialTTL guesses the initial TTL of a packet
+func initialTTL(ttl int) int {
+    for _, i := range []int{ 32, 64, 128 } {
+        if ttl <= i {
+            return i
+        }
+    }
+    return 255
+}
+
+// match tells if the fingerprint matches the signature. The fuzzy match
+// ignores the IP flags and ECN quirks
+func (s *tcpSignature) match(fp *tcpFingerprint, fuzzy bool) bool {
+    if s.ver != 0 && s.ver != fp.ver {
+        return false
+    }
+    if s.ittl != initialTTL(fp.ttl) || s.olen != fp.olen || s.olayout != fp.olayout {
+        return false
+    }
+    if s.mss >= 0 && s.mss != fp.mss {
+        return false
+    }
+    if s.scale >= 0 && s.scale != fp.scale {
+        return false
+    }
+    if s.pclass >= 0 && s.pclass != fp.pclass {
+        return false
+    }
+
+    switch s.wsizeKind {
+    case wsizeExact:
+        if fp.wsize != s.wsize {
+            return false
+        }
+    case wsizeMSS:
+        if fp.mss <= 0 || fp.wsize != fp.mss * s.wsize {
+            return false
+        }
+    case wsizeMTU:
+        mtu := fp.mss + 40
+        if fp.ver == 6 {
+            mtu = fp.mss + 60
+        }
+        if fp.mss <= 0 || fp.wsize != mtu * s.wsize {
+            return false
+        }
+    case wsizeMod:
+        if s.wsize == 0 || fp.wsize % s.wsize != 0 {
+            return false
+        }
+    }
+
+    a, b := s.quirks, fp.quirks
+    if fuzzy {
+        a, b = withoutFuzzy(a), withoutFuzzy(b)
+    }
+    return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+func withoutFuzzy(quirks []string) []string {
+    list := []string{}
+    for _, q := range quirks {
+        if !fuzzyQuirks[q] {
+            list = append(list, q)
+        }
+    }
+    return list
+}
+
+// tcpFingerprintOf returns the fingerprint of a SYN or SYN+ACK packet
+func tcpFingerprintOf(packet gopacket.Packet, tcp *layers.TCP) *tcpFingerprint {
+    fp := &tcpFingerprint{ mss: -1, wsize: int(tcp.Window) }
+    quirks := map[string]bool{}
+
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        fp.ver, fp.ttl = 4, int(ipv4.TTL)
+        fp.olen = int(ipv4.IHL) * 4 - 20
+        df := ipv4.Flags & layers.IPv4DontFragment != 0
+        quirks["df"] = df
+        quirks["id+"] = df && ipv4.Id != 0
+        quirks["id-"] = !df && ipv4.Id == 0
+        quirks["0+"] = ipv4.Flags & layers.IPv4EvilBit != 0
+        quirks["ecn"] = ipv4.TOS & 0x03 != 0
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        fp.ver, fp.ttl = 6, int(ipv6.HopLimit)
+        quirks["flow"] = ipv6.FlowLabel != 0
+        quirks["ecn"] = ipv6.TrafficClass & 0x03 != 0
+    } else {
+        return nil
+    }
+
+    if tcp.ECE || tcp.CWR {
+        quirks["ecn"] = true
+    }
+    quirks["seq-"] = tcp.Seq == 0
+    quirks["ack+"] = !tcp.ACK && tcp.Ack != 0
+    quirks["ack-"] = tcp.ACK && tcp.Ack == 0
+    quirks["uptr+"] = !tcp.URG && tcp.Urgent != 0
+    quirks["urgf+"] = tcp.URG
+    quirks["pushf+"] = tcp.PSH
+    if len(tcp.Payload) > 0 {
+        fp.pclass = 1
+    }
+
+    var opts []byte
+    if end := int(tcp.DataOffset) * 4; end > 20 && end <= len(tcp.Contents) {
+        opts = tcp.Contents[20:end]
+    }
+    layout := []string{}
+    for i := 0; i < len(opts); {
+        kind := opts[i]
+        if kind == 0 {
+            rest := opts[i + 1:]
+            layout = append(layout, fmt.Sprintf("eol+%d", len(rest)))
+            for _, b := range rest {
+                if b != 0 {
+                    quirks["opt+"] = true
+                }
+            }
+            break
+        }
+        if kind == 1 {
+            layout = append(layout, "nop")
+            i++
+            continue
+        }
+        if i + 1 >= len(opts) || opts[i + 1] < 2 || i + int(opts[i + 1]) > len(opts) {
+            quirks["bad"] = true
+            break
+        }
+        data := opts[i + 2:i + int(opts[i + 1])]
+        switch kind {
+        case 2:
+            layout = append(layout, "mss")
+            if len(data) == 2 {
+                fp.mss = int(binary.BigEndian.Uint16(data))
+            }
+        case 3:
+            layout = append(layout, "ws")
+            if len(data) == 1 {
+                fp.scale = int(data[0])
+                quirks["exws"] = fp.scale > 14
+            }
+        case 4:
+            layout = append(layout, "sok")
+        case 5:
+            layout = append(layout, "sack")
+        case 8:
+            layout = append(layout, "ts")
+            if len(data) == 8 {
+                quirks["ts1-"] = binary.BigEndian.Uint32(data) == 0
+                quirks["ts2+"] = !tcp.ACK && binary.BigEndian.Uint32(data[4:]) != 0
+            }
+        default:
+            layout = append(layout, fmt.Sprintf("?%d", kind))
+        }
+        i += int(opts[i + 1])
+    }
+    fp.olayout = strings.Join(layout, ",")
+
+    for q, set := range quirks {
+        if set {
+            fp.quirks = append(fp.quirks, q)
+        }
+    }
+    sort.Strings(fp.quirks)
+    return fp
+}
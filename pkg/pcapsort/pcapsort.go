@@ -0,0 +1,327 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package pcapsort
+
+import (
+    "container/heap"
+    "io"
+    "os"
+    "sort"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/internal/tools"
+    "github.com/helviojunior/pcapraptor/pkg/gopcap"
+    "github.com/helviojunior/pcapraptor/pkg/log"
+    "github.com/helviojunior/pcapraptor/pkg/pcapw"
+)
+
+// per record overhead used to estimate the memory of buffered packets
+const recordOverhead = 64
+
+// Options controls how the capture file is sorted
+type Options struct {
+    // Maximum amount of packet data (in bytes) kept in memory
+    MaxMemory       uint64
+    // Directory used to store the temporary sorted chunks
+    TempDir         string
+    // When greater than zero, sort using a sliding reorder window instead
+    // of the external merge sort (single pass, streaming friendly)
+    Window          time.Duration
+    // Called after each packet is read
+    OnPacket        func()
+}
+
+// Result holds sorting counters
+type Result struct {
+    Packets         int64
+    OutOfOrder      int64
+    Chunks          int
+    // Packets that arrived later than the reorder window allowed and were
+    // written as soon as possible (window mode only)
+    LateWritten     int64
+}
+
+type record struct {
+    header          gopcap.PacketHeader
+    data            []byte
+    seq             int64
+    src             int
+}
+
+func (r *record) before(o *record) bool {
+    if r.header.TsSec != o.header.TsSec {
+        return r.header.TsSec < o.header.TsSec
+    }
+    if r.header.TsUsec != o.header.TsUsec {
+        return r.header.TsUsec < o.header.TsUsec
+    }
+    return r.seq < o.seq
+}
+
+func (r *record) size() uint64 {
+    return uint64(len(r.data)) + recordOverhead
+}
+
+// recordHeap is a min heap of records ordered by timestamp
+type recordHeap []*record
+
+func (h recordHeap) Len() int            { return len(h) }
+func (h recordHeap) Less(i, j int) bool  { return h[i].before(h[j]) }
+func (h recordHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *recordHeap) Push(x interface{}) { *h = append(*h, x.(*record)) }
+func (h *recordHeap) Pop() interface{} {
+    old := *h
+    n := len(old)
+    item := old[n-1]
+    old[n-1] = nil
+    *h = old[:n-1]
+    return item
+}
+
+// SortFile reorders the records of src chronologically and writes them to dst
+func SortFile(src string, dst string, opts Options) (*Result, error) {
+    if opts.MaxMemory == 0 {
+        opts.MaxMemory = 256 * tools.MiByte
+    }
+
+    r, err := gopcap.Open(src)
+    if err != nil {
+        return nil, err
+    }
+    defer r.Close()
+
+    w, err := pcapw.Open(dst, r.Header)
+    if err != nil {
+        return nil, err
+    }
+    defer w.Close()
+
+    if opts.Window > 0 {
+        return sortWindow(r, w, opts)
+    }
+
+    return sortExternal(r, w, opts)
+}
+
+// sortWindow keeps a heap of packets and only writes a packet when a newer
+// one, at least Window ahead, has been read
+func sortWindow(r *gopcap.Reader, w *pcapw.Writer, opts Options) (*Result, error) {
+    res := &Result{}
+    h := &recordHeap{}
+    var maxTime, lastWritten time.Time
+    var memory uint64
+
+    emit := func() error {
+        rec := heap.Pop(h).(*record)
+        memory -= rec.size()
+        lastWritten = r.Header.PacketTime(rec.header)
+        return w.WritePacket(rec.header, rec.data)
+    }
+
+    var seq int64
+    for {
+        ph, data, err := r.ReadNextPacket()
+        if err != nil {
+            if err == io.EOF {
+                break
+            }
+            return res, err
+        }
+        if opts.OnPacket != nil {
+            opts.OnPacket()
+        }
+
+        res.Packets++
+        ts := r.Header.PacketTime(ph)
+        if ts.Before(maxTime) {
+            res.OutOfOrder++
+        } else {
+            maxTime = ts
+        }
+        if !lastWritten.IsZero() && ts.Before(lastWritten) {
+            res.LateWritten++
+        }
+
+        rec := &record{ header: ph, data: data, seq: seq }
+        seq++
+        heap.Push(h, rec)
+        memory += rec.size()
+
+        limit := maxTime.Add(-opts.Window)
+        for h.Len() > 0 && (r.Header.PacketTime((*h)[0].header).Before(limit) || memory > opts.MaxMemory) {
+            if err := emit(); err != nil {
+                return res, err
+            }
+        }
+    }
+
+    for h.Len() > 0 {
+        if err := emit(); err != nil {
+            return res, err
+        }
+    }
+
+    if res.LateWritten > 0 {
+        log.Warnf("%d packets were older than the reorder window and remain out of order, increase --window or use the full sort", res.LateWritten)
+    }
+
+    return res, nil
+}
+
+// sortExternal splits the file in sorted chunks limited by MaxMemory
+// and then merges all chunks into the destination file
+func sortExternal(r *gopcap.Reader, w *pcapw.Writer, opts Options) (*Result, error) {
+    res := &Result{}
+    chunkFiles := []string{}
+    chunk := []*record{}
+    var memory uint64
+    var maxTime time.Time
+    var seq int64
+
+    defer func() {
+        for _, f := range chunkFiles {
+            os.Remove(f)
+        }
+    }()
+
+    flush := func() error {
+        if len(chunk) == 0 {
+            return nil
+        }
+        sort.Slice(chunk, func(i, j int) bool {
+            return chunk[i].before(chunk[j])
+        })
+
+        fn := tools.TempFileName(opts.TempDir, "pcapraptor_sort_", ".pcap")
+        chunkFiles = append(chunkFiles, fn)
+        log.Debug("Writing sorted chunk", "file", fn, "packets", len(chunk))
+
+        cw, err := pcapw.Open(fn, r.Header)
+        if err != nil {
+            return err
+        }
+        for _, rec := range chunk {
+            if err := cw.WritePacket(rec.header, rec.data); err != nil {
+                cw.Close()
+                return err
+            }
+        }
+
+        chunk = []*record{}
+        memory = 0
+        return cw.Close()
+    }
+
+    for {
+        ph, data, err := r.ReadNextPacket()
+        if err != nil {
+            if err == io.EOF {
+                break
+            }
+            return res, err
+        }
+        if opts.OnPacket != nil {
+            opts.OnPacket()
+        }
+
+        res.Packets++
+        ts := r.Header.PacketTime(ph)
+        if ts.Before(maxTime) {
+            res.OutOfOrder++
+        } else {
+            maxTime = ts
+        }
+
+        rec := &record{ header: ph, data: data, seq: seq }
+        seq++
+        chunk = append(chunk, rec)
+        memory += rec.size()
+
+        if memory >= opts.MaxMemory {
+            if err := flush(); err != nil {
+                return res, err
+            }
+        }
+    }
+
+    // Everything fits in memory, no need to merge
+    if len(chunkFiles) == 0 {
+        sort.Slice(chunk, func(i, j int) bool {
+            return chunk[i].before(chunk[j])
+        })
+        for _, rec := range chunk {
+            if err := w.WritePacket(rec.header, rec.data); err != nil {
+                return res, err
+            }
+        }
+        res.Chunks = 1
+        return res, nil
+    }
+
+    if err := flush(); err != nil {
+        return res, err
+    }
+    res.Chunks = len(chunkFiles)
+
+    return res, mergeChunks(chunkFiles, w)
+}
+
+// mergeChunks does a k-way merge of the sorted chunk files
+func mergeChunks(files []string, w *pcapw.Writer) error {
+    readers := make([]*gopcap.Reader, len(files))
+    defer func() {
+        for _, cr := range readers {
+            if cr != nil {
+                cr.Close()
+            }
+        }
+    }()
+
+    h := &recordHeap{}
+    next := func(idx int) error {
+        ph, data, err := readers[idx].ReadNextPacket()
+        if err != nil {
+            if err == io.EOF {
+                return nil
+            }
+            return err
+        }
+        // chunk index keeps the merge stable for equal timestamps
+        heap.Push(h, &record{ header: ph, data: data, seq: int64(idx), src: idx })
+        return nil
+    }
+
+    for i, f := range files {
+        cr, err := gopcap.Open(f)
+        if err != nil {
+            return err
+        }
+        readers[i] = cr
+        if err := next(i); err != nil {
+            return err
+        }
+    }
+
+    for h.Len() > 0 {
+        rec := heap.Pop(h).(*record)
+        if err := w.WritePacket(rec.header, rec.data); err != nil {
+            return err
+        }
+        if err := next(rec.src); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,98 @@
+package pcapsort
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/helviojunior/pcapraptor/pkg/gopcap"
+	"github.com/helviojunior/pcapraptor/pkg/pcapw"
+)
+
+func writeTestFile(t *testing.T, fn string, times []int32) {
+	w, err := pcapw.Open(fn, gopcap.FileHeader{
+		MagicNumber:  gopcap.MagicMicroseconds,
+		VersionMajor: 2,
+		VersionMinor: 4,
+		Snaplen:      65535,
+		Network:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for i, ts := range times {
+		data := []byte{byte(i), 0, 0, 0}
+		if err := w.WritePacket(gopcap.PacketHeader{TsSec: ts, OriginalLen: 4}, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readTimes(t *testing.T, fn string) []int32 {
+	r, err := gopcap.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	times := []int32{}
+	for {
+		h, _, err := r.ReadNextPacket()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		times = append(times, h.TsSec)
+	}
+	return times
+}
+
+func checkSorted(t *testing.T, times []int32, count int) {
+	if len(times) != count {
+		t.Fatalf("expected %d packets, got %d", count, len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if times[i] < times[i-1] {
+			t.Fatalf("packet %d out of order: %v", i, times)
+		}
+	}
+}
+
+func TestSortFileExternal(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.pcap")
+	dst := filepath.Join(dir, "dst.pcap")
+	times := []int32{10, 9, 8, 15, 1, 3, 2, 20, 12, 11, 5}
+	writeTestFile(t, src, times)
+
+	// tiny memory limit forces one chunk for every two packets
+	res, err := SortFile(src, dst, Options{MaxMemory: 2 * (4 + recordOverhead), TempDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Chunks < 2 {
+		t.Fatalf("expected several chunks, got %d", res.Chunks)
+	}
+	checkSorted(t, readTimes(t, dst), len(times))
+}
+
+func TestSortFileWindow(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.pcap")
+	dst := filepath.Join(dir, "dst.pcap")
+	times := []int32{1, 3, 2, 4, 6, 5, 7, 9, 8}
+	writeTestFile(t, src, times)
+
+	res, err := SortFile(src, dst, Options{Window: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.OutOfOrder != 3 {
+		t.Fatalf("expected 3 out of order packets, got %d", res.OutOfOrder)
+	}
+	checkSorted(t, readTimes(t, dst), len(times))
+}
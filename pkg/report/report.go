@@ -0,0 +1,127 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package report
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "reflect"
+)
+
+const (
+    FormatText      = "text"
+    FormatJSON      = "json"
+    FormatJSONL     = "jsonl"
+    FormatCSV       = "csv"
+)
+
+// Formats lists all output formats
+var Formats = []string{ FormatText, FormatJSON, FormatJSONL, FormatCSV }
+
+// CSVWriter is implemented by results that can be written as CSV
+type CSVWriter interface {
+    WriteCSV(w io.Writer) error
+}
+
+// JSONLines is implemented by results written as more than one JSON Lines
+// record. Slices are written one element per line and any other value as
+// a single line.
+type JSONLines interface {
+    JSONLines() []interface{}
+}
+
+// FormatWriter is implemented by results with formats of their own (e.g.
+// graph formats), it is used for any format other than the ones above
+type FormatWriter interface {
+    WriteFormat(w io.Writer, format string) error
+}
+
+// Supports returns true if the result can be written in the format
+func Supports(v interface{}, format string) bool {
+    switch format {
+    case FormatText, FormatJSON, FormatJSONL:
+        return true
+    case FormatCSV:
+        _, ok := v.(CSVWriter)
+        return ok
+    }
+    _, ok := v.(FormatWriter)
+    return ok
+}
+
+// Encode returns the result in the given format, text is the human
+// readable form used by FormatText
+func Encode(format string, v interface{}, text string) ([]byte, error) {
+    switch format {
+    case FormatText:
+        return []byte(text), nil
+
+    case FormatJSON:
+        out, err := json.MarshalIndent(v, "", "  ")
+        if err != nil {
+            return nil, err
+        }
+        return append(out, '\n'), nil
+
+    case FormatJSONL:
+        buf := &bytes.Buffer{}
+        enc := json.NewEncoder(buf)
+        for _, r := range records(v) {
+            if err := enc.Encode(r); err != nil {
+                return nil, err
+            }
+        }
+        return buf.Bytes(), nil
+
+    case FormatCSV:
+        cw, ok := v.(CSVWriter)
+        if !ok {
+            return nil, errors.New("csv format is not supported by this command")
+        }
+        buf := &bytes.Buffer{}
+        if err := cw.WriteCSV(buf); err != nil {
+            return nil, err
+        }
+        return buf.Bytes(), nil
+    }
+
+    if fw, ok := v.(FormatWriter); ok {
+        buf := &bytes.Buffer{}
+        if err := fw.WriteFormat(buf, format); err != nil {
+            return nil, err
+        }
+        return buf.Bytes(), nil
+    }
+
+    return nil, errors.New(fmt.Sprintf("unsupported output format (%s)", format))
+}
+
+// records returns the JSON Lines records of the result
+func records(v interface{}) []interface{} {
+    if l, ok := v.(JSONLines); ok {
+        return l.JSONLines()
+    }
+    rv := reflect.ValueOf(v)
+    if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+        list := []interface{}{}
+        for i := 0; i < rv.Len(); i++ {
+            list = append(list, rv.Index(i).Interface())
+        }
+        return list
+    }
+    return []interface{}{ v }
+}
@@ -0,0 +1,225 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package rewrite
+
+import (
+    "encoding/binary"
+
+    "github.com/helviojunior/pcapraptor/pkg/checksum"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// DHCPv4 options carrying IPv4 addresses
+var dhcpAddrOptions = map[layers.DHCPOpt]bool{
+    layers.DHCPOptRouter        : true,
+    layers.DHCPOptTimeServer    : true,
+    layers.DHCPOptNameServer    : true,
+    layers.DHCPOptDNS           : true,
+    layers.DHCPOptLogServer     : true,
+    layers.DHCPOptNTPServers    : true,
+    layers.DHCPOptNetBIOSTCPNS  : true,
+    layers.DHCPOptNetBIOSTCPDDS : true,
+    layers.DHCPOptRequestIP     : true,
+    layers.DHCPOptServerID      : true,
+    layers.DHCPOptBroadcastAddr : true,
+}
+
+// Stats holds rewrite counters
+type Stats struct {
+    Packets         int64
+    Rewritten       int64
+    Checksums       int64
+}
+
+// Rewriter applies a rule set to frames
+type Rewriter struct {
+    Rules           *Rules
+    // Recompute IP/TCP/UDP checksums of rewritten packets
+    FixChecksums    bool
+    Stats           Stats
+}
+
+// NewRewriter returns a rewriter for the given rules
+func NewRewriter(rules *Rules) *Rewriter {
+    return &Rewriter{
+        Rules           : rules,
+        FixChecksums    : true,
+    }
+}
+
+// Apply rewrites a frame, returning the new frame and true if anything
+// was changed. The original data slice is never modified.
+func (rw *Rewriter) Apply(data []byte, linkType layers.LinkType) ([]byte, bool) {
+    rw.Stats.Packets++
+
+    buf := make([]byte, len(data))
+    copy(buf, data)
+
+    // NoCopy: every layer points to buf so it can be patched in place
+    packet := gopacket.NewPacket(buf, linkType, gopacket.NoCopy)
+
+    changed := false
+    for _, l := range packet.Layers() {
+        c := l.LayerContents()
+        switch t := l.(type) {
+        case *layers.Ethernet:
+            if len(c) >= 12 {
+                changed = rw.patchMAC(c[0:6]) || changed
+                changed = rw.patchMAC(c[6:12]) || changed
+            }
+
+        case *layers.Dot1Q:
+            if len(c) >= 2 {
+                tci := binary.BigEndian.Uint16(c[0:2])
+                if id, ok := rw.Rules.MapVLAN(tci & 0x0fff); ok {
+                    binary.BigEndian.PutUint16(c[0:2], (tci & 0xf000) | id)
+                    changed = true
+                }
+            }
+
+        case *layers.ARP:
+            hl := int(t.HwAddressSize)
+            pl := int(t.ProtAddressSize)
+            if len(c) < 8 + (2 * hl) + (2 * pl) {
+                continue
+            }
+            off := 8
+            if hl == 6 {
+                changed = rw.patchMAC(c[off:off+hl]) || changed
+            }
+            off += hl
+            changed = rw.patchIP(c[off:off+pl]) || changed
+            off += pl
+            if hl == 6 {
+                changed = rw.patchMAC(c[off:off+hl]) || changed
+            }
+            off += hl
+            changed = rw.patchIP(c[off:off+pl]) || changed
+
+        case *layers.IPv4:
+            if len(c) >= 20 {
+                changed = rw.patchIP(c[12:16]) || changed
+                changed = rw.patchIP(c[16:20]) || changed
+            }
+
+        case *layers.IPv6:
+            if len(c) >= 40 {
+                changed = rw.patchIP(c[8:24]) || changed
+                changed = rw.patchIP(c[24:40]) || changed
+            }
+
+        case *layers.TCP:
+            if len(c) >= 4 {
+                changed = rw.patchPort("tcp", c[0:2]) || changed
+                changed = rw.patchPort("tcp", c[2:4]) || changed
+            }
+
+        case *layers.UDP:
+            if len(c) >= 4 {
+                changed = rw.patchPort("udp", c[0:2]) || changed
+                changed = rw.patchPort("udp", c[2:4]) || changed
+            }
+
+        case *layers.DHCPv4:
+            changed = rw.patchDHCPv4(c) || changed
+        }
+    }
+
+    if !changed {
+        return data, false
+    }
+
+    rw.Stats.Rewritten++
+    if rw.FixChecksums {
+        rw.Stats.Checksums += int64(checksum.Fix(packet))
+    }
+
+    return buf, true
+}
+
+func (rw *Rewriter) patchMAC(b []byte) bool {
+    if n := rw.Rules.MapMAC(b); n != nil {
+        copy(b, n)
+        return true
+    }
+    return false
+}
+
+func (rw *Rewriter) patchIP(b []byte) bool {
+    if n := rw.Rules.MapIP(b); n != nil {
+        copy(b, n)
+        return true
+    }
+    return false
+}
+
+func (rw *Rewriter) patchPort(proto string, b []byte) bool {
+    if p, ok := rw.Rules.MapPort(proto, binary.BigEndian.Uint16(b)); ok {
+        binary.BigEndian.PutUint16(b, p)
+        return true
+    }
+    return false
+}
+
+// patchDHCPv4 rewrites the address fields and the address options of a
+// DHCPv4 message
+func (rw *Rewriter) patchDHCPv4(c []byte) bool {
+    if len(c) < 240 {
+        return false
+    }
+
+    changed := false
+    // ciaddr, yiaddr, siaddr, giaddr
+    for off := 12; off < 28; off += 4 {
+        changed = rw.patchIP(c[off:off+4]) || changed
+    }
+    // chaddr
+    if c[1] == 1 && c[2] == 6 {
+        changed = rw.patchMAC(c[28:34]) || changed
+    }
+
+    // options after the magic cookie
+    off := 240
+    for off < len(c) {
+        opt := layers.DHCPOpt(c[off])
+        if opt == layers.DHCPOptEnd {
+            break
+        }
+        if opt == layers.DHCPOptPad {
+            off++
+            continue
+        }
+        if off + 1 >= len(c) {
+            break
+        }
+        size := int(c[off+1])
+        data := off + 2
+        if data + size > len(c) {
+            break
+        }
+
+        if dhcpAddrOptions[opt] {
+            for a := data; a + 4 <= data + size; a += 4 {
+                changed = rw.patchIP(c[a:a+4]) || changed
+            }
+        } else if opt == layers.DHCPOptClientID && size == 7 && c[data] == 1 {
+            changed = rw.patchMAC(c[data+1:data+7]) || changed
+        }
+
+        off = data + size
+    }
+
+    return changed
+}
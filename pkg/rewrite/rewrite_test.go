@@ -0,0 +1,259 @@
+package rewrite
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"github.com/helviojunior/pcapraptor/pkg/checksum"
+)
+
+func rules(t *testing.T, lines ...string) *Rules {
+	r := &Rules{}
+	for _, l := range lines {
+		if err := r.AddRule(l); err != nil {
+			t.Fatalf("%s: %s", l, err)
+		}
+	}
+	return r
+}
+
+func TestAddRule(t *testing.T) {
+	tests := []struct {
+		line  string
+		count int
+		err   bool
+	}{
+		{"", 0, false},
+		{"# comment only", 0, false},
+		{"ip 10.0.0.0/8 172.16.0.0/8 # trailing comment", 1, false},
+		{"IP 10.0.0.5 192.168.0.9", 1, false},
+		{"cidr 2001:db8::/32 2001:db9::/32", 1, false},
+		{"mac 00:11:22:33:44:55 02:00:00:00:00:01", 1, false},
+		{"port tcp 8080 80", 1, false},
+		{"port 53 5353", 1, false},
+		{"vlan 10 20", 1, false},
+		{"ip 10.0.0.0/8", 0, true},
+		{"ip 10.0.0.0/16 172.16.0.0/24", 0, true},
+		{"ip 10.0.0.0/8 2001:db8::/8", 0, true},
+		{"mac 00:11:22:33:44:55:66:77 02:00:00:00:00:01", 0, true},
+		{"port sctp 80 81", 0, true},
+		{"port tcp 0 80", 0, true},
+		{"vlan 10 4096", 0, true},
+		{"nat 10.0.0.1 10.0.0.2", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			r := &Rules{}
+			err := r.AddRule(tt.line)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if r.Count() != tt.count {
+				t.Errorf("expected %d rules, got %d", tt.count, r.Count())
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "rules.txt")
+	// the examples of the rules file format documentation
+	data := "# lab network\n" +
+		"ip 10.0.0.0/16 172.16.0.0/16\n" +
+		"mac 00:11:22:33:44:55 02:00:00:00:00:01\n" +
+		"port tcp 8080 80 # web proxy\n" +
+		"\n" +
+		"vlan 10 20\n"
+	if err := os.WriteFile(fileName, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadRules(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.IPs) != 1 || len(r.MACs) != 1 || len(r.Ports) != 1 || len(r.VLANs) != 1 {
+		t.Errorf("unexpected rules %+v", r)
+	}
+
+	if err := os.WriteFile(fileName, []byte(data+"vlan 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRules(fileName); err == nil || err.Error() != "line 7: vlan rule must be: vlan <from id> <to id>" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMapIP(t *testing.T) {
+	r := rules(t,
+		"ip 10.1.2.3 192.168.0.1",
+		"ip 10.0.0.0/16 172.16.0.0/12",
+		"ip 2001:db8::/32 2001:db9::/32")
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"10.1.2.3", "192.168.0.1"},
+		// host bits are kept
+		{"10.0.2.4", "172.16.2.4"},
+		{"10.0.255.1", "172.16.255.1"},
+		{"10.1.2.4", ""},
+		{"2001:db8::42", "2001:db9::42"},
+		{"192.168.0.1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			got := r.MapIP(ip)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("expected no match, got %s", net.IP(got))
+				}
+				return
+			}
+			if !net.IP(got).Equal(net.ParseIP(tt.want)) {
+				t.Errorf("expected %s, got %s", tt.want, net.IP(got))
+			}
+		})
+	}
+}
+
+func TestMapPort(t *testing.T) {
+	r := rules(t, "port tcp 8080 80", "port 53 5353")
+	tests := []struct {
+		proto string
+		port  uint16
+		want  uint16
+		ok    bool
+	}{
+		{"tcp", 8080, 80, true},
+		{"udp", 8080, 0, false},
+		{"udp", 53, 5353, true},
+		{"tcp", 53, 5353, true},
+		{"tcp", 443, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := r.MapPort(tt.proto, tt.port)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s/%d: expected %d %v, got %d %v", tt.proto, tt.port, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func serialize(t *testing.T, l ...gopacket.SerializableLayer) []byte {
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, l...); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// tcpFrame returns a VLAN tagged TCP frame with valid checksums
+func tcpFrame(t *testing.T, vlan uint16, srcMAC net.HardwareAddr, src, dst net.IP, sport, dport int) []byte {
+	eth := &layers.Ethernet{SrcMAC: srcMAC, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeDot1Q}
+	tag := &layers.Dot1Q{Priority: 5, VLANIdentifier: vlan, Type: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, Id: 1, Protocol: layers.IPProtocolTCP, SrcIP: src, DstIP: dst}
+	tcp := &layers.TCP{SrcPort: layers.TCPPort(sport), DstPort: layers.TCPPort(dport), Seq: 1, ACK: true, Window: 1000}
+	tcp.SetNetworkLayerForChecksum(ip)
+	return serialize(t, eth, tag, ip, tcp, gopacket.Payload("GET / HTTP/1.1\r\n\r\n"))
+}
+
+func TestApply(t *testing.T) {
+	r := rules(t,
+		"ip 10.0.0.0/24 192.168.50.0/24",
+		"mac 00:11:22:33:44:55 02:00:00:00:00:01",
+		"port tcp 8080 80",
+		"vlan 10 20")
+	orig := tcpFrame(t, 10, net.HardwareAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}, net.IP{10, 0, 0, 5}, net.IP{8, 8, 8, 8}, 40000, 8080)
+	want := tcpFrame(t, 20, net.HardwareAddr{2, 0, 0, 0, 0, 1}, net.IP{192, 168, 50, 5}, net.IP{8, 8, 8, 8}, 40000, 80)
+	saved := append([]byte{}, orig...)
+
+	rw := NewRewriter(r)
+	got, changed := rw.Apply(orig, layers.LinkTypeEthernet)
+	if !changed {
+		t.Fatal("expected the frame to be rewritten")
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unexpected frame\n%x\nexpected\n%x", got, want)
+	}
+	if !bytes.Equal(orig, saved) {
+		t.Error("Apply changed the original frame")
+	}
+	for _, res := range checksum.Verify(gopacket.NewPacket(got, layers.LinkTypeEthernet, gopacket.Default)) {
+		if res.Status != checksum.StatusOk {
+			t.Errorf("%s checksum is %s", res.Protocol, res.Status)
+		}
+	}
+	if rw.Stats.Checksums != 2 {
+		t.Errorf("expected 2 fixed checksums, got %d", rw.Stats.Checksums)
+	}
+
+	rw.FixChecksums = false
+	got, _ = rw.Apply(orig, layers.LinkTypeEthernet)
+	if bytes.Equal(got, want) {
+		t.Error("expected the original checksums without FixChecksums")
+	}
+
+	other := tcpFrame(t, 30, net.HardwareAddr{0, 1, 2, 3, 4, 5}, net.IP{10, 0, 1, 5}, net.IP{8, 8, 8, 8}, 40000, 443)
+	if got, changed := rw.Apply(other, layers.LinkTypeEthernet); changed || &got[0] != &other[0] {
+		t.Error("expected an unmatched frame to be returned as is")
+	}
+	if rw.Stats.Packets != 3 || rw.Stats.Rewritten != 2 {
+		t.Errorf("unexpected stats %+v", rw.Stats)
+	}
+}
+
+func TestApplyARPAndDHCP(t *testing.T) {
+	r := rules(t, "ip 10.0.0.0/24 192.168.50.0/24", "mac 00:11:22:33:44:55 02:00:00:00:00:01")
+	client := net.HardwareAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}
+	eth := func(t layers.EthernetType) *layers.Ethernet {
+		return &layers.Ethernet{SrcMAC: client, DstMAC: layers.EthernetBroadcast, EthernetType: t}
+	}
+
+	arp := serialize(t, eth(layers.EthernetTypeARP), &layers.ARP{
+		AddrType: layers.LinkTypeEthernet, Protocol: layers.EthernetTypeIPv4, HwAddressSize: 6, ProtAddressSize: 4,
+		Operation: layers.ARPRequest, SourceHwAddress: client, SourceProtAddress: []byte{10, 0, 0, 5},
+		DstHwAddress: make([]byte, 6), DstProtAddress: []byte{10, 0, 0, 1}})
+	got, _ := NewRewriter(r).Apply(arp, layers.LinkTypeEthernet)
+	a := gopacket.NewPacket(got, layers.LinkTypeEthernet, gopacket.Default).Layer(layers.LayerTypeARP).(*layers.ARP)
+	if net.HardwareAddr(a.SourceHwAddress).String() != "02:00:00:00:00:01" ||
+		!net.IP(a.SourceProtAddress).Equal(net.IP{192, 168, 50, 5}) || !net.IP(a.DstProtAddress).Equal(net.IP{192, 168, 50, 1}) {
+		t.Errorf("unexpected ARP %s %s -> %s", net.HardwareAddr(a.SourceHwAddress), net.IP(a.SourceProtAddress), net.IP(a.DstProtAddress))
+	}
+
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.IP{10, 0, 0, 1}, DstIP: net.IP{10, 0, 0, 5}}
+	udp := &layers.UDP{SrcPort: 67, DstPort: 68}
+	udp.SetNetworkLayerForChecksum(ip)
+	dhcp := &layers.DHCPv4{Operation: layers.DHCPOpReply, HardwareType: layers.LinkTypeEthernet, HardwareLen: 6,
+		YourClientIP: net.IP{10, 0, 0, 5}, ClientHWAddr: client,
+		Options: layers.DHCPOptions{
+			layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(layers.DHCPMsgTypeAck)}),
+			layers.NewDHCPOption(layers.DHCPOptRouter, []byte{10, 0, 0, 1}),
+			layers.NewDHCPOption(layers.DHCPOptDNS, []byte{10, 0, 0, 2, 8, 8, 8, 8}),
+			layers.NewDHCPOption(layers.DHCPOptClientID, append([]byte{1}, client...)),
+		}}
+	got, _ = NewRewriter(r).Apply(serialize(t, eth(layers.EthernetTypeIPv4), ip, udp, dhcp), layers.LinkTypeEthernet)
+	d := gopacket.NewPacket(got, layers.LinkTypeEthernet, gopacket.Default).Layer(layers.LayerTypeDHCPv4).(*layers.DHCPv4)
+	if !d.YourClientIP.Equal(net.IP{192, 168, 50, 5}) || d.ClientHWAddr.String() != "02:00:00:00:00:01" {
+		t.Errorf("unexpected DHCP addresses %s %s", d.YourClientIP, d.ClientHWAddr)
+	}
+	want := map[layers.DHCPOpt][]byte{
+		layers.DHCPOptRouter:   {192, 168, 50, 1},
+		layers.DHCPOptDNS:      {192, 168, 50, 2, 8, 8, 8, 8},
+		layers.DHCPOptClientID: {1, 2, 0, 0, 0, 0, 1},
+	}
+	for _, o := range d.Options {
+		if w, ok := want[o.Type]; ok && !bytes.Equal(o.Data, w) {
+			t.Errorf("option %s: expected %v, got %v", o.Type, w, o.Data)
+		}
+	}
+}
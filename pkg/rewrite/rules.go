@@ -0,0 +1,264 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package rewrite
+
+import (
+    "bufio"
+    "bytes"
+    "errors"
+    "fmt"
+    "net"
+    "os"
+    "strconv"
+    "strings"
+)
+
+// Rules file format, one rule per line ('#' starts a comment):
+//
+//   ip   <from cidr> <to cidr>        e.g. ip 10.0.0.0/16 172.16.0.0/16
+//   mac  <from mac> <to mac>          e.g. mac 00:11:22:33:44:55 02:00:00:00:00:01
+//   port [tcp|udp] <from> <to>        e.g. port tcp 8080 80
+//   vlan <from id> <to id>            e.g. vlan 10 20
+//
+// IP rules keep the host bits, so the destination prefix length must be
+// equal or shorter than the source one.
+
+// IPRule maps a network into another one
+type IPRule struct {
+    From            *net.IPNet
+    To              *net.IPNet
+}
+
+// MACRule maps a MAC address into another one
+type MACRule struct {
+    From            net.HardwareAddr
+    To              net.HardwareAddr
+}
+
+// PortRule maps a TCP/UDP port into another one
+type PortRule struct {
+    Proto           string
+    From            uint16
+    To              uint16
+}
+
+// VLANRule retags a VLAN ID
+type VLANRule struct {
+    From            uint16
+    To              uint16
+}
+
+// Rules is the set of rewrite rules
+type Rules struct {
+    IPs             []IPRule
+    MACs            []MACRule
+    Ports           []PortRule
+    VLANs           []VLANRule
+}
+
+// Count returns the number of rules
+func (r *Rules) Count() int {
+    return len(r.IPs) + len(r.MACs) + len(r.Ports) + len(r.VLANs)
+}
+
+// LoadRules reads a rules file
+func LoadRules(fileName string) (*Rules, error) {
+    f, err := os.Open(fileName)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    rules := &Rules{}
+    scanner := bufio.NewScanner(f)
+    lineNum := 0
+    for scanner.Scan() {
+        lineNum++
+        if err := rules.AddRule(scanner.Text()); err != nil {
+            return nil, errors.New(fmt.Sprintf("line %d: %s", lineNum, err))
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+
+    return rules, nil
+}
+
+// AddRule parses one rule line
+func (r *Rules) AddRule(line string) error {
+    if idx := strings.Index(line, "#"); idx >= 0 {
+        line = line[:idx]
+    }
+    fields := strings.Fields(line)
+    if len(fields) == 0 {
+        return nil
+    }
+
+    switch strings.ToLower(fields[0]) {
+    case "ip", "cidr", "net":
+        if len(fields) != 3 {
+            return errors.New("ip rule must be: ip <from cidr> <to cidr>")
+        }
+        from, err := parseCIDR(fields[1])
+        if err != nil {
+            return err
+        }
+        to, err := parseCIDR(fields[2])
+        if err != nil {
+            return err
+        }
+        if len(from.IP) != len(to.IP) {
+            return errors.New("ip rule cannot map between IPv4 and IPv6")
+        }
+        fOnes, _ := from.Mask.Size()
+        tOnes, _ := to.Mask.Size()
+        if tOnes > fOnes {
+            return errors.New(fmt.Sprintf("destination network %s is smaller than source network %s", to, from))
+        }
+        r.IPs = append(r.IPs, IPRule{ From: from, To: to })
+
+    case "mac":
+        if len(fields) != 3 {
+            return errors.New("mac rule must be: mac <from mac> <to mac>")
+        }
+        from, err := net.ParseMAC(fields[1])
+        if err != nil {
+            return err
+        }
+        to, err := net.ParseMAC(fields[2])
+        if err != nil {
+            return err
+        }
+        if len(from) != 6 || len(to) != 6 {
+            return errors.New("only 48 bit MAC addresses are supported")
+        }
+        r.MACs = append(r.MACs, MACRule{ From: from, To: to })
+
+    case "port":
+        proto := "any"
+        args := fields[1:]
+        if len(args) == 3 {
+            proto = strings.ToLower(args[0])
+            args = args[1:]
+        }
+        if len(args) != 2 || (proto != "tcp" && proto != "udp" && proto != "any") {
+            return errors.New("port rule must be: port [tcp|udp] <from> <to>")
+        }
+        from, err := parseUint16(args[0], 1, 65535)
+        if err != nil {
+            return err
+        }
+        to, err := parseUint16(args[1], 1, 65535)
+        if err != nil {
+            return err
+        }
+        r.Ports = append(r.Ports, PortRule{ Proto: proto, From: from, To: to })
+
+    case "vlan":
+        if len(fields) != 3 {
+            return errors.New("vlan rule must be: vlan <from id> <to id>")
+        }
+        from, err := parseUint16(fields[1], 0, 4095)
+        if err != nil {
+            return err
+        }
+        to, err := parseUint16(fields[2], 0, 4095)
+        if err != nil {
+            return err
+        }
+        r.VLANs = append(r.VLANs, VLANRule{ From: from, To: to })
+
+    default:
+        return errors.New(fmt.Sprintf("unknown rule type (%s)", fields[0]))
+    }
+
+    return nil
+}
+
+func parseCIDR(s string) (*net.IPNet, error) {
+    if !strings.Contains(s, "/") {
+        if ip := net.ParseIP(s); ip != nil {
+            if ip.To4() != nil {
+                s += "/32"
+            } else {
+                s += "/128"
+            }
+        }
+    }
+    _, n, err := net.ParseCIDR(s)
+    if err != nil {
+        return nil, err
+    }
+    if ip4 := n.IP.To4(); ip4 != nil {
+        n.IP = ip4
+    }
+    return n, nil
+}
+
+func parseUint16(s string, min int, max int) (uint16, error) {
+    v, err := strconv.Atoi(s)
+    if err != nil {
+        return 0, err
+    }
+    if v < min || v > max {
+        return 0, errors.New(fmt.Sprintf("value %d out of range (%d-%d)", v, min, max))
+    }
+    return uint16(v), nil
+}
+
+// MapIP returns the rewritten address, or nil if no rule matches
+func (r *Rules) MapIP(ip []byte) []byte {
+    for _, rule := range r.IPs {
+        if len(ip) != len(rule.From.IP) || !rule.From.Contains(ip) {
+            continue
+        }
+        out := make([]byte, len(ip))
+        for i := range ip {
+            out[i] = rule.To.IP[i] | (ip[i] & ^rule.From.Mask[i])
+        }
+        return out
+    }
+    return nil
+}
+
+// MapMAC returns the rewritten MAC address, or nil if no rule matches
+func (r *Rules) MapMAC(mac []byte) []byte {
+    for _, rule := range r.MACs {
+        if bytes.Equal(mac, rule.From) {
+            return rule.To
+        }
+    }
+    return nil
+}
+
+// MapPort returns the rewritten port and true if a rule matches
+func (r *Rules) MapPort(proto string, port uint16) (uint16, bool) {
+    for _, rule := range r.Ports {
+        if rule.From == port && (rule.Proto == "any" || rule.Proto == proto) {
+            return rule.To, true
+        }
+    }
+    return 0, false
+}
+
+// MapVLAN returns the new VLAN ID and true if a rule matches
+func (r *Rules) MapVLAN(id uint16) (uint16, bool) {
+    for _, rule := range r.VLANs {
+        if rule.From == id {
+            return rule.To, true
+        }
+    }
+    return 0, false
+}
@@ -0,0 +1,96 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tcpstream
+
+// maxPendingSegments is the most out of order segments kept
+const maxPendingSegments = 64
+
+// Stream reassembles one direction of a TCP connection from the first
+// segment seen, keeping the out of order segments until the gap is
+// filled. The data is buffered until the parser consumes it (Skip)
+type Stream struct {
+    nextSeq         uint32
+    data            []byte
+    pending         map[uint32][]byte
+    max             int
+}
+
+// New returns a stream starting at seq, buffering at most max bytes
+func New(seq uint32, max int) *Stream {
+    return &Stream{ nextSeq: seq, pending: map[uint32][]byte{}, max: max }
+}
+
+// Add adds a segment, returning false when nothing new was appended
+func (s *Stream) Add(seq uint32, payload []byte) bool {
+    if len(payload) == 0 || len(s.data) >= s.max {
+        return false
+    }
+    diff := int32(seq - s.nextSeq)
+    if diff > 0 {
+        if len(s.pending) < maxPendingSegments {
+            s.pending[seq] = append([]byte{}, payload...)
+        }
+        return false
+    }
+    // retransmission, keep only the new bytes
+    if -diff >= int32(len(payload)) {
+        return false
+    }
+    s.append(payload[-diff:])
+
+    for len(s.pending) > 0 {
+        found := false
+        for seq, p := range s.pending {
+            d := int32(seq - s.nextSeq)
+            if d > 0 {
+                continue
+            }
+            delete(s.pending, seq)
+            if -d < int32(len(p)) {
+                s.append(p[-d:])
+                found = true
+            }
+        }
+        if !found {
+            break
+        }
+    }
+    return true
+}
+
+func (s *Stream) append(p []byte) {
+    s.data = append(s.data, p...)
+    s.nextSeq += uint32(len(p))
+}
+
+// Data returns the buffered data not consumed yet
+func (s *Stream) Data() []byte {
+    return s.data
+}
+
+// Skip consumes the first n buffered bytes
+func (s *Stream) Skip(n int) {
+    if n >= len(s.data) {
+        s.data = nil
+        return
+    }
+    s.data = append([]byte{}, s.data[n:]...)
+}
+
+// Close drops the buffered data and stops buffering
+func (s *Stream) Close() {
+    s.data = nil
+    s.pending = map[uint32][]byte{}
+    s.max = 0
+}
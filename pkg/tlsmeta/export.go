@@ -0,0 +1,59 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tlsmeta
+
+import (
+    "encoding/csv"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Sessions is the list of TLS sessions found
+type Sessions []Session
+
+var SessionsCSVHeader = []string{
+    "client", "server", "sni", "alpn", "negotiated_alpn", "client_version", "version", "cipher",
+    "ja3", "ja3_hash", "ja3s", "ja3s_hash", "ja4", "subject", "issuer", "san", "serial",
+    "not_before", "not_after", "self_signed", "expired", "sha256", "chain_length", "first_seen",
+}
+
+// WriteCSV writes the sessions as CSV, multi-valued columns are
+// separated by '; '
+func (l Sessions) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(SessionsCSVHeader); err != nil {
+        return err
+    }
+    for _, s := range l {
+        row := []string{
+            s.Client, s.Server, s.SNI, strings.Join(s.ALPN, "; "), s.NegotiatedALPN, s.ClientVersion,
+            s.Version, s.Cipher, s.JA3, s.JA3Hash, s.JA3S, s.JA3SHash, s.JA4,
+        }
+        if c := s.Certificate; c != nil {
+            row = append(row, c.Subject, c.Issuer, strings.Join(c.SANs, "; "), c.Serial,
+                c.NotBefore.UTC().Format(time.RFC3339), c.NotAfter.UTC().Format(time.RFC3339),
+                strconv.FormatBool(c.SelfSigned), strconv.FormatBool(c.Expired), c.SHA256)
+        } else {
+            row = append(row, "", "", "", "", "", "", "", "", "")
+        }
+        row = append(row, strconv.Itoa(s.ChainLength), s.FirstSeen.UTC().Format(time.RFC3339Nano))
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
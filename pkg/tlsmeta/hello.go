@@ -0,0 +1,310 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tlsmeta
+
+import (
+    "crypto/md5"
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// TLS extensions used by the fingerprints
+const (
+    extServerName           = 0x0000
+    extSupportedGroups      = 0x000a
+    extECPointFormats       = 0x000b
+    extSignatureAlgorithms  = 0x000d
+    extALPN                 = 0x0010
+    extSupportedVersions    = 0x002b
+)
+
+var errHello = errors.New("invalid TLS hello message")
+
+// clientHello is the part of the ClientHello used by the fingerprints
+type clientHello struct {
+    version         uint16
+    ciphers         []uint16
+    extensions      []uint16
+    groups          []uint16
+    pointFormats    []uint8
+    sigAlgs         []uint16
+    versions        []uint16
+    sni             string
+    alpn            []string
+}
+
+// serverHello is the part of the ServerHello used by the fingerprints
+type serverHello struct {
+    version         uint16
+    cipher          uint16
+    extensions      []uint16
+    // supported_versions extension, TLS 1.3
+    selected        uint16
+    alpn            string
+}
+
+// isGREASE tells the reserved GREASE values (RFC 8701) apart, they are
+// left out of the fingerprints
+func isGREASE(v uint16) bool {
+    return v & 0x0f0f == 0x0a0a && v >> 8 == v & 0xff
+}
+
+// reader reads the big endian fields of a handshake message
+type reader struct {
+    data            []byte
+    err             bool
+}
+
+func (r *reader) bytes(n int) []byte {
+    if r.err || n < 0 || len(r.data) < n {
+        r.err = true
+        return nil
+    }
+    b := r.data[:n]
+    r.data = r.data[n:]
+    return b
+}
+
+func (r *reader) u8() int {
+    b := r.bytes(1)
+    if b == nil {
+        return 0
+    }
+    return int(b[0])
+}
+
+func (r *reader) u16() int {
+    b := r.bytes(2)
+    if b == nil {
+        return 0
+    }
+    return int(binary.BigEndian.Uint16(b))
+}
+
+// vector returns a reader on a vector prefixed by its size
+func (r *reader) vector(sizeLen int) *reader {
+    size := 0
+    switch sizeLen {
+    case 1:
+        size = r.u8()
+    case 2:
+        size = r.u16()
+    }
+    return &reader{ data: r.bytes(size), err: r.err }
+}
+
+func (r *reader) u16s() []uint16 {
+    list := []uint16{}
+    for len(r.data) >= 2 {
+        list = append(list, uint16(r.u16()))
+    }
+    return list
+}
+
+func parseClientHello(data []byte) (*clientHello, error) {
+    r := &reader{ data: data }
+    h := &clientHello{ version: uint16(r.u16()) }
+    r.bytes(32)
+    r.vector(1)
+    h.ciphers = r.vector(2).u16s()
+    r.vector(1)
+    if r.err {
+        return nil, errHello
+    }
+    if len(r.data) == 0 {
+        return h, nil
+    }
+
+    exts := r.vector(2)
+    for len(exts.data) >= 4 && !exts.err {
+        id := uint16(exts.u16())
+        ext := exts.vector(2)
+        h.extensions = append(h.extensions, id)
+        switch id {
+        case extServerName:
+            list := ext.vector(2)
+            for len(list.data) > 3 && !list.err {
+                kind := list.u8()
+                name := list.vector(2)
+                if kind == 0 && !name.err {
+                    h.sni = string(name.data)
+                }
+            }
+        case extSupportedGroups:
+            h.groups = ext.vector(2).u16s()
+        case extECPointFormats:
+            h.pointFormats = append([]uint8{}, ext.vector(1).data...)
+        case extSignatureAlgorithms:
+            h.sigAlgs = ext.vector(2).u16s()
+        case extALPN:
+            list := ext.vector(2)
+            for len(list.data) > 0 && !list.err {
+                if p := list.vector(1); !p.err {
+                    h.alpn = append(h.alpn, string(p.data))
+                }
+            }
+        case extSupportedVersions:
+            h.versions = ext.vector(1).u16s()
+        }
+    }
+    if exts.err {
+        return nil, errHello
+    }
+    return h, nil
+}
+
+func parseServerHello(data []byte) (*serverHello, error) {
+    r := &reader{ data: data }
+    h := &serverHello{ version: uint16(r.u16()) }
+    r.bytes(32)
+    r.vector(1)
+    h.cipher = uint16(r.u16())
+    r.u8()
+    if r.err {
+        return nil, errHello
+    }
+    if len(r.data) == 0 {
+        return h, nil
+    }
+
+    exts := r.vector(2)
+    for len(exts.data) >= 4 && !exts.err {
+        id := uint16(exts.u16())
+        ext := exts.vector(2)
+        h.extensions = append(h.extensions, id)
+        switch id {
+        case extALPN:
+            if p := ext.vector(2).vector(1); !p.err {
+                h.alpn = string(p.data)
+            }
+        case extSupportedVersions:
+            h.selected = uint16(ext.u16())
+        }
+    }
+    if exts.err {
+        return nil, errHello
+    }
+    return h, nil
+}
+
+func joinInts[T uint8 | uint16](list []T, skipGREASE bool) string {
+    s := []string{}
+    for _, v := range list {
+        if skipGREASE && isGREASE(uint16(v)) {
+            continue
+        }
+        s = append(s, strconv.Itoa(int(v)))
+    }
+    return strings.Join(s, "-")
+}
+
+func md5Hex(s string) string {
+    sum := md5.Sum([]byte(s))
+    return hex.EncodeToString(sum[:])
+}
+
+// ja3 returns the JA3 string of the ClientHello: version, ciphers,
+// extensions, groups and point formats
+func (h *clientHello) ja3() string {
+    return fmt.Sprintf("%d,%s,%s,%s,%s", h.version,
+        joinInts(h.ciphers, true), joinInts(h.extensions, true),
+        joinInts(h.groups, true), joinInts(h.pointFormats, false))
+}
+
+// ja3s returns the JA3S string of the ServerHello: version, cipher and
+// extensions
+func (h *serverHello) ja3s() string {
+    return fmt.Sprintf("%d,%d,%s", h.version, h.cipher, joinInts(h.extensions, false))
+}
+
+// ja4 returns the JA4 fingerprint of the ClientHello (TCP)
+func (h *clientHello) ja4() string {
+    version := h.version
+    for _, v := range h.versions {
+        if !isGREASE(v) && v > version {
+            version = v
+        }
+    }
+    ver := map[uint16]string{ 0x0304: "13", 0x0303: "12", 0x0302: "11", 0x0301: "10", 0x0300: "s3" }[version]
+    if ver == "" {
+        ver = "00"
+    }
+    sni := "i"
+    if h.sni != "" {
+        sni = "d"
+    }
+
+    ciphers := []string{}
+    for _, c := range h.ciphers {
+        if !isGREASE(c) {
+            ciphers = append(ciphers, fmt.Sprintf("%04x", c))
+        }
+    }
+    exts := []string{}
+    count := 0
+    for _, e := range h.extensions {
+        if isGREASE(e) {
+            continue
+        }
+        count++
+        if e != extServerName && e != extALPN {
+            exts = append(exts, fmt.Sprintf("%04x", e))
+        }
+    }
+    alpn := "00"
+    if len(h.alpn) > 0 && h.alpn[0] != "" {
+        a := h.alpn[0]
+        if isAlnum(a[0]) && isAlnum(a[len(a) - 1]) {
+            alpn = string(a[0]) + string(a[len(a) - 1])
+        } else {
+            x := hex.EncodeToString([]byte(a))
+            alpn = string(x[0]) + string(x[len(x) - 1])
+        }
+    }
+
+    part := fmt.Sprintf("t%s%s%02d%02d%s", ver, sni, min(len(ciphers), 99), min(count, 99), alpn)
+
+    sort.Strings(ciphers)
+    sort.Strings(exts)
+    extStr := strings.Join(exts, ",")
+    sigs := []string{}
+    for _, s := range h.sigAlgs {
+        if !isGREASE(s) {
+            sigs = append(sigs, fmt.Sprintf("%04x", s))
+        }
+    }
+    if len(sigs) > 0 {
+        extStr += "_" + strings.Join(sigs, ",")
+    }
+
+    return part + "_" + sha256Prefix(strings.Join(ciphers, ","), len(ciphers)) + "_" + sha256Prefix(extStr, len(exts))
+}
+
+func sha256Prefix(s string, items int) string {
+    if items == 0 {
+        return "000000000000"
+    }
+    sum := sha256.Sum256([]byte(s))
+    return hex.EncodeToString(sum[:])[:12]
+}
+
+func isAlnum(b byte) bool {
+    return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,334 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tlsmeta
+
+import (
+    "crypto/sha256"
+    "crypto/tls"
+    "crypto/x509"
+    "encoding/binary"
+    "encoding/hex"
+    "net"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/tcpstream"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+// TLS record and handshake types
+const (
+    recordChangeCipherSpec  = 20
+    recordAlert             = 21
+    recordHandshake         = 22
+    recordApplicationData   = 23
+
+    handshakeClientHello    = 1
+    handshakeServerHello    = 2
+    handshakeCertificate    = 11
+    handshakeServerDone     = 14
+)
+
+// Certificate is the server (leaf) certificate
+type Certificate struct {
+    Subject         string      `json:"subject"`
+    Issuer          string      `json:"issuer"`
+    SANs            []string    `json:"san"`
+    Serial          string      `json:"serial"`
+    NotBefore       time.Time   `json:"not_before"`
+    NotAfter        time.Time   `json:"not_after"`
+    SelfSigned      bool        `json:"self_signed"`
+    // out of the validity period when the session was seen
+    Expired         bool        `json:"expired"`
+    SHA256          string      `json:"sha256"`
+}
+
+// Session is the TLS metadata of a TCP connection. The certificate is
+// only seen up to TLS 1.2, TLS 1.3 encrypts it
+type Session struct {
+    Client          string          `json:"client"`
+    Server          string          `json:"server"`
+    SNI             string          `json:"sni"`
+    // protocols offered by the client
+    ALPN            []string        `json:"alpn"`
+    // protocol selected by the server
+    NegotiatedALPN  string          `json:"negotiated_alpn"`
+    // highest version offered by the client
+    ClientVersion   string          `json:"client_version"`
+    Version         string          `json:"version"`
+    Cipher          string          `json:"cipher"`
+    JA3             string          `json:"ja3"`
+    JA3Hash         string          `json:"ja3_hash"`
+    JA3S            string          `json:"ja3s"`
+    JA3SHash        string          `json:"ja3s_hash"`
+    JA4             string          `json:"ja4"`
+    Certificate     *Certificate    `json:"certificate"`
+    ChainLength     int             `json:"chain_length"`
+    FirstSeen       time.Time       `json:"first_seen"`
+}
+
+// maxStreamSize is the most bytes buffered by direction, the handshake
+// is far smaller
+const maxStreamSize = 256 * 1024
+
+// direction is one side of the connection and its handshake parsing
+type direction struct {
+    stream          *tcpstream.Stream
+    // bytes of the records already parsed
+    parsed          int
+    // handshake messages spanning records
+    handshake       []byte
+    done            bool
+}
+
+type flow struct {
+    session         *Session
+    // sequence number of the ClientHello
+    helloSeq        uint32
+    client          *direction
+    server          *direction
+}
+
+// Collector extracts the TLS metadata packet by packet
+type Collector struct {
+    flows           map[string]*flow
+    sessions        []*Session
+}
+
+func NewCollector() *Collector {
+    return &Collector{ flows: map[string]*flow{} }
+}
+
+// isHandshake tells if the payload starts with a TLS handshake record
+func isHandshake(payload []byte) bool {
+    return len(payload) >= 6 && payload[0] == recordHandshake && payload[1] == 3 && payload[2] <= 4
+}
+
+// Add collects the TLS handshake of the packet
+func (c *Collector) Add(packet gopacket.Packet, ts time.Time) {
+    var src, dst net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        src, dst = ipv4.SrcIP, ipv4.DstIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        src, dst = ipv6.SrcIP, ipv6.DstIP
+    }
+    tcpLayer := packet.Layer(layers.LayerTypeTCP)
+    if src == nil || tcpLayer == nil {
+        return
+    }
+    tcp := tcpLayer.(*layers.TCP)
+    a := net.JoinHostPort(src.String(), strconv.Itoa(int(tcp.SrcPort)))
+    b := net.JoinHostPort(dst.String(), strconv.Itoa(int(tcp.DstPort)))
+
+    // a new connection reusing the 4-tuple starts a new session
+    if tcp.SYN && !tcp.ACK {
+        delete(c.flows, a + "|" + b)
+    }
+    if len(tcp.Payload) == 0 {
+        return
+    }
+
+    var d *direction
+    f := c.flows[a + "|" + b]
+    if f != nil && isHandshake(tcp.Payload) && tcp.Payload[5] == handshakeClientHello {
+        // the SYN was not captured, a ClientHello far from the first one
+        // is a new connection (retransmissions and the ClientHello after
+        // a HelloRetryRequest are close to it)
+        if diff := int32(tcp.Seq - f.helloSeq); diff < 0 || diff >= maxStreamSize {
+            delete(c.flows, a + "|" + b)
+            f = nil
+        }
+    }
+    if f != nil {
+        d = f.client
+    } else if f = c.flows[b + "|" + a]; f != nil {
+        if f.server == nil {
+            if !isHandshake(tcp.Payload) {
+                return
+            }
+            f.server = &direction{ stream: tcpstream.New(tcp.Seq, maxStreamSize) }
+        }
+        d = f.server
+    } else {
+        // a new connection starts with the ClientHello
+        if !isHandshake(tcp.Payload) || tcp.Payload[5] != handshakeClientHello {
+            return
+        }
+        f = &flow{
+            session     : &Session{ Client: a, Server: b, ALPN: []string{}, FirstSeen: ts },
+            helloSeq    : tcp.Seq,
+            client      : &direction{ stream: tcpstream.New(tcp.Seq, maxStreamSize) },
+        }
+        c.flows[a + "|" + b] = f
+        c.sessions = append(c.sessions, f.session)
+        d = f.client
+    }
+
+    if d.done || !d.stream.Add(tcp.Seq, tcp.Payload) {
+        return
+    }
+    c.parse(f, d, ts)
+}
+
+// parse parses the complete records of the direction
+func (c *Collector) parse(f *flow, d *direction, ts time.Time) {
+    data := d.stream.Data()
+    for !d.done && len(data) - d.parsed >= 5 {
+        rec := data[d.parsed:]
+        kind := rec[0]
+        size := int(binary.BigEndian.Uint16(rec[3:5]))
+        if rec[1] != 3 {
+            d.done = true
+            return
+        }
+        if len(rec) < 5 + size {
+            return
+        }
+        d.parsed += 5 + size
+
+        switch kind {
+        case recordHandshake:
+            d.handshake = append(d.handshake, rec[5:5 + size]...)
+            c.parseHandshake(f, d, ts)
+        case recordChangeCipherSpec, recordApplicationData, recordAlert:
+            // the rest is encrypted
+            d.done = true
+        default:
+            d.done = true
+        }
+    }
+    if d.done {
+        d.stream.Close()
+        d.handshake = nil
+    }
+}
+
+// parseHandshake parses the complete handshake messages of the direction
+func (c *Collector) parseHandshake(f *flow, d *direction, ts time.Time) {
+    s := f.session
+    for len(d.handshake) >= 4 {
+        kind := d.handshake[0]
+        size := int(d.handshake[1]) << 16 | int(d.handshake[2]) << 8 | int(d.handshake[3])
+        if len(d.handshake) < 4 + size {
+            return
+        }
+        msg := d.handshake[4:4 + size]
+        d.handshake = d.handshake[4 + size:]
+
+        switch kind {
+        case handshakeClientHello:
+            h, err := parseClientHello(msg)
+            if err != nil {
+                d.done = true
+                return
+            }
+            s.SNI = h.sni
+            s.ALPN = append([]string{}, h.alpn...)
+            version := h.version
+            for _, v := range h.versions {
+                if !isGREASE(v) && v > version {
+                    version = v
+                }
+            }
+            s.ClientVersion = tls.VersionName(version)
+            s.JA3 = h.ja3()
+            s.JA3Hash = md5Hex(s.JA3)
+            s.JA4 = h.ja4()
+            // nothing else is needed from the client
+            d.done = true
+            return
+
+        case handshakeServerHello:
+            h, err := parseServerHello(msg)
+            if err != nil {
+                d.done = true
+                return
+            }
+            version := h.version
+            if h.selected != 0 {
+                version = h.selected
+            }
+            s.Version = tls.VersionName(version)
+            s.Cipher = tls.CipherSuiteName(h.cipher)
+            s.NegotiatedALPN = h.alpn
+            s.JA3S = h.ja3s()
+            s.JA3SHash = md5Hex(s.JA3S)
+            if version >= tls.VersionTLS13 {
+                d.done = true
+                return
+            }
+
+        case handshakeCertificate:
+            c.parseCertificates(s, msg, ts)
+
+        case handshakeServerDone:
+            d.done = true
+            return
+        }
+    }
+}
+
+// parseCertificates parses the TLS 1.2 certificate chain, the first one
+// is the server certificate
+func (c *Collector) parseCertificates(s *Session, msg []byte, ts time.Time) {
+    r := &reader{ data: msg }
+    size := r.u8() << 16 | r.u16()
+    list := &reader{ data: r.bytes(size), err: r.err }
+    for len(list.data) >= 3 && !list.err {
+        n := list.u8() << 16 | list.u16()
+        der := list.bytes(n)
+        if list.err {
+            return
+        }
+        s.ChainLength++
+        if s.Certificate != nil {
+            continue
+        }
+        cert, err := x509.ParseCertificate(der)
+        if err != nil {
+            continue
+        }
+        sans := append([]string{}, cert.DNSNames...)
+        for _, ip := range cert.IPAddresses {
+            sans = append(sans, ip.String())
+        }
+        sans = append(sans, cert.EmailAddresses...)
+        sum := sha256.Sum256(der)
+        s.Certificate = &Certificate{
+            Subject     : cert.Subject.String(),
+            Issuer      : cert.Issuer.String(),
+            SANs        : sans,
+            Serial      : cert.SerialNumber.Text(16),
+            NotBefore   : cert.NotBefore,
+            NotAfter    : cert.NotAfter,
+            SelfSigned  : cert.Subject.String() == cert.Issuer.String(),
+            Expired     : ts.After(cert.NotAfter) || ts.Before(cert.NotBefore),
+            SHA256      : hex.EncodeToString(sum[:]),
+        }
+    }
+}
+
+// Sessions returns the TLS sessions found, ordered by first seen time
+func (c *Collector) Sessions() []Session {
+    list := []Session{}
+    for _, s := range c.sessions {
+        list = append(list, *s)
+    }
+    sort.SliceStable(list, func(i, j int) bool { return list[i].FirstSeen.Before(list[j].FirstSeen) })
+    return list
+}
@@ -0,0 +1,225 @@
+package tlsmeta
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func u16(v ...uint16) []byte {
+	out := []byte{}
+	for _, x := range v {
+		out = binary.BigEndian.AppendUint16(out, x)
+	}
+	return out
+}
+
+// vec prefixes data by its size, in sizeLen bytes
+func vec(sizeLen int, data []byte) []byte {
+	if sizeLen == 1 {
+		return append([]byte{byte(len(data))}, data...)
+	}
+	return append(u16(uint16(len(data))), data...)
+}
+
+type ext struct {
+	id   uint16
+	data []byte
+}
+
+func buildHello(version uint16, ciphers []uint16, exts []ext) []byte {
+	m := u16(version)
+	m = append(m, make([]byte, 32)...)
+	m = append(m, 0)
+	m = append(m, vec(2, u16(ciphers...))...)
+	m = append(m, 1, 0)
+	list := []byte{}
+	for _, e := range exts {
+		list = append(list, u16(e.id)...)
+		list = append(list, vec(2, e.data)...)
+	}
+	return append(m, vec(2, list)...)
+}
+
+func sniExt(name string) ext {
+	return ext{extServerName, vec(2, append([]byte{0}, vec(2, []byte(name))...))}
+}
+
+func alpnExt(protos ...string) ext {
+	list := []byte{}
+	for _, p := range protos {
+		list = append(list, vec(1, []byte(p))...)
+	}
+	return ext{extALPN, vec(2, list)}
+}
+
+func TestJA3(t *testing.T) {
+	// the JA3 reference example, with GREASE values that are left out
+	data := buildHello(769,
+		[]uint16{0x0a0a, 47, 53, 5, 10, 49161, 49162, 49171, 49172, 50, 56, 19, 4},
+		[]ext{
+			{0x1a1a, nil},
+			sniExt("example.com"),
+			{extSupportedGroups, vec(2, u16(0x2a2a, 23, 24, 25))},
+			{extECPointFormats, vec(1, []byte{0})},
+		})
+	h, err := parseClientHello(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "769,47-53-5-10-49161-49162-49171-49172-50-56-19-4,0-10-11,23-24-25,0"
+	if got := h.ja3(); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if got := md5Hex(h.ja3()); got != "ada70206e40642a3e4461f35503241d5" {
+		t.Errorf("unexpected JA3 hash %s", got)
+	}
+}
+
+func TestJA3S(t *testing.T) {
+	tests := []struct {
+		name string
+		exts []ext
+		ja3s string
+		hash string
+	}{
+		{"no extensions", nil, "771,49199,", "174e7e4992a63f6d419626d97363adb8"},
+		{"tls13", []ext{{0xff01, []byte{0}}, {0, nil}, {11, vec(1, []byte{0})}, {35, nil}, {16, vec(2, vec(1, []byte("h2")))}, {extSupportedVersions, u16(0x0304)}},
+			"771,49199,65281-0-11-35-16-43", "d2dafa81080402228392d59158e0aada"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := u16(771)
+			m = append(m, make([]byte, 32)...)
+			m = append(m, 0)
+			m = append(m, u16(49199)...)
+			m = append(m, 0)
+			if tt.exts != nil {
+				list := []byte{}
+				for _, e := range tt.exts {
+					list = append(list, u16(e.id)...)
+					list = append(list, vec(2, e.data)...)
+				}
+				m = append(m, vec(2, list)...)
+			}
+			h, err := parseServerHello(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := h.ja3s(); got != tt.ja3s {
+				t.Fatalf("expected %s, got %s", tt.ja3s, got)
+			}
+			if tt.hash != "" && md5Hex(h.ja3s()) != tt.hash {
+				t.Errorf("unexpected JA3S hash %s", md5Hex(h.ja3s()))
+			}
+		})
+	}
+}
+
+// ja4Hello is the ClientHello of the JA4 reference example
+func ja4Hello(sni string) []byte {
+	ciphers := []uint16{0x8a8a, 0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035}
+	exts := []ext{{0x9a9a, nil}}
+	if sni != "" {
+		exts = append(exts, sniExt(sni))
+	}
+	exts = append(exts,
+		ext{0x0017, nil}, ext{0xff01, []byte{0}},
+		ext{extSupportedGroups, vec(2, u16(0x4a4a, 0x001d, 0x0017, 0x0018))},
+		ext{extECPointFormats, vec(1, []byte{0})}, ext{0x0023, nil}, alpnExt("h2", "http/1.1"),
+		ext{0x0005, []byte{1, 0, 0, 0, 0}},
+		ext{extSignatureAlgorithms, vec(2, u16(0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601))},
+		ext{0x0012, nil}, ext{0x0033, nil}, ext{0x002d, vec(1, []byte{1})},
+		ext{extSupportedVersions, vec(1, u16(0x3a3a, 0x0304, 0x0303))},
+		ext{0x001b, nil}, ext{0x4469, nil}, ext{0x0015, nil}, ext{0x0a0a, nil})
+	return buildHello(0x0303, ciphers, exts)
+}
+
+func TestJA4(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"reference", ja4Hello("www.example.com"), "t13d1516h2_8daaf6152771_e5627efa2ab1"},
+		{"no sni", ja4Hello(""), "t13i1515h2_8daaf6152771_e5627efa2ab1"},
+		{"tls12 no extensions", buildHello(0x0303, []uint16{0x002f}, nil), "t12i010000_" + sha256Prefix("002f", 1) + "_000000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseClientHello(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := h.ja4(); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// handshakeRecord wraps a handshake message in a TLS record
+func handshakeRecord(kind byte, msg []byte) []byte {
+	body := append([]byte{kind, 0, byte(len(msg) >> 8), byte(len(msg))}, msg...)
+	return append([]byte{recordHandshake, 3, 1}, vec(2, body)...)
+}
+
+func tcpPacket(t *testing.T, sport, dport int, seq uint32, syn bool, payload []byte) gopacket.Packet {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP,
+		SrcIP: net.IP{192, 168, 10, 5}, DstIP: net.IP{10, 0, 0, 20}}
+	tcp := &layers.TCP{SrcPort: layers.TCPPort(sport), DstPort: layers.TCPPort(dport), Seq: seq, SYN: syn, ACK: !syn, Window: 1000}
+	tcp.SetNetworkLayerForChecksum(ip)
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ip, tcp, gopacket.Payload(payload)); err != nil {
+		t.Fatal(err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+}
+
+func TestReusedTuple(t *testing.T) {
+	first := handshakeRecord(handshakeClientHello, ja4Hello("first.example.com"))
+	second := handshakeRecord(handshakeClientHello, ja4Hello("second.example.com"))
+	ts := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		packets []gopacket.Packet
+		want    []string
+	}{
+		{"retransmission", []gopacket.Packet{
+			tcpPacket(t, 40000, 443, 1000, false, first),
+			tcpPacket(t, 40000, 443, 1000, false, first),
+		}, []string{"first.example.com"}},
+		{"new syn", []gopacket.Packet{
+			tcpPacket(t, 40000, 443, 1000, false, first),
+			tcpPacket(t, 40000, 443, 1500, true, nil),
+			tcpPacket(t, 40000, 443, 1501, false, second),
+		}, []string{"first.example.com", "second.example.com"}},
+		{"syn not captured", []gopacket.Packet{
+			tcpPacket(t, 40000, 443, 1000, false, first),
+			tcpPacket(t, 40000, 443, 0x80000000, false, second),
+		}, []string{"first.example.com", "second.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector()
+			for i, p := range tt.packets {
+				c.Add(p, ts.Add(time.Duration(i)*time.Second))
+			}
+			sessions := c.Sessions()
+			if len(sessions) != len(tt.want) {
+				t.Fatalf("expected %d sessions, got %d", len(tt.want), len(sessions))
+			}
+			for i, s := range sessions {
+				if s.SNI != tt.want[i] {
+					t.Errorf("session %d: expected %s, got %s", i, tt.want[i], s.SNI)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package topology
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "strconv"
+    "strings"
+)
+
+const (
+    FormatDOT       = "dot"
+    FormatGraphML   = "graphml"
+    FormatHTML      = "html"
+)
+
+var nodeColors = map[string]string{
+    NodeHost        : "#9ecae1",
+    NodeGateway     : "#fdae6b",
+    NodeSubnet      : "#a1d99b",
+}
+
+var nodeShapes = map[string]string{
+    NodeHost        : "box",
+    NodeGateway     : "diamond",
+    NodeSubnet      : "ellipse",
+}
+
+// WriteFormat writes the graph as DOT, GraphML or HTML
+func (g *Graph) WriteFormat(w io.Writer, format string) error {
+    switch format {
+    case FormatDOT:
+        return WriteDOT(w, g)
+    case FormatGraphML:
+        return WriteGraphML(w, g)
+    case FormatHTML:
+        return WriteHTML(w, g)
+    }
+    return errors.New(fmt.Sprintf("unsupported graph format (%s)", format))
+}
+
+// dotQuote returns the string as a DOT quoted string
+func dotQuote(s string) string {
+    s = strings.ReplaceAll(s, "\\", "\\\\")
+    s = strings.ReplaceAll(s, "\"", "\\\"")
+    s = strings.ReplaceAll(s, "\n", "\\n")
+    return "\"" + s + "\""
+}
+
+// penWidth scales the conversation edges by packets (1 to 5)
+func penWidth(packets int64) float64 {
+    if packets <= 1 {
+        return 1
+    }
+    return math.Min(5, 1 + math.Log10(float64(packets)))
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language
+func WriteDOT(w io.Writer, g *Graph) error {
+    b := &strings.Builder{}
+    b.WriteString("graph topology {\n")
+    b.WriteString("    graph [overlap=false, splines=true];\n")
+    b.WriteString("    node [fontname=\"Helvetica\", fontsize=10, style=filled];\n")
+    b.WriteString("    edge [fontname=\"Helvetica\", fontsize=8];\n\n")
+
+    for _, n := range g.Nodes {
+        fmt.Fprintf(b, "    %s [label=%s, shape=%s, fillcolor=%s, tooltip=%s];\n",
+            dotQuote(n.ID), dotQuote(n.Label), nodeShapes[n.Type], dotQuote(nodeColors[n.Type]), dotQuote(nodeTitle(n)))
+    }
+    b.WriteString("\n")
+
+    for _, e := range g.Edges {
+        switch e.Type {
+        case EdgeMember:
+            fmt.Fprintf(b, "    %s -- %s [style=dashed, color=\"#999999\"];\n", dotQuote(e.Source), dotQuote(e.Target))
+        case EdgeRoutes:
+            fmt.Fprintf(b, "    %s -- %s [style=bold, color=\"#e6550d\"];\n", dotQuote(e.Source), dotQuote(e.Target))
+        default:
+            fmt.Fprintf(b, "    %s -- %s [label=%s, penwidth=%.1f, color=\"#3182bd\"];\n",
+                dotQuote(e.Source), dotQuote(e.Target), dotQuote(fmt.Sprintf("%d pkts", e.Packets)), penWidth(e.Packets))
+        }
+    }
+    b.WriteString("}\n")
+
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+type graphML struct {
+    XMLName         xml.Name            `xml:"graphml"`
+    Xmlns           string              `xml:"xmlns,attr"`
+    Keys            []graphMLKey        `xml:"key"`
+    Graph           graphMLGraph        `xml:"graph"`
+}
+
+type graphMLKey struct {
+    ID              string              `xml:"id,attr"`
+    For             string              `xml:"for,attr"`
+    Name            string              `xml:"attr.name,attr"`
+    Type            string              `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+    ID              string              `xml:"id,attr"`
+    EdgeDefault     string              `xml:"edgedefault,attr"`
+    Nodes           []graphMLNode       `xml:"node"`
+    Edges           []graphMLEdge       `xml:"edge"`
+}
+
+type graphMLNode struct {
+    ID              string              `xml:"id,attr"`
+    Data            []graphMLData       `xml:"data"`
+}
+
+type graphMLEdge struct {
+    ID              string              `xml:"id,attr"`
+    Source          string              `xml:"source,attr"`
+    Target          string              `xml:"target,attr"`
+    Data            []graphMLData       `xml:"data"`
+}
+
+type graphMLData struct {
+    Key             string              `xml:"key,attr"`
+    Value           string              `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML, lists are joined by commas
+func WriteGraphML(w io.Writer, g *Graph) error {
+    doc := graphML{
+        Xmlns       : "http://graphml.graphdrawing.org/xmlns",
+        Keys        : []graphMLKey{
+            { "type", "node", "type", "string" },
+            { "label", "node", "label", "string" },
+            { "ips", "node", "ips", "string" },
+            { "macs", "node", "macs", "string" },
+            { "vendor", "node", "vendor", "string" },
+            { "names", "node", "names", "string" },
+            { "roles", "node", "roles", "string" },
+            { "vlan", "node", "vlan", "string" },
+            { "hosts", "node", "hosts", "int" },
+            { "packets", "node", "packets", "long" },
+            { "os", "node", "os", "string" },
+            { "etype", "edge", "type", "string" },
+            { "epackets", "edge", "packets", "long" },
+            { "ebytes", "edge", "bytes", "long" },
+        },
+        Graph       : graphMLGraph{ ID: "topology", EdgeDefault: "undirected" },
+    }
+
+    for _, n := range g.Nodes {
+        data := []graphMLData{
+            { "type", n.Type },
+            { "label", strings.ReplaceAll(n.Label, "\n", " ") },
+        }
+        add := func(key string, value string) {
+            if value != "" {
+                data = append(data, graphMLData{ key, value })
+            }
+        }
+        add("ips", strings.Join(n.IPs, ","))
+        add("macs", strings.Join(n.MACs, ","))
+        add("vendor", n.Vendor)
+        add("names", strings.Join(n.Names, ","))
+        add("roles", strings.Join(n.Roles, ","))
+        add("vlan", n.VLAN)
+        add("os", n.OS)
+        if n.Type == NodeSubnet {
+            add("hosts", strconv.Itoa(n.Hosts))
+        } else {
+            add("packets", strconv.FormatInt(n.Packets, 10))
+        }
+        doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ ID: n.ID, Data: data })
+    }
+
+    for i, e := range g.Edges {
+        data := []graphMLData{ { "etype", e.Type } }
+        if e.Type == EdgeConversation {
+            data = append(data, graphMLData{ "epackets", strconv.FormatInt(e.Packets, 10) })
+            data = append(data, graphMLData{ "ebytes", strconv.FormatInt(e.Bytes, 10) })
+        }
+        doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+            ID          : fmt.Sprintf("e%d", i),
+            Source      : e.Source,
+            Target      : e.Target,
+            Data        : data,
+        })
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+    enc := xml.NewEncoder(w)
+    enc.Indent("", "  ")
+    if err := enc.Encode(doc); err != nil {
+        return err
+    }
+    _, err := io.WriteString(w, "\n")
+    return err
+}
+
+// WriteHTML writes a self-contained HTML page (no external resources)
+// drawing the graph with a force-directed layout
+func WriteHTML(w io.Writer, g *Graph) error {
+    type htmlNode struct {
+        Node
+        Title   string      `json:"title"`
+        Color   string      `json:"color"`
+    }
+    nodes := []htmlNode{}
+    for _, n := range g.Nodes {
+        nodes = append(nodes, htmlNode{ Node: n, Title: nodeTitle(n), Color: nodeColors[n.Type] })
+    }
+
+    // json.Marshal escapes <, > and &, so the data is safe inside <script>
+    data, err := json.Marshal(struct {
+        Nodes   []htmlNode  `json:"nodes"`
+        Edges   []Edge      `json:"edges"`
+    }{ nodes, g.Edges })
+    if err != nil {
+        return err
+    }
+
+    page := strings.Replace(htmlTemplate, "{{DATA}}", string(data), 1)
+    page = strings.Replace(page, "{{SUMMARY}}", fmt.Sprintf("%d hosts, %d gateways, %d subnets, %d edges",
+        g.Count(NodeHost), g.Count(NodeGateway), g.Count(NodeSubnet), len(g.Edges)), 1)
+    _, err = io.WriteString(w, page)
+    return err
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>pcapraptor topology</title>
+<style>
+  html, body { margin: 0; height: 100%; font-family: Helvetica, Arial, sans-serif; font-size: 12px; background: #fafafa; }
+  #bar { position: absolute; top: 0; left: 0; right: 0; padding: 6px 10px; background: #333; color: #eee; }
+  #bar label { margin-left: 12px; }
+  #info { position: absolute; top: 36px; right: 10px; max-width: 340px; padding: 8px; background: #fff; border: 1px solid #ccc; white-space: pre-wrap; display: none; }
+  svg { position: absolute; top: 30px; left: 0; width: 100%; height: calc(100% - 30px); cursor: move; }
+  .node text { font-size: 10px; pointer-events: none; }
+  .member { stroke: #999; stroke-dasharray: 4 3; }
+  .routes { stroke: #e6550d; stroke-width: 2.5; }
+  .conversation { stroke: #3182bd; stroke-opacity: 0.6; }
+</style>
+</head>
+<body>
+<div id="bar">pcapraptor topology &mdash; {{SUMMARY}}
+  <label><input type="checkbox" id="showConv" checked> conversations</label>
+  <label><input type="checkbox" id="showMember" checked> subnet members</label>
+  <label><input type="text" id="search" placeholder="search"></label>
+</div>
+<div id="info"></div>
+<svg id="graph"><g id="view"><g id="edges"></g><g id="nodes"></g></g></svg>
+<script>
+(function() {
+  var data = {{DATA}};
+  var NS = "http://www.w3.org/2000/svg";
+  var svg = document.getElementById("graph");
+  var view = document.getElementById("view");
+  var gEdges = document.getElementById("edges");
+  var gNodes = document.getElementById("nodes");
+  var info = document.getElementById("info");
+  var W = svg.clientWidth || 1200, H = svg.clientHeight || 800;
+  var byId = {};
+
+  data.nodes.forEach(function(n, i) {
+    var a = 2 * Math.PI * i / Math.max(1, data.nodes.length);
+    n.x = W / 2 + Math.cos(a) * W / 3 + Math.random() * 10;
+    n.y = H / 2 + Math.sin(a) * H / 3 + Math.random() * 10;
+    n.vx = 0; n.vy = 0;
+    byId[n.id] = n;
+  });
+  data.edges = data.edges.filter(function(e) { return byId[e.source] && byId[e.target]; });
+
+  data.edges.forEach(function(e) {
+    e.el = document.createElementNS(NS, "line");
+    e.el.setAttribute("class", e.type);
+    if (e.type === "conversation") {
+      e.el.setAttribute("stroke-width", Math.min(5, 1 + Math.log10(Math.max(1, e.packets))));
+      var t = document.createElementNS(NS, "title");
+      t.textContent = byId[e.source].label.replace("\n", " ") + " <-> " + byId[e.target].label.replace("\n", " ") +
+        "\n" + e.packets + " packets, " + e.bytes + " bytes";
+      e.el.appendChild(t);
+    }
+    gEdges.appendChild(e.el);
+  });
+
+  data.nodes.forEach(function(n) {
+    var g = document.createElementNS(NS, "g");
+    g.setAttribute("class", "node");
+    var shape;
+    if (n.type === "subnet") {
+      shape = document.createElementNS(NS, "ellipse");
+      shape.setAttribute("rx", 34); shape.setAttribute("ry", 16);
+    } else if (n.type === "gateway") {
+      shape = document.createElementNS(NS, "polygon");
+      shape.setAttribute("points", "0,-16 22,0 0,16 -22,0");
+    } else {
+      shape = document.createElementNS(NS, "rect");
+      shape.setAttribute("x", -12); shape.setAttribute("y", -9);
+      shape.setAttribute("width", 24); shape.setAttribute("height", 18);
+    }
+    shape.setAttribute("fill", n.color);
+    shape.setAttribute("stroke", "#555");
+    g.appendChild(shape);
+    n.label.split("\n").forEach(function(line, i) {
+      var t = document.createElementNS(NS, "text");
+      t.setAttribute("y", 28 + i * 11);
+      t.setAttribute("text-anchor", "middle");
+      t.textContent = line;
+      g.appendChild(t);
+    });
+    var title = document.createElementNS(NS, "title");
+    title.textContent = n.title;
+    g.appendChild(title);
+    g.addEventListener("mousedown", function(ev) { ev.stopPropagation(); drag = n; });
+    g.addEventListener("click", function() { info.style.display = "block"; info.textContent = n.title; });
+    n.el = g;
+    gNodes.appendChild(g);
+  });
+
+  // force-directed layout
+  var alpha = 1;
+  function step() {
+    var nodes = data.nodes, i, j;
+    for (i = 0; i < nodes.length; i++) {
+      for (j = i + 1; j < nodes.length; j++) {
+        var a = nodes[i], b = nodes[j];
+        var dx = b.x - a.x, dy = b.y - a.y, d2 = dx * dx + dy * dy + 0.01;
+        if (d2 > 250000) continue;
+        var f = 900 / d2;
+        a.vx -= dx * f; a.vy -= dy * f; b.vx += dx * f; b.vy += dy * f;
+      }
+    }
+    data.edges.forEach(function(e) {
+      var a = byId[e.source], b = byId[e.target];
+      var dx = b.x - a.x, dy = b.y - a.y, d = Math.sqrt(dx * dx + dy * dy) + 0.01;
+      var len = e.type === "conversation" ? 160 : 90;
+      var f = (d - len) * 0.02;
+      a.vx += dx / d * f; a.vy += dy / d * f; b.vx -= dx / d * f; b.vy -= dy / d * f;
+    });
+    nodes.forEach(function(n) {
+      n.vx += (W / 2 - n.x) * 0.002; n.vy += (H / 2 - n.y) * 0.002;
+      if (n !== drag) { n.x += n.vx * alpha; n.y += n.vy * alpha; }
+      n.vx *= 0.6; n.vy *= 0.6;
+    });
+    alpha = Math.max(0.02, alpha * 0.995);
+  }
+
+  function draw() {
+    data.edges.forEach(function(e) {
+      var a = byId[e.source], b = byId[e.target];
+      e.el.setAttribute("x1", a.x); e.el.setAttribute("y1", a.y);
+      e.el.setAttribute("x2", b.x); e.el.setAttribute("y2", b.y);
+    });
+    data.nodes.forEach(function(n) {
+      n.el.setAttribute("transform", "translate(" + n.x + "," + n.y + ")");
+    });
+  }
+
+  function tick() {
+    if (alpha > 0.02 || drag) { step(); draw(); }
+    requestAnimationFrame(tick);
+  }
+
+  // pan, zoom and drag
+  var drag = null, pan = null, tx = 0, ty = 0, scale = 1;
+  function apply() { view.setAttribute("transform", "translate(" + tx + "," + ty + ") scale(" + scale + ")"); }
+  svg.addEventListener("mousedown", function(ev) { pan = { x: ev.clientX - tx, y: ev.clientY - ty }; });
+  window.addEventListener("mousemove", function(ev) {
+    if (drag) {
+      var r = svg.getBoundingClientRect();
+      drag.x = (ev.clientX - r.left - tx) / scale; drag.y = (ev.clientY - r.top - ty) / scale;
+      alpha = Math.max(alpha, 0.3);
+    } else if (pan) {
+      tx = ev.clientX - pan.x; ty = ev.clientY - pan.y; apply();
+    }
+  });
+  window.addEventListener("mouseup", function() { drag = null; pan = null; });
+  svg.addEventListener("wheel", function(ev) {
+    ev.preventDefault();
+    var k = ev.deltaY < 0 ? 1.1 : 1 / 1.1;
+    var r = svg.getBoundingClientRect(), mx = ev.clientX - r.left, my = ev.clientY - r.top;
+    tx = mx - (mx - tx) * k; ty = my - (my - ty) * k; scale *= k; apply();
+  }, { passive: false });
+
+  function toggle(id, type) {
+    document.getElementById(id).addEventListener("change", function(ev) {
+      data.edges.forEach(function(e) { if (e.type === type) e.el.style.display = ev.target.checked ? "" : "none"; });
+    });
+  }
+  toggle("showConv", "conversation");
+  toggle("showMember", "member");
+
+  document.getElementById("search").addEventListener("input", function(ev) {
+    var q = ev.target.value.toLowerCase();
+    data.nodes.forEach(function(n) {
+      n.el.style.opacity = (!q || n.title.toLowerCase().indexOf(q) >= 0) ? 1 : 0.15;
+    });
+  });
+
+  for (var i = 0; i < 200; i++) step();
+  draw();
+  tick();
+})();
+</script>
+</body>
+</html>
+`
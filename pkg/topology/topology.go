@@ -0,0 +1,426 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package topology
+
+import (
+    "fmt"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/helviojunior/pcapraptor/pkg/inventory"
+    "github.com/helviojunior/pcapraptor/pkg/netcalc"
+    "github.com/google/gopacket"
+    "github.com/google/gopacket/layers"
+)
+
+const (
+    NodeHost            = "host"
+    NodeGateway         = "gateway"
+    NodeSubnet          = "subnet"
+
+    // host attached to a subnet
+    EdgeMember          = "member"
+    // gateway routing a subnet
+    EdgeRoutes          = "routes"
+    // traffic between two hosts
+    EdgeConversation    = "conversation"
+)
+
+// Node is a host, gateway or subnet of the graph
+type Node struct {
+    ID              string              `json:"id"`
+    Type            string              `json:"type"`
+    Label           string              `json:"label"`
+    IPs             []string            `json:"ips,omitempty"`
+    MACs            []string            `json:"macs,omitempty"`
+    Vendor          string              `json:"vendor,omitempty"`
+    Names           []string            `json:"names,omitempty"`
+    Roles           []string            `json:"roles,omitempty"`
+    VLAN            string              `json:"vlan,omitempty"`
+    // observed addresses inside a subnet
+    Hosts           int                 `json:"hosts,omitempty"`
+    Packets         int64               `json:"packets"`
+    OS              string              `json:"os,omitempty"`
+}
+
+// Edge links two nodes, packets and bytes are set for conversations
+type Edge struct {
+    Source          string              `json:"source"`
+    Target          string              `json:"target"`
+    Type            string              `json:"type"`
+    Packets         int64               `json:"packets"`
+    Bytes           int64               `json:"bytes"`
+}
+
+// Graph is the network topology rebuilt from the capture
+type Graph struct {
+    Nodes           []Node              `json:"nodes"`
+    Edges           []Edge              `json:"edges"`
+}
+
+// Count returns the number of nodes of the given type
+func (g *Graph) Count(nodeType string) int {
+    n := 0
+    for _, node := range g.Nodes {
+        if node.Type == nodeType {
+            n++
+        }
+    }
+    return n
+}
+
+// Conversation is the traffic between two addresses, both directions
+type Conversation struct {
+    A               string
+    B               string
+    Packets         int64
+    Bytes           int64
+}
+
+// Conversations counts the traffic between unicast address pairs
+type Conversations struct {
+    pairs           map[string]*Conversation
+}
+
+func NewConversations() *Conversations {
+    return &Conversations{
+        pairs       : map[string]*Conversation{},
+    }
+}
+
+// Add counts the packet, length is the original packet length
+func (c *Conversations) Add(packet gopacket.Packet, length int) {
+    var src, dst net.IP
+    if ipLayer := packet.Layer(layers.LayerTypeIPv4); ipLayer != nil {
+        ipv4 := ipLayer.(*layers.IPv4)
+        src, dst = ipv4.SrcIP, ipv4.DstIP
+    } else if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+        ipv6 := ipLayer.(*layers.IPv6)
+        src, dst = ipv6.SrcIP, ipv6.DstIP
+    }
+    if !isUnicast(src) || !isUnicast(dst) || src.Equal(dst) {
+        return
+    }
+
+    a, b := src.String(), dst.String()
+    if b < a {
+        a, b = b, a
+    }
+    key := a + "|" + b
+    conv, ok := c.pairs[key]
+    if !ok {
+        conv = &Conversation{ A: a, B: b }
+        c.pairs[key] = conv
+    }
+    conv.Packets++
+    conv.Bytes += int64(length)
+}
+
+// Top returns up to max conversations (0 means all), most packets first
+func (c *Conversations) Top(max int) []Conversation {
+    list := []Conversation{}
+    for _, conv := range c.pairs {
+        list = append(list, *conv)
+    }
+    sort.Slice(list, func(i, j int) bool {
+        if list[i].Packets != list[j].Packets {
+            return list[i].Packets > list[j].Packets
+        }
+        if list[i].A != list[j].A {
+            return list[i].A < list[j].A
+        }
+        return list[i].B < list[j].B
+    })
+    if max > 0 && len(list) > max {
+        list = list[:max]
+    }
+    return list
+}
+
+func isUnicast(ip net.IP) bool {
+    if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+        return false
+    }
+    return true
+}
+
+// Builder assembles the graph: add the hosts first, then the subnets,
+// gateways and conversations
+type Builder struct {
+    graph           *Graph
+    byIP            map[string]int
+    byMAC           map[string]int
+    subnets         []builderSubnet
+    edges           map[string]bool
+}
+
+type builderSubnet struct {
+    node            int
+    network         *net.IPNet
+    vlan            string
+}
+
+func NewBuilder() *Builder {
+    return &Builder{
+        graph       : &Graph{ Nodes: []Node{}, Edges: []Edge{} },
+        byIP        : map[string]int{},
+        byMAC       : map[string]int{},
+        edges       : map[string]bool{},
+    }
+}
+
+// Graph returns the graph built
+func (b *Builder) Graph() *Graph {
+    return b.graph
+}
+
+func (b *Builder) addNode(n Node) int {
+    b.graph.Nodes = append(b.graph.Nodes, n)
+    return len(b.graph.Nodes) - 1
+}
+
+func (b *Builder) addEdge(e Edge) {
+    key := e.Source + "|" + e.Target + "|" + e.Type
+    if b.edges[key] {
+        return
+    }
+    b.edges[key] = true
+    b.graph.Edges = append(b.graph.Edges, e)
+}
+
+// AddHosts adds one node per inventory host
+func (b *Builder) AddHosts(hosts []inventory.Host) {
+    for _, h := range hosts {
+        if len(h.IPs) == 0 {
+            continue
+        }
+        n := Node{
+            ID          : fmt.Sprintf("h%d", len(b.graph.Nodes)),
+            Type        : NodeHost,
+            Label       : h.IPs[0],
+            IPs         : h.IPs,
+            MACs        : h.MACs,
+            Names       : []string{},
+            Roles       : h.Roles,
+            VLAN        : strings.Join(h.VLANList(), ","),
+            Packets     : h.Packets,
+            OS          : h.OS,
+        }
+        for _, name := range h.Hostnames {
+            n.Names = append(n.Names, name.Name)
+        }
+        if len(h.Vendors) > 0 {
+            n.Vendor = h.Vendors[0]
+        }
+        if len(n.Names) > 0 {
+            n.Label = fmt.Sprintf("%s\n%s", n.Names[0], h.IPs[0])
+        }
+        for _, r := range h.Roles {
+            if r == inventory.RoleGateway {
+                n.Type = NodeGateway
+            }
+        }
+        idx := b.addNode(n)
+        for _, ip := range h.IPs {
+            b.byIP[ip] = idx
+        }
+        for _, mac := range h.MACs {
+            b.byMAC[mac] = idx
+        }
+    }
+}
+
+// AddSubnets adds one node per subnet and links the hosts inside it, a
+// host address found in more than one subnet (overlapping VLANs) is
+// linked to the most specific one at the host VLANs
+func (b *Builder) AddSubnets(subnets []netcalc.InferredSubnet) {
+    for _, s := range subnets {
+        network := s.IPNet()
+        if network == nil {
+            continue
+        }
+        label := s.String()
+        if s.VLAN != "" {
+            label = fmt.Sprintf("%s\nVLAN %s", label, s.VLAN)
+        }
+        idx := b.addNode(Node{
+            ID          : fmt.Sprintf("s%d", len(b.graph.Nodes)),
+            Type        : NodeSubnet,
+            Label       : label,
+            VLAN        : s.VLAN,
+            Hosts       : s.Hosts,
+        })
+        b.subnets = append(b.subnets, builderSubnet{ node: idx, network: network, vlan: s.VLAN })
+    }
+
+    for i := range b.graph.Nodes {
+        n := &b.graph.Nodes[i]
+        if n.Type == NodeSubnet {
+            continue
+        }
+        for _, a := range n.IPs {
+            if s := b.subnetOf(net.ParseIP(a), n.VLAN); s != nil {
+                b.addEdge(Edge{ Source: n.ID, Target: b.graph.Nodes[s.node].ID, Type: EdgeMember })
+            }
+        }
+    }
+}
+
+func (b *Builder) subnetOf(ip net.IP, hostVLANs string) *builderSubnet {
+    if ip == nil {
+        return nil
+    }
+    var best *builderSubnet
+    bestOnes := -1
+    bestVLAN := false
+    for i := range b.subnets {
+        s := &b.subnets[i]
+        if !s.network.Contains(ip) {
+            continue
+        }
+        ones, _ := s.network.Mask.Size()
+        vlan := matchVLAN(s.vlan, hostVLANs)
+        if best == nil || (vlan && !bestVLAN) || (vlan == bestVLAN && ones > bestOnes) {
+            best, bestOnes, bestVLAN = s, ones, vlan
+        }
+    }
+    return best
+}
+
+// matchVLAN tells if the subnet VLAN (e.g. "100.20") is one of the host
+// VLANs ("100,20"), the inner tag is the one of the host
+func matchVLAN(vlan string, hostVLANs string) bool {
+    if vlan == "" {
+        return hostVLANs == ""
+    }
+    tags := strings.Split(vlan, ".")
+    inner := tags[len(tags) - 1]
+    for _, v := range strings.Split(hostVLANs, ",") {
+        if v == inner {
+            return true
+        }
+    }
+    return false
+}
+
+// AddGateways marks the gateway hosts (a node is added for gateways not
+// in the inventory) and links them to the subnets they route
+func (b *Builder) AddGateways(vlan string, gateways []netcalc.Gateway) {
+    for _, g := range gateways {
+        idx := -1
+        if i, ok := b.byMAC[g.MAC]; ok && g.MAC != "" {
+            idx = i
+        } else {
+            for _, a := range g.IPs {
+                if i, ok := b.byIP[a]; ok {
+                    idx = i
+                    break
+                }
+            }
+        }
+        if idx < 0 {
+            label := g.MAC
+            if len(g.IPs) > 0 {
+                label = g.IPs[0]
+            }
+            macs := []string{}
+            if g.MAC != "" {
+                macs = append(macs, g.MAC)
+            }
+            idx = b.addNode(Node{
+                ID          : fmt.Sprintf("g%d", len(b.graph.Nodes)),
+                Type        : NodeGateway,
+                Label       : label,
+                IPs         : g.IPs,
+                MACs        : macs,
+                Vendor      : g.Vendor,
+                Roles       : []string{ inventory.RoleGateway },
+                VLAN        : vlan,
+                Packets     : int64(g.RoutedFrames),
+            })
+            for _, a := range g.IPs {
+                b.byIP[a] = idx
+            }
+            if g.MAC != "" {
+                b.byMAC[g.MAC] = idx
+            }
+        }
+
+        n := &b.graph.Nodes[idx]
+        n.Type = NodeGateway
+        for _, vip := range g.VirtualIPs {
+            b.byIP[vip] = idx
+        }
+
+        for _, cidr := range g.Subnets {
+            for _, s := range b.subnets {
+                if s.network.String() == cidr && s.vlan == vlan {
+                    b.addEdge(Edge{ Source: n.ID, Target: b.graph.Nodes[s.node].ID, Type: EdgeRoutes })
+                }
+            }
+        }
+    }
+}
+
+// AddConversations adds an edge per conversation between known nodes
+func (b *Builder) AddConversations(list []Conversation) {
+    for _, c := range list {
+        ia, okA := b.byIP[c.A]
+        ib, okB := b.byIP[c.B]
+        if !okA || !okB || ia == ib {
+            continue
+        }
+        b.graph.Edges = append(b.graph.Edges, Edge{
+            Source      : b.graph.Nodes[ia].ID,
+            Target      : b.graph.Nodes[ib].ID,
+            Type        : EdgeConversation,
+            Packets     : c.Packets,
+            Bytes       : c.Bytes,
+        })
+    }
+}
+
+// nodeTitle returns the node details, one per line
+func nodeTitle(n Node) string {
+    lines := []string{ strings.ReplaceAll(n.Label, "\n", " ") }
+    if len(n.IPs) > 0 {
+        lines = append(lines, "IPs: " + strings.Join(n.IPs, ", "))
+    }
+    if len(n.MACs) > 0 {
+        lines = append(lines, "MACs: " + strings.Join(n.MACs, ", "))
+    }
+    if n.Vendor != "" {
+        lines = append(lines, "Vendor: " + n.Vendor)
+    }
+    if n.OS != "" {
+        lines = append(lines, "OS: " + n.OS)
+    }
+    if len(n.Names) > 0 {
+        lines = append(lines, "Names: " + strings.Join(n.Names, ", "))
+    }
+    if len(n.Roles) > 0 {
+        lines = append(lines, "Roles: " + strings.Join(n.Roles, ", "))
+    }
+    if n.VLAN != "" {
+        lines = append(lines, "VLAN: " + n.VLAN)
+    }
+    if n.Type == NodeSubnet {
+        lines = append(lines, "Hosts: " + strconv.Itoa(n.Hosts))
+    } else {
+        lines = append(lines, "Packets: " + strconv.FormatInt(n.Packets, 10))
+    }
+    return strings.Join(lines, "\n")
+}
@@ -0,0 +1,29 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/helviojunior/pcapraptor/pkg/netcalc"
+)
+
+func TestAddGatewaysWithoutMAC(t *testing.T) {
+	b := NewBuilder()
+	b.AddGateways("", []netcalc.Gateway{
+		{IPs: []string{"10.0.0.1"}},
+		{IPs: []string{"10.0.1.1"}},
+		{MAC: "00:11:22:33:44:55", IPs: []string{"10.0.2.1"}},
+		{MAC: "00:11:22:33:44:55", IPs: []string{"10.0.2.2"}},
+	})
+
+	g := b.Graph()
+	if got := g.Count(NodeGateway); got != 3 {
+		t.Fatalf("expected 3 gateways, got %d", got)
+	}
+	for _, n := range g.Nodes {
+		for _, mac := range n.MACs {
+			if mac == "" {
+				t.Fatalf("node %s has an empty MAC address", n.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package wifi
+
+import (
+    "math"
+    "time"
+)
+
+// minimum samples and span of a clock fit
+const (
+    minClockSamples = 10
+    minClockSpan    = 10 * time.Second
+)
+
+// a TSF timer going back more than this (microseconds) has restarted
+const tsfResetGap = 1000000
+
+// Clock is the beacon TSF timer of an access point used as a monotonic
+// reference: the capture clock is fitted against it
+type Clock struct {
+    Samples         int         `json:"samples"`
+    // seconds of the capture covered by the samples
+    Span            float64     `json:"span"`
+    // capture clock drift (parts per million), positive when the capture
+    // clock runs faster than the TSF timer
+    Drift           float64     `json:"drift"`
+    // root mean square of the fit residuals (milliseconds)
+    Residual        float64     `json:"residual"`
+    // TSF timer restarts (access point reboots)
+    Resets          int         `json:"resets"`
+}
+
+// TSFSample is a beacon TSF timer (microseconds) and its capture time.
+// The timer is monotonic inside a segment only, Segment is increased at
+// each TSF reset
+type TSFSample struct {
+    Time            time.Time
+    TSF             uint64
+    Segment         int
+}
+
+// clockSamples holds the TSF samples, split where the timer restarts
+type clockSamples struct {
+    segments        [][]TSFSample
+}
+
+func (c *clockSamples) add(ts time.Time, tsf uint64) {
+    n := len(c.segments)
+    if n > 0 {
+        last := c.segments[n - 1][len(c.segments[n - 1]) - 1].TSF
+        if tsf < last && last - tsf <= tsfResetGap {
+            // out of order beacon
+            return
+        }
+    }
+    if n == 0 || tsf < c.segments[n - 1][len(c.segments[n - 1]) - 1].TSF {
+        c.segments = append(c.segments, []TSFSample{})
+        n++
+    }
+    c.segments[n - 1] = append(c.segments[n - 1], TSFSample{ Time: ts, TSF: tsf, Segment: n - 1 })
+}
+
+// fit returns the least squares fit of the capture time against the TSF
+// timer of the longest segment, nil without enough samples
+func (c *clockSamples) fit() *Clock {
+    var best []TSFSample
+    for _, s := range c.segments {
+        if len(s) > len(best) {
+            best = s
+        }
+    }
+    if len(best) < minClockSamples {
+        return nil
+    }
+    span := best[len(best) - 1].Time.Sub(best[0].Time)
+    if span < minClockSpan {
+        return nil
+    }
+
+    // x: TSF seconds, y: capture seconds, both from the first sample
+    n := float64(len(best))
+    var sx, sy, sxx, sxy float64
+    xs, ys := make([]float64, len(best)), make([]float64, len(best))
+    for i, s := range best {
+        xs[i] = float64(s.TSF - best[0].TSF) / 1e6
+        ys[i] = s.Time.Sub(best[0].Time).Seconds()
+        sx += xs[i]
+        sy += ys[i]
+        sxx += xs[i] * xs[i]
+        sxy += xs[i] * ys[i]
+    }
+    den := n * sxx - sx * sx
+    if den == 0 {
+        return nil
+    }
+    slope := (n * sxy - sx * sy) / den
+    intercept := (sy - slope * sx) / n
+
+    var sq float64
+    for i := range xs {
+        r := ys[i] - (intercept + slope * xs[i])
+        sq += r * r
+    }
+
+    return &Clock{
+        Samples     : len(best),
+        Span        : span.Seconds(),
+        Drift       : (slope - 1) * 1e6,
+        Residual    : math.Sqrt(sq / n) * 1e3,
+        Resets      : len(c.segments) - 1,
+    }
+}
+
+// TSFSamples returns the beacon TSF samples of each access point, segment
+// by segment
+func (c *Collector) TSFSamples() map[string][]TSFSample {
+    list := map[string][]TSFSample{}
+    for bssid, e := range c.aps {
+        for _, s := range e.clock.segments {
+            list[bssid] = append(list[bssid], s...)
+        }
+    }
+    return list
+}
@@ -0,0 +1,39 @@
+package wifi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSegments(t *testing.T) {
+	ts := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	tsf := []uint64{
+		50000000, 50102400, 50204800,
+		// out of order beacon, dropped
+		50102400,
+		// access point reboot
+		1000, 103400,
+	}
+	c := &clockSamples{}
+	for i, v := range tsf {
+		c.add(ts.Add(time.Duration(i)*100*time.Millisecond), v)
+	}
+	if len(c.segments) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(c.segments))
+	}
+	want := []int{0, 0, 0, 1, 1}
+	got := []int{}
+	for _, s := range c.segments {
+		for _, x := range s {
+			got = append(got, x.Segment)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d samples, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sample %d: expected segment %d, got %d", i, want[i], got[i])
+		}
+	}
+}
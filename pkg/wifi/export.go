@@ -0,0 +1,143 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package wifi
+
+import (
+    "encoding/csv"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// JSONLines writes one record per access point, client and probed SSID,
+// the "type" field tells them apart
+func (r *Report) JSONLines() []interface{} {
+    type kind struct {
+        Type    string  `json:"type"`
+    }
+    list := []interface{}{}
+    for _, ap := range r.AccessPoints {
+        list = append(list, struct {
+            kind
+            AccessPoint
+        }{ kind{ "access_point" }, ap })
+    }
+    for _, cl := range r.Clients {
+        list = append(list, struct {
+            kind
+            Client
+        }{ kind{ "client" }, cl })
+    }
+    for _, p := range r.Probes {
+        list = append(list, struct {
+            kind
+            Probe
+        }{ kind{ "probe" }, p })
+    }
+    return list
+}
+
+var reportCSVHeader = []string{
+    "type", "mac", "ssid", "hidden", "channel", "band", "encryption", "ciphers", "auth",
+    "vendor", "bssids", "clients", "probes", "frames", "signal", "first_seen", "last_seen",
+    "clock_drift", "clock_residual",
+}
+
+func formatTime(t time.Time) string {
+    if t.IsZero() {
+        return ""
+    }
+    return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatSignal(s int) string {
+    if s == 0 {
+        return ""
+    }
+    return strconv.Itoa(s)
+}
+
+// WriteCSV writes one row per access point, client and probed SSID,
+// multi-valued columns are separated by '; '
+func (r *Report) WriteCSV(w io.Writer) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(reportCSVHeader); err != nil {
+        return err
+    }
+
+    rows := [][]string{}
+    for _, ap := range r.AccessPoints {
+        drift, residual := "", ""
+        if ap.Clock != nil {
+            drift = strconv.FormatFloat(ap.Clock.Drift, 'f', 3, 64)
+            residual = strconv.FormatFloat(ap.Clock.Residual, 'f', 3, 64)
+        }
+        rows = append(rows, []string{
+            "access_point", ap.BSSID, ap.SSID, strconv.FormatBool(ap.Hidden), strconv.Itoa(ap.Channel),
+            ap.Band, ap.Encryption, strings.Join(ap.Ciphers, "; "), strings.Join(ap.Auth, "; "),
+            ap.Vendor, "", strings.Join(ap.Clients, "; "), "", strconv.FormatInt(ap.Beacons + ap.DataFrames, 10),
+            formatSignal(ap.Signal), formatTime(ap.FirstSeen), formatTime(ap.LastSeen), drift, residual,
+        })
+    }
+    for _, cl := range r.Clients {
+        rows = append(rows, []string{
+            "client", cl.MAC, "", "", "", "", "", "", "", cl.Vendor, strings.Join(cl.BSSIDs, "; "), "",
+            strings.Join(cl.Probes, "; "), strconv.FormatInt(cl.Frames, 10), formatSignal(cl.Signal),
+            formatTime(cl.FirstSeen), formatTime(cl.LastSeen), "", "",
+        })
+    }
+    for _, p := range r.Probes {
+        rows = append(rows, []string{
+            "probe", "", p.SSID, "", "", "", "", "", "", "", "", strings.Join(p.Clients, "; "), "",
+            strconv.FormatInt(p.Requests, 10), "", "", "", "", "",
+        })
+    }
+
+    for _, row := range rows {
+        if err := cw.Write(row); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+// WriteTSF writes the beacon TSF samples as CSV (bssid, capture time, TSF
+// microseconds and segment), to fit the capture clock drift. The TSF is
+// only monotonic inside a segment, a new one starts at each reset
+func WriteTSF(w io.Writer, samples map[string][]TSFSample) error {
+    cw := csv.NewWriter(w)
+    if err := cw.Write([]string{ "bssid", "time", "tsf", "segment" }); err != nil {
+        return err
+    }
+    bssids := []string{}
+    for b := range samples {
+        bssids = append(bssids, b)
+    }
+    sort.Strings(bssids)
+    for _, b := range bssids {
+        for _, s := range samples[b] {
+            row := []string{
+                b, s.Time.UTC().Format(time.RFC3339Nano), strconv.FormatUint(s.TSF, 10), strconv.Itoa(s.Segment),
+            }
+            if err := cw.Write(row); err != nil {
+                return err
+            }
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
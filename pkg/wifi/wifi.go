@@ -0,0 +1,356 @@
+/*
+ * PACP - PCAP manipulation tool in Golang
+ * Copyright (c) 2025 Helvio Junior <helvio_junior [at] hotmail [dot] com>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package wifi
+
+import (
+    "net"
+    "sort"
+    "time"
+
+    "github.com/helviojunior/pcapraptor/pkg/dot11"
+    "github.com/helviojunior/pcapraptor/pkg/inventory"
+    "github.com/google/gopacket/layers"
+)
+
+// AccessPoint is a BSS announced by beacons or probe responses, or seen
+// at the data frames
+type AccessPoint struct {
+    BSSID           string      `json:"bssid"`
+    SSID            string      `json:"ssid"`
+    // the beacons hide the SSID, it is set when revealed by probe
+    // responses or association requests
+    Hidden          bool        `json:"hidden"`
+    Channel         int         `json:"channel"`
+    Band            string      `json:"band"`
+    Encryption      string      `json:"encryption"`
+    Ciphers         []string    `json:"ciphers"`
+    Auth            []string    `json:"auth"`
+    Vendor          string      `json:"vendor"`
+    Clients         []string    `json:"clients"`
+    Beacons         int64       `json:"beacons"`
+    DataFrames      int64       `json:"data_frames"`
+    // best signal (dBm), zero when the capture has no Radiotap signal
+    Signal          int         `json:"signal"`
+    FirstSeen       time.Time   `json:"first_seen"`
+    LastSeen        time.Time   `json:"last_seen"`
+    Clock           *Clock      `json:"clock,omitempty"`
+}
+
+// Client is a station associated to or probing for access points
+type Client struct {
+    MAC             string      `json:"mac"`
+    Vendor          string      `json:"vendor"`
+    BSSIDs          []string    `json:"bssids"`
+    Probes          []string    `json:"probes"`
+    Frames          int64       `json:"frames"`
+    Signal          int         `json:"signal"`
+    FirstSeen       time.Time   `json:"first_seen"`
+    LastSeen        time.Time   `json:"last_seen"`
+}
+
+// Probe is a SSID looked for by probe requests
+type Probe struct {
+    SSID            string      `json:"ssid"`
+    Clients         []string    `json:"clients"`
+    Requests        int64       `json:"requests"`
+}
+
+// Report is the wireless inventory of the capture
+type Report struct {
+    AccessPoints    []AccessPoint   `json:"access_points"`
+    Clients         []Client        `json:"clients"`
+    Probes          []Probe         `json:"probes"`
+}
+
+type apEntry struct {
+    ap              AccessPoint
+    hasSignal       bool
+    clients         map[string]bool
+    clock           clockSamples
+}
+
+type clientEntry struct {
+    client          Client
+    hasSignal       bool
+    bssids          map[string]bool
+    probes          map[string]bool
+}
+
+type probeEntry struct {
+    clients         map[string]bool
+    requests        int64
+}
+
+// Collector builds the wireless inventory frame by frame
+type Collector struct {
+    aps             map[string]*apEntry
+    clients         map[string]*clientEntry
+    probes          map[string]*probeEntry
+    frames          int64
+}
+
+// NewCollector returns an empty collector
+func NewCollector() *Collector {
+    return &Collector{
+        aps         : map[string]*apEntry{},
+        clients     : map[string]*clientEntry{},
+        probes      : map[string]*probeEntry{},
+    }
+}
+
+// Frames returns the number of 802.11 frames decoded
+func (c *Collector) Frames() int64 {
+    return c.frames
+}
+
+func seen(first, last *time.Time, ts time.Time) {
+    if first.IsZero() || ts.Before(*first) {
+        *first = ts
+    }
+    if ts.After(*last) {
+        *last = ts
+    }
+}
+
+// isStation tells if the address can be a station (unicast)
+func isStation(mac net.HardwareAddr) bool {
+    return len(mac) == 6 && mac[0] & 0x01 == 0
+}
+
+func (c *Collector) ap(bssid net.HardwareAddr, ts time.Time) *apEntry {
+    k := bssid.String()
+    e, ok := c.aps[k]
+    if !ok {
+        e = &apEntry{
+            ap          : AccessPoint{ BSSID: k, Vendor: inventory.Vendor(bssid), Ciphers: []string{}, Auth: []string{} },
+            clients     : map[string]bool{},
+        }
+        c.aps[k] = e
+    }
+    seen(&e.ap.FirstSeen, &e.ap.LastSeen, ts)
+    return e
+}
+
+func (c *Collector) client(mac net.HardwareAddr, ts time.Time) *clientEntry {
+    k := mac.String()
+    e, ok := c.clients[k]
+    if !ok {
+        e = &clientEntry{
+            client      : Client{ MAC: k, Vendor: inventory.Vendor(mac) },
+            bssids      : map[string]bool{},
+            probes      : map[string]bool{},
+        }
+        c.clients[k] = e
+    }
+    seen(&e.client.FirstSeen, &e.client.LastSeen, ts)
+    return e
+}
+
+// associate records a station of an access point
+func (c *Collector) associate(bssid, station net.HardwareAddr, ts time.Time) {
+    if !isStation(bssid) || !isStation(station) || bssid.String() == station.String() {
+        return
+    }
+    c.ap(bssid, ts).clients[station.String()] = true
+    c.client(station, ts).bssids[bssid.String()] = true
+}
+
+func signal(f *dot11.Frame, best *int, has *bool) {
+    if f.HasSignal && (!*has || f.Signal > *best) {
+        *best, *has = f.Signal, true
+    }
+}
+
+// Add collects one frame of a Radiotap or 802.11 capture
+func (c *Collector) Add(data []byte, linkType layers.LinkType, ts time.Time) {
+    f, err := dot11.Parse(data, linkType)
+    if err != nil || f.Type == dot11.TypeControl {
+        return
+    }
+    c.frames++
+
+    if f.Type == dot11.TypeData {
+        c.addData(f, ts)
+        return
+    }
+
+    switch f.Subtype {
+    case dot11.SubtypeBeacon, dot11.SubtypeProbeResponse:
+        c.addBeacon(f, ts)
+    case dot11.SubtypeProbeRequest:
+        if !isStation(f.Addr2) {
+            return
+        }
+        e := c.client(f.Addr2, ts)
+        e.client.Frames++
+        signal(f, &e.client.Signal, &e.hasSignal)
+        ssid, hidden, ok := f.Elements().SSID()
+        if !ok || hidden {
+            return
+        }
+        e.probes[ssid] = true
+        p, ok := c.probes[ssid]
+        if !ok {
+            p = &probeEntry{ clients: map[string]bool{} }
+            c.probes[ssid] = p
+        }
+        p.clients[f.Addr2.String()] = true
+        p.requests++
+    case dot11.SubtypeAssocRequest, dot11.SubtypeReassocRequest:
+        c.associate(f.Addr1, f.Addr2, ts)
+        if ssid, hidden, ok := f.Elements().SSID(); ok && !hidden {
+            if e, ok := c.aps[f.Addr1.String()]; ok && e.ap.SSID == "" {
+                e.ap.SSID = ssid
+            }
+        }
+    case dot11.SubtypeAssocResponse, dot11.SubtypeReassocResponse:
+        c.associate(f.Addr2, f.Addr1, ts)
+    case dot11.SubtypeAuth:
+        if f.Addr2.String() == f.Addr3.String() {
+            c.associate(f.Addr2, f.Addr1, ts)
+        } else {
+            c.associate(f.Addr1, f.Addr2, ts)
+        }
+    }
+}
+
+func (c *Collector) addBeacon(f *dot11.Frame, ts time.Time) {
+    if !isStation(f.Addr3) {
+        return
+    }
+    e := c.ap(f.Addr3, ts)
+    elements := f.Elements()
+
+    ssid, hidden, ok := elements.SSID()
+    if ok {
+        if hidden {
+            if f.Subtype == dot11.SubtypeBeacon {
+                e.ap.Hidden = true
+            }
+        } else {
+            e.ap.SSID = ssid
+        }
+    }
+    if ch := elements.Channel(); ch != 0 {
+        e.ap.Channel = ch
+    } else if e.ap.Channel == 0 {
+        e.ap.Channel = dot11.Channel(f.Frequency)
+    }
+    if band := dot11.Band(f.Frequency); band != "" {
+        e.ap.Band = band
+    } else if e.ap.Band == "" && e.ap.Channel > 0 && e.ap.Channel <= 14 {
+        e.ap.Band = "2.4GHz"
+    }
+    // beacons are authoritative, probe responses fill the gaps
+    if capability, ok := f.Capability(); ok && (f.Subtype == dot11.SubtypeBeacon || e.ap.Encryption == "") {
+        sec := elements.Security(capability)
+        e.ap.Encryption, e.ap.Ciphers, e.ap.Auth = sec.Encryption, sec.Ciphers, sec.Auth
+    }
+
+    if f.Subtype == dot11.SubtypeBeacon {
+        e.ap.Beacons++
+        signal(f, &e.ap.Signal, &e.hasSignal)
+        if tsf, ok := f.Timestamp(); ok {
+            e.clock.add(ts, tsf)
+        }
+    } else if isStation(f.Addr1) {
+        // probe response to a station
+        c.client(f.Addr1, ts)
+    }
+}
+
+func (c *Collector) addData(f *dot11.Frame, ts time.Time) {
+    bssid := f.BSSID()
+    if !isStation(bssid) {
+        return
+    }
+    e := c.ap(bssid, ts)
+    e.ap.DataFrames++
+
+    var station net.HardwareAddr
+    switch {
+    case f.Flags & dot11.FlagToDS != 0:
+        station = f.Addr2
+    case f.Flags & dot11.FlagFromDS != 0:
+        station = f.Addr1
+        signal(f, &e.ap.Signal, &e.hasSignal)
+    default:
+        // ad hoc network
+        station = f.Addr2
+    }
+    if !isStation(station) || station.String() == bssid.String() {
+        return
+    }
+    c.associate(bssid, station, ts)
+    s := c.clients[station.String()]
+    s.client.Frames++
+    if f.Flags & dot11.FlagToDS != 0 {
+        signal(f, &s.client.Signal, &s.hasSignal)
+    }
+}
+
+func sortedKeys(set map[string]bool) []string {
+    list := []string{}
+    for k := range set {
+        list = append(list, k)
+    }
+    sort.Strings(list)
+    return list
+}
+
+// Report returns the access points, clients and probed networks found,
+// timestamps are corrected by shift (if set)
+func (c *Collector) Report(shift *time.Duration) *Report {
+    rpt := &Report{ AccessPoints: []AccessPoint{}, Clients: []Client{}, Probes: []Probe{} }
+    adjust := func(first, last *time.Time) {
+        if shift != nil {
+            *first, *last = first.Add(*shift), last.Add(*shift)
+        }
+    }
+
+    for _, e := range c.aps {
+        ap := e.ap
+        ap.Clients = sortedKeys(e.clients)
+        ap.Clock = e.clock.fit()
+        adjust(&ap.FirstSeen, &ap.LastSeen)
+        rpt.AccessPoints = append(rpt.AccessPoints, ap)
+    }
+    sort.Slice(rpt.AccessPoints, func(i, j int) bool {
+        a, b := rpt.AccessPoints[i], rpt.AccessPoints[j]
+        if a.SSID != b.SSID {
+            return a.SSID < b.SSID
+        }
+        return a.BSSID < b.BSSID
+    })
+
+    for _, e := range c.clients {
+        // access points answering probes are not clients
+        if _, ok := c.aps[e.client.MAC]; ok {
+            continue
+        }
+        cl := e.client
+        cl.BSSIDs = sortedKeys(e.bssids)
+        cl.Probes = sortedKeys(e.probes)
+        adjust(&cl.FirstSeen, &cl.LastSeen)
+        rpt.Clients = append(rpt.Clients, cl)
+    }
+    sort.Slice(rpt.Clients, func(i, j int) bool { return rpt.Clients[i].MAC < rpt.Clients[j].MAC })
+
+    for ssid, p := range c.probes {
+        rpt.Probes = append(rpt.Probes, Probe{ SSID: ssid, Clients: sortedKeys(p.clients), Requests: p.requests })
+    }
+    sort.Slice(rpt.Probes, func(i, j int) bool { return rpt.Probes[i].SSID < rpt.Probes[j].SSID })
+
+    return rpt
+}